@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// edit is one line of a diff: kept (' '), removed ('-') or added ('+')
+type edit struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff describes the changes turning before into after as a unified diff of the file name,
+// or returns "" if there are none
+func unifiedDiff(name, before, after string) string {
+	edits := diffLines(strings.SplitAfter(before, "\n"), strings.SplitAfter(after, "\n"))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+	hunks := 0
+	// line numbers in before and after at edits[i], counting from 1
+	beforeLine, afterLine := 1, 1
+	for i := 0; i < len(edits); {
+		if edits[i].kind == ' ' {
+			beforeLine++
+			afterLine++
+			i++
+			continue
+		}
+
+		// a hunk runs from the context before this change to the context after the last change that
+		// is close enough to share context with it
+		start := max(i-diffContext, 0)
+		end := i
+		for unchanged := 0; end < len(edits) && unchanged < 2*diffContext+1; end++ {
+			if edits[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > i && edits[end-1].kind == ' ' {
+			end--
+		}
+		end = min(end+diffContext, len(edits))
+
+		hunkBefore, hunkAfter := beforeLine-(i-start), afterLine-(i-start)
+		var removed, added int
+		for _, e := range edits[start:end] {
+			if e.kind != '+' {
+				removed++
+			}
+			if e.kind != '-' {
+				added++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(hunkBefore, removed), hunkRange(hunkAfter, added))
+		for _, e := range edits[start:end] {
+			out.WriteByte(e.kind)
+			out.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		hunks++
+
+		for _, e := range edits[i:end] {
+			if e.kind != '+' {
+				beforeLine++
+			}
+			if e.kind != '-' {
+				afterLine++
+			}
+		}
+		i = end
+	}
+	if hunks == 0 {
+		return ""
+	}
+	return out.String()
+}
+
+// hunkRange formats the first line and number of lines of a hunk; an empty range starts at the
+// line before it
+func hunkRange(first, count int) string {
+	if count == 0 {
+		first--
+	}
+	if count == 1 {
+		return fmt.Sprint(first)
+	}
+	return fmt.Sprintf("%d,%d", first, count)
+}
+
+// diffLines finds a shortest edit script from a to b through their longest common subsequence
+func diffLines(a, b []string) []edit {
+	// the lines split from a text ending in a newline end with an empty one
+	if len(a) > 0 && a[len(a)-1] == "" {
+		a = a[:len(a)-1]
+	}
+	if len(b) > 0 && b[len(b)-1] == "" {
+		b = b[:len(b)-1]
+	}
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{' ', line})
+	}
+
+	// common[i][j] is the length of the longest common subsequence of middleA[i:] and middleB[j:]
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	common := make([][]int, len(middleA)+1)
+	for i := range common {
+		common[i] = make([]int, len(middleB)+1)
+	}
+	for i := len(middleA) - 1; i >= 0; i-- {
+		for j := len(middleB) - 1; j >= 0; j-- {
+			if middleA[i] == middleB[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(middleA) || j < len(middleB) {
+		switch {
+		case i < len(middleA) && j < len(middleB) && middleA[i] == middleB[j]:
+			edits = append(edits, edit{' ', middleA[i]})
+			i++
+			j++
+		case j == len(middleB) || (i < len(middleA) && common[i+1][j] >= common[i][j+1]):
+			edits = append(edits, edit{'-', middleA[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', middleB[j]})
+			j++
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{' ', line})
+	}
+	return edits
+}
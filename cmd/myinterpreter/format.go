@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// formatOptions are the flags of the fmt command. Without any, formatted source is printed to stdout.
+type formatOptions struct {
+	// list files that aren't formatted instead of printing them
+	check bool
+	// print unified diffs instead of formatted source
+	diff bool
+	// rewrite files in place
+	write bool
+}
+
+// formatFiles runs the fmt command on files and returns the exit status: 65 if any file has errors,
+// 1 if checking found unformatted files, 0 otherwise
+func formatFiles(files []string, options formatOptions, errorFormat lox.ErrorFormat) int {
+	status := 0
+	for _, filename := range files {
+		contents, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			return 1
+		}
+		source := string(contents)
+		formatted, err := lox.Format(source)
+		if err != nil {
+			lox.WriteDiagnostics(os.Stderr, errorFormat, err, filename, source)
+			status = 65
+			continue
+		}
+
+		if !options.check && !options.diff && !options.write {
+			fmt.Print(formatted)
+			continue
+		}
+		if formatted == source {
+			continue
+		}
+		if options.check {
+			fmt.Println(filename)
+			if status == 0 {
+				status = 1
+			}
+		}
+		if options.diff {
+			fmt.Print(unifiedDiff(filename, source, formatted))
+		}
+		if options.write {
+			if err := os.WriteFile(filename, []byte(formatted), 0o644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+				return 1
+			}
+		}
+	}
+	return status
+}
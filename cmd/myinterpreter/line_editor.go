@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// errInterrupted is returned by ReadLine when the user presses Ctrl-C
+var errInterrupted = errors.New("interrupted")
+
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+	AddHistory(line string)
+	Close() error
+}
+
+// newLineReader returns an interactive editor when stdin is a terminal, and a plain reader otherwise
+// (e.g. when input is piped in)
+func newLineReader() lineReader {
+	if editor, err := newTerminalEditor(os.Stdin, os.Stdout); err == nil {
+		return editor
+	}
+	return &plainLineReader{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+}
+
+type plainLineReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (r *plainLineReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	line, err := r.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (r *plainLineReader) AddHistory(string) {}
+
+func (r *plainLineReader) Close() error {
+	return nil
+}
+
+// terminalEditor is a minimal line editor working on a terminal in raw mode: it supports cursor
+// movement, deletion and browsing the history with the arrow keys
+type terminalEditor struct {
+	term    *rawTerminal
+	in      *bufio.Reader
+	out     io.Writer
+	history []string
+}
+
+func newTerminalEditor(in *os.File, out io.Writer) (*terminalEditor, error) {
+	term, err := openRawTerminal(int(in.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	return &terminalEditor{term: term, in: bufio.NewReader(in), out: out}, nil
+}
+
+func (e *terminalEditor) AddHistory(line string) {
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+}
+
+func (e *terminalEditor) Close() error {
+	return nil
+}
+
+func (e *terminalEditor) ReadLine(prompt string) (string, error) {
+	if err := e.term.enable(); err != nil {
+		return "", err
+	}
+	defer e.term.restore()
+
+	var line []rune
+	cursor := 0
+	// historyIndex == len(history) is the line being typed, which is kept aside while browsing
+	historyIndex := len(e.history)
+	var pending []rune
+
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(line))
+		if back := len(line) - cursor; back > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", back)
+		}
+	}
+	showHistory := func(index int) {
+		if index < 0 || index > len(e.history) {
+			return
+		}
+		if historyIndex == len(e.history) {
+			pending = line
+		}
+		historyIndex = index
+		if index == len(e.history) {
+			line = pending
+		} else {
+			// multi-line entries are recalled joined on one line
+			line = []rune(strings.ReplaceAll(e.history[index], "\n", " "))
+		}
+		cursor = len(line)
+		redraw()
+	}
+
+	redraw()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(line), nil
+		case 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+			if cursor < len(line) {
+				line = append(line[:cursor], line[cursor+1:]...)
+			}
+		case 127, 8: // Backspace
+			if cursor > 0 {
+				line = append(line[:cursor-1], line[cursor:]...)
+				cursor--
+			}
+		case 1: // Ctrl-A
+			cursor = 0
+		case 5: // Ctrl-E
+			cursor = len(line)
+		case 21: // Ctrl-U
+			line = line[cursor:]
+			cursor = 0
+		case 27: // escape sequence
+			if next, _ := e.in.ReadByte(); next != '[' && next != 'O' {
+				break
+			}
+			switch key, _ := e.in.ReadByte(); key {
+			case 'A':
+				showHistory(historyIndex - 1)
+			case 'B':
+				showHistory(historyIndex + 1)
+			case 'C':
+				if cursor < len(line) {
+					cursor++
+				}
+			case 'D':
+				if cursor > 0 {
+					cursor--
+				}
+			case 'H':
+				cursor = 0
+			case 'F':
+				cursor = len(line)
+			case '3':
+				if tilde, _ := e.in.ReadByte(); tilde == '~' && cursor < len(line) {
+					line = append(line[:cursor], line[cursor+1:]...)
+				}
+			}
+		default:
+			if r < ' ' {
+				break
+			}
+			line = append(line[:cursor], append([]rune{r}, line[cursor:]...)...)
+			cursor++
+		}
+		redraw()
+	}
+}
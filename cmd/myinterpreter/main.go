@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"slices"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
 )
 
 const (
@@ -11,14 +17,31 @@ const (
 	parseCommand    = "parse"
 	evaluateCommand = "evaluate"
 	runCommand      = "run"
+	replCommand     = "repl"
+	fmtCommand      = "fmt"
+	dumpASTCommand  = "dump-ast"
 )
 
-var allowedCommands = []string{tokenizeCommand, parseCommand, evaluateCommand, runCommand}
+var allowedCommands = []string{tokenizeCommand, parseCommand, evaluateCommand, runCommand, replCommand, fmtCommand, dumpASTCommand}
+
+const (
+	astBackend = "ast"
+	vmBackend  = "vm"
+)
 
-var LoxHadError = false
-var LoxHadRuntimeError = false
+var errorFormats = map[string]lox.ErrorFormat{
+	"human": lox.HumanErrors,
+	"short": lox.ShortErrors,
+	"json":  lox.JSONErrors,
+}
 
 func main() {
+	// without arguments, start an interactive session
+	if len(os.Args) < 2 || os.Args[1] == replCommand {
+		NewRepl().Run()
+		return
+	}
+
 	// You can use print statements as follows for debugging, they'll be visible when running tests.
 	fmt.Fprintln(os.Stderr, "Logs from your program will appear here!")
 
@@ -34,54 +57,172 @@ func main() {
 		os.Exit(1)
 	}
 
-	filename := os.Args[2]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	backend := flags.String("backend", astBackend, "execution engine used by evaluate and run: ast (tree-walker) or vm (bytecode)")
+	disassemble := flags.Bool("disassemble", false, "print the compiled bytecode to stderr before running (vm backend only)")
+	errorFormatName := flags.String("error-format", "human", "how errors are reported: human (with source excerpts), short (one line each) or json")
+	modulePathList := flags.String("module-path", "", "directories searched for imported modules, separated by '"+string(filepath.ListSeparator)+"'; searched before $"+modulePathEnv)
+	check := flags.Bool("check", false, "fmt: list the files that aren't formatted and exit with status 1 if there are any")
+	diff := flags.Bool("diff", false, "fmt: print the changes formatting makes as unified diffs")
+	write := flags.Bool("w", false, "fmt: write formatted source back to the files")
+	program := flags.Bool("program", false, "parse: parse the whole file as a program rather than a single expression")
+	tree := flags.Bool("tree", false, "parse: print an indented tree of node types, fields and source lines instead of S-expressions")
+	astFormat := flags.String("format", "json", "dump-ast: how the AST is printed: json, sexpr (S-expressions) or tree (indented tree)")
+	fromAST := flags.Bool("ast", false, "run: the file holds a JSON AST written by dump-ast rather than source code")
+	flags.Parse(os.Args[2:])
+
+	if flags.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: ./your_program.sh %s [--backend=ast|vm] [--error-format=human|short|json] [--module-path=dirs] <filename>\n", allowedCommands)
+		fmt.Fprintf(os.Stderr, "       ./your_program.sh %s [--check] [--diff] [-w] <filename>...\n", fmtCommand)
+		os.Exit(1)
+	}
+	if *backend != astBackend && *backend != vmBackend {
+		fmt.Fprintf(os.Stderr, "Unknown backend: %s\n", *backend)
+		os.Exit(1)
+	}
+	errorFormat, ok := errorFormats[*errorFormatName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown error format: %s\n", *errorFormatName)
+		os.Exit(1)
+	}
+
+	if command == fmtCommand {
+		os.Exit(formatFiles(flags.Args(), formatOptions{check: *check, diff: *diff, write: *write}, errorFormat))
+	}
+
+	filename := flags.Arg(0)
 	fileContents, err := os.ReadFile(filename)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
 		os.Exit(1)
 	}
+	source := string(fileContents)
 
-	if len(fileContents) >= 0 {
-		scanner := Scanner{Source: []rune(string(fileContents))}
-		tokens := scanner.ScanTokens()
-		parser := Parser{Tokens: tokens}
-
-		prettyPrinter := &AstPrettyPrinter{}
-		interpreter := NewInterpreter()
+	options := []lox.Option{lox.WithErrorFormat(errorFormat), lox.WithSourceName(filename), lox.WithModulePath(modulePath(*modulePathList)...)}
+	if *backend == vmBackend {
+		options = append(options, lox.WithBackend(lox.Bytecode))
+	}
+	if *disassemble {
+		options = append(options, lox.WithDisassembly(os.Stderr))
+	}
+	interpreter := lox.New(options...)
+	ctx := context.Background()
 
-		switch command {
-		case tokenizeCommand:
-			for _, tok := range tokens {
-				fmt.Println(tok)
-			}
-		case parseCommand:
-			expr, err := parser.ParseExpr()
-			if err != nil {
-				fmt.Fprint(os.Stderr, err.Error())
-				break
-			}
-			prettyPrinter.PrintExpr(expr)
-		case evaluateCommand:
-			expr, err := parser.ParseExpr()
+	switch command {
+	case tokenizeCommand:
+		var tokens []lox.Token
+		tokens, err = lox.Scan(source)
+		if err != nil {
+			lox.WriteDiagnostics(os.Stderr, errorFormat, err, filename, source)
+		}
+		for _, tok := range tokens {
+			fmt.Println(tok)
+		}
+	case parseCommand:
+		prettyPrinter := &lox.AstPrettyPrinter{}
+		if *program {
+			var stmts []lox.Stmt
+			stmts, err = lox.Parse(source)
 			if err != nil {
-				fmt.Fprint(os.Stderr, err.Error())
-				break
+				lox.WriteDiagnostics(os.Stderr, errorFormat, err, filename, source)
 			}
-			interpreter.InterpretExpr(expr)
-		case runCommand:
-			stmts, err := parser.Parse()
-			if err != nil {
-				fmt.Fprint(os.Stderr, err.Error())
-				break
+			if *tree {
+				lox.PrintTree(os.Stdout, source, stmts)
+			} else {
+				prettyPrinter.Print(os.Stdout, stmts)
 			}
-			interpreter.Interpret(stmts)
+			break
 		}
 
-		if LoxHadError {
+		var expr lox.Expr
+		expr, err = lox.ParseExpr(source)
+		if err != nil {
+			lox.WriteDiagnostics(os.Stderr, errorFormat, err, filename, source)
+		}
+		if expr != nil && *tree {
+			lox.PrintExprTree(os.Stdout, source, expr)
+		} else if expr != nil {
+			prettyPrinter.PrintExpr(os.Stdout, expr)
+		}
+	case evaluateCommand:
+		var result interface{}
+		result, err = interpreter.EvalExpr(ctx, source)
+		if err == nil {
+			if result == nil {
+				fmt.Println("nil")
+			} else {
+				fmt.Println(result)
+			}
+		}
+	case runCommand:
+		if !*fromAST {
+			_, err = interpreter.Eval(ctx, source)
+			break
+		}
+		var stmts []lox.Stmt
+		stmts, err = lox.UnmarshalProgram(fileContents)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading AST: %v\n", err)
 			os.Exit(65)
 		}
-		if LoxHadRuntimeError {
-			os.Exit(70)
+		_, err = interpreter.Exec(ctx, stmts)
+	case dumpASTCommand:
+		var stmts []lox.Stmt
+		stmts, err = lox.Parse(source)
+		if err != nil {
+			lox.WriteDiagnostics(os.Stderr, errorFormat, err, filename, source)
+			break
+		}
+		switch *astFormat {
+		case "json":
+			var encoded []byte
+			encoded, err = lox.MarshalProgram(stmts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding AST: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(encoded))
+		case "sexpr":
+			prettyPrinter := &lox.AstPrettyPrinter{}
+			prettyPrinter.Print(os.Stdout, stmts)
+		case "tree":
+			lox.PrintTree(os.Stdout, source, stmts)
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown AST format: %s\n", *astFormat)
+			os.Exit(1)
+		}
+	}
+
+	os.Exit(exitCode(err))
+}
+
+// modulePathEnv names the environment variable listing the directories searched for imported modules
+const modulePathEnv = "LOX_PATH"
+
+// modulePath lists the directories of the --module-path flag followed by those of $LOX_PATH
+func modulePath(flagValue string) []string {
+	var dirs []string
+	for _, list := range []string{flagValue, os.Getenv(modulePathEnv)} {
+		for _, dir := range filepath.SplitList(list) {
+			if dir != "" {
+				dirs = append(dirs, dir)
+			}
 		}
 	}
+	return dirs
+}
+
+// exitCode maps static errors (found before running anything) to 65 and all other failures to 70
+func exitCode(err error) int {
+	var scanErr *lox.ScanError
+	var parseErr *lox.ParseError
+	var compileErr *lox.CompileError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &scanErr), errors.As(err, &parseErr), errors.As(err, &compileErr):
+		return 65
+	default:
+		return 70
+	}
 }
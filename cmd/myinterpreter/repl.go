@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+const (
+	replPrompt         = "> "
+	replContinuePrompt = "... "
+)
+
+const replHelp = `Enter Lox statements or expressions; the value of a bare expression is printed.
+Input continues on the next line while braces, parentheses or a string are left open.
+Meta-commands:
+  :help         show this message
+  :env          list the global variables and their values
+  :load <file>  run a file in the current session
+  :reset        discard all global state and start over
+  :quit         leave the REPL (or press Ctrl-D)
+Use the up/down arrow keys to browse the input history.`
+
+// Repl keeps a single interpreter alive across inputs, so globals and functions persist
+type Repl struct {
+	interpreter *lox.Interpreter
+	lines       lineReader
+}
+
+// replSourceName is the file name diagnostics give for REPL input
+const replSourceName = "<repl>"
+
+func NewRepl() *Repl {
+	return &Repl{
+		interpreter: newReplInterpreter(),
+		lines:       newLineReader(),
+	}
+}
+
+func newReplInterpreter() *lox.Interpreter {
+	return lox.New(lox.WithErrorFormat(lox.HumanErrors), lox.WithSourceName(replSourceName), lox.WithModulePath(modulePath("")...))
+}
+
+func (r *Repl) Run() {
+	defer r.lines.Close()
+	fmt.Println("Lox REPL; type :help for help.")
+
+	var input strings.Builder
+	for {
+		prompt := replPrompt
+		if input.Len() > 0 {
+			prompt = replContinuePrompt
+		}
+
+		line, err := r.lines.ReadLine(prompt)
+		if errors.Is(err, errInterrupted) {
+			// Ctrl-C abandons the current (possibly multi-line) input
+			input.Reset()
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			fmt.Println()
+			return
+		}
+
+		if input.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			r.lines.AddHistory(line)
+			if quit := r.metaCommand(strings.TrimSpace(line)); quit {
+				return
+			}
+			continue
+		}
+
+		input.WriteString(line)
+		input.WriteString("\n")
+		if strings.TrimSpace(input.String()) == "" {
+			input.Reset()
+			continue
+		}
+		if !isInputComplete(input.String()) {
+			continue
+		}
+
+		source := input.String()
+		input.Reset()
+		r.lines.AddHistory(strings.TrimRight(source, "\n"))
+		r.run(source)
+	}
+}
+
+func (r *Repl) metaCommand(line string) (quit bool) {
+	command, argument, _ := strings.Cut(line, " ")
+	argument = strings.TrimSpace(argument)
+
+	switch command {
+	case ":help":
+		fmt.Println(replHelp)
+	case ":env":
+		globals := r.interpreter.Globals()
+		names := make([]string, 0, len(globals))
+		for name := range globals {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s = %s\n", name, lox.Stringify(globals[name]))
+		}
+	case ":load":
+		if argument == "" {
+			fmt.Fprintln(os.Stderr, "Usage: :load <file>")
+			break
+		}
+		err := r.interpreter.RunFile(context.Background(), argument)
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			// script errors were already reported by the interpreter
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		}
+	case ":reset":
+		r.interpreter = newReplInterpreter()
+		fmt.Println("Session reset.")
+	case ":quit", ":exit":
+		return true
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s (type :help for a list)\n", command)
+	}
+	return false
+}
+
+// run executes source in the session; errors are reported by the interpreter and the session keeps going
+func (r *Repl) run(source string) {
+	stmts, err := lox.Parse(source)
+	if err != nil {
+		// a lone expression may omit its trailing semicolon
+		withSemicolon, retryErr := lox.Parse(source + ";")
+		if retryErr != nil {
+			lox.WriteDiagnostics(os.Stderr, lox.HumanErrors, err, replSourceName, source)
+			return
+		}
+		stmts = withSemicolon
+		source += ";"
+	}
+
+	// Eval parses the source once more, so that diagnostics can quote it
+	result, err := r.interpreter.Eval(context.Background(), source)
+	if err != nil || len(stmts) == 0 {
+		return
+	}
+
+	if _, ok := stmts[len(stmts)-1].(*lox.ExpressionStmt); ok {
+		fmt.Println(lox.Stringify(result))
+	}
+}
+
+// isInputComplete reports whether every brace, bracket, parenthesis, string and block comment opened in
+// source is closed
+func isInputComplete(source string) bool {
+	depth := 0
+	inString := false
+	// for every "${" not closed yet, innermost last, the depth its embedded expression starts at
+	var interpolations []int
+	runes := []rune(source)
+	for i := 0; i < len(runes); i++ {
+		switch {
+		case inString:
+			switch {
+			case runes[i] == '\\':
+				i++
+			case runes[i] == '"':
+				inString = false
+			case runes[i] == '$' && i+1 < len(runes) && runes[i+1] == '{':
+				i++
+				interpolations = append(interpolations, depth)
+				inString = false
+			}
+		case runes[i] == '"':
+			inString = true
+		case runes[i] == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case runes[i] == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			// block comments nest
+			comments := 1
+			for i += 2; comments > 0; i++ {
+				if i+1 >= len(runes) {
+					return false
+				}
+				if runes[i] == '/' && runes[i+1] == '*' {
+					comments++
+					i++
+				} else if runes[i] == '*' && runes[i+1] == '/' {
+					comments--
+					i++
+				}
+			}
+			i--
+		case runes[i] == '}' && len(interpolations) > 0 && interpolations[len(interpolations)-1] == depth:
+			interpolations = interpolations[:len(interpolations)-1]
+			inString = true
+		case runes[i] == '(' || runes[i] == '{' || runes[i] == '[':
+			depth++
+		case runes[i] == ')' || runes[i] == '}' || runes[i] == ']':
+			depth--
+		}
+	}
+	return !inString && len(interpolations) == 0 && depth <= 0
+}
@@ -0,0 +1,45 @@
+//go:build linux
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// rawTerminal switches a terminal between its original mode and the raw mode used by the line editor
+type rawTerminal struct {
+	fd       int
+	original syscall.Termios
+}
+
+// openRawTerminal fails when fd is not a terminal
+func openRawTerminal(fd int) (*rawTerminal, error) {
+	term := &rawTerminal{fd: fd}
+	if err := ioctlTermios(fd, syscall.TCGETS, &term.original); err != nil {
+		return nil, err
+	}
+	return term, nil
+}
+
+func (t *rawTerminal) enable() error {
+	raw := t.original
+	raw.Iflag &^= syscall.ICRNL | syscall.IXON | syscall.BRKINT | syscall.INPCK | syscall.ISTRIP
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	return ioctlTermios(t.fd, syscall.TCSETS, &raw)
+}
+
+func (t *rawTerminal) restore() error {
+	return ioctlTermios(t.fd, syscall.TCSETS, &t.original)
+}
+
+func ioctlTermios(fd int, request uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// rawTerminal is only implemented on Linux; elsewhere the REPL reads plain lines without history navigation
+type rawTerminal struct{}
+
+func openRawTerminal(fd int) (*rawTerminal, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+func (t *rawTerminal) enable() error {
+	return nil
+}
+
+func (t *rawTerminal) restore() error {
+	return nil
+}
@@ -0,0 +1,4921 @@
+// Code generated by ast_codegen.go; DO NOT EDIT.
+
+package lox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// define the base Expr (5.2.2 Metaprogramming the trees)
+type Expr interface {
+	// define the abstract accept() function (5.3.3 Visitors for expressions)
+	Accept(visitor ExprVisitor) (result interface{}, err error)
+	// Span is the part of the source the node was parsed from
+	Span() Span
+}
+
+// define the visitor interface (5.3.3 Visitors for expressions)
+type ExprVisitor interface {
+	VisitBinaryExpr(v *BinaryExpr) (result interface{}, err error)
+
+	VisitUnaryExpr(v *UnaryExpr) (result interface{}, err error)
+
+	VisitGroupingExpr(v *GroupingExpr) (result interface{}, err error)
+
+	VisitLiteralExpr(v *LiteralExpr) (result interface{}, err error)
+
+	VisitVariableExpr(v *VariableExpr) (result interface{}, err error)
+
+	VisitAssignExpr(v *AssignExpr) (result interface{}, err error)
+
+	VisitLogicalExpr(v *LogicalExpr) (result interface{}, err error)
+
+	VisitCallExpr(v *CallExpr) (result interface{}, err error)
+
+	VisitGetExpr(v *GetExpr) (result interface{}, err error)
+
+	VisitSetExpr(v *SetExpr) (result interface{}, err error)
+
+	VisitThisExpr(v *ThisExpr) (result interface{}, err error)
+
+	VisitSuperExpr(v *SuperExpr) (result interface{}, err error)
+
+	VisitLambdaExpr(v *LambdaExpr) (result interface{}, err error)
+
+	VisitListExpr(v *ListExpr) (result interface{}, err error)
+
+	VisitMapExpr(v *MapExpr) (result interface{}, err error)
+
+	VisitIndexExpr(v *IndexExpr) (result interface{}, err error)
+
+	VisitIndexSetExpr(v *IndexSetExpr) (result interface{}, err error)
+
+	VisitSliceExpr(v *SliceExpr) (result interface{}, err error)
+
+	VisitInterpolationExpr(v *InterpolationExpr) (result interface{}, err error)
+
+	VisitCompoundExpr(v *CompoundExpr) (result interface{}, err error)
+
+	VisitIncrementExpr(v *IncrementExpr) (result interface{}, err error)
+
+	VisitConditionalExpr(v *ConditionalExpr) (result interface{}, err error)
+}
+
+type StubExprVisitor struct{}
+
+// type assertion to ensure stub implements all
+var _ ExprVisitor = StubExprVisitor{}
+
+func (s StubExprVisitor) VisitBinaryExpr(_ *BinaryExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for BinaryExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitUnaryExpr(_ *UnaryExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for UnaryExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitGroupingExpr(_ *GroupingExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for GroupingExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitLiteralExpr(_ *LiteralExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for LiteralExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitVariableExpr(_ *VariableExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for VariableExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitAssignExpr(_ *AssignExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for AssignExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitLogicalExpr(_ *LogicalExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for LogicalExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitCallExpr(_ *CallExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for CallExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitGetExpr(_ *GetExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for GetExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitSetExpr(_ *SetExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for SetExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitThisExpr(_ *ThisExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for ThisExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitSuperExpr(_ *SuperExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for SuperExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitLambdaExpr(_ *LambdaExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for LambdaExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitListExpr(_ *ListExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for ListExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitMapExpr(_ *MapExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for MapExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitIndexExpr(_ *IndexExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for IndexExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitIndexSetExpr(_ *IndexSetExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for IndexSetExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitSliceExpr(_ *SliceExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for SliceExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitInterpolationExpr(_ *InterpolationExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for InterpolationExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitCompoundExpr(_ *CompoundExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for CompoundExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitIncrementExpr(_ *IncrementExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for IncrementExpr is not implemented")
+}
+
+func (s StubExprVisitor) VisitConditionalExpr(_ *ConditionalExpr) (result interface{}, err error) {
+	return nil, errors.New("visit func for ConditionalExpr is not implemented")
+}
+
+// define the subtype Binary (5.2.2 Metaprogramming the trees)
+type BinaryExpr struct {
+	left Expr
+
+	operator Token
+
+	right Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *BinaryExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitBinaryExpr(b)
+}
+
+func (b *BinaryExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*BinaryExpr)(nil)
+
+// define the subtype Unary (5.2.2 Metaprogramming the trees)
+type UnaryExpr struct {
+	operator Token
+
+	right Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *UnaryExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitUnaryExpr(b)
+}
+
+func (b *UnaryExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*UnaryExpr)(nil)
+
+// define the subtype Grouping (5.2.2 Metaprogramming the trees)
+type GroupingExpr struct {
+	expr Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *GroupingExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitGroupingExpr(b)
+}
+
+func (b *GroupingExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*GroupingExpr)(nil)
+
+// define the subtype Literal (5.2.2 Metaprogramming the trees)
+type LiteralExpr struct {
+	value interface{}
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *LiteralExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitLiteralExpr(b)
+}
+
+func (b *LiteralExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*LiteralExpr)(nil)
+
+// define the subtype Variable (5.2.2 Metaprogramming the trees)
+type VariableExpr struct {
+	variableName Token
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *VariableExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitVariableExpr(b)
+}
+
+func (b *VariableExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*VariableExpr)(nil)
+
+// define the subtype Assign (5.2.2 Metaprogramming the trees)
+type AssignExpr struct {
+	variableName Token
+
+	assignValue Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *AssignExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitAssignExpr(b)
+}
+
+func (b *AssignExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*AssignExpr)(nil)
+
+// define the subtype Logical (5.2.2 Metaprogramming the trees)
+type LogicalExpr struct {
+	left Expr
+
+	operator Token
+
+	right Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *LogicalExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitLogicalExpr(b)
+}
+
+func (b *LogicalExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*LogicalExpr)(nil)
+
+// define the subtype Call (5.2.2 Metaprogramming the trees)
+type CallExpr struct {
+	callee Expr
+
+	arguments []Expr
+
+	closingParen Token
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *CallExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitCallExpr(b)
+}
+
+func (b *CallExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*CallExpr)(nil)
+
+// define the subtype Get (5.2.2 Metaprogramming the trees)
+type GetExpr struct {
+	object Expr
+
+	name Token
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *GetExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitGetExpr(b)
+}
+
+func (b *GetExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*GetExpr)(nil)
+
+// define the subtype Set (5.2.2 Metaprogramming the trees)
+type SetExpr struct {
+	object Expr
+
+	name Token
+
+	value Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *SetExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitSetExpr(b)
+}
+
+func (b *SetExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*SetExpr)(nil)
+
+// define the subtype This (5.2.2 Metaprogramming the trees)
+type ThisExpr struct {
+	keyword Token
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ThisExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitThisExpr(b)
+}
+
+func (b *ThisExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*ThisExpr)(nil)
+
+// define the subtype Super (5.2.2 Metaprogramming the trees)
+type SuperExpr struct {
+	keyword Token
+
+	method Token
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *SuperExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitSuperExpr(b)
+}
+
+func (b *SuperExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*SuperExpr)(nil)
+
+// define the subtype Lambda (5.2.2 Metaprogramming the trees)
+type LambdaExpr struct {
+	keyword Token
+
+	parameters []Token
+
+	body []Stmt
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *LambdaExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitLambdaExpr(b)
+}
+
+func (b *LambdaExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*LambdaExpr)(nil)
+
+// define the subtype List (5.2.2 Metaprogramming the trees)
+type ListExpr struct {
+	elements []Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ListExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitListExpr(b)
+}
+
+func (b *ListExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*ListExpr)(nil)
+
+// define the subtype Map (5.2.2 Metaprogramming the trees)
+type MapExpr struct {
+	brace Token
+
+	keys []Expr
+
+	values []Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *MapExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitMapExpr(b)
+}
+
+func (b *MapExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*MapExpr)(nil)
+
+// define the subtype Index (5.2.2 Metaprogramming the trees)
+type IndexExpr struct {
+	object Expr
+
+	bracket Token
+
+	index Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *IndexExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitIndexExpr(b)
+}
+
+func (b *IndexExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*IndexExpr)(nil)
+
+// define the subtype IndexSet (5.2.2 Metaprogramming the trees)
+type IndexSetExpr struct {
+	object Expr
+
+	bracket Token
+
+	index Expr
+
+	value Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *IndexSetExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitIndexSetExpr(b)
+}
+
+func (b *IndexSetExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*IndexSetExpr)(nil)
+
+// define the subtype Slice (5.2.2 Metaprogramming the trees)
+type SliceExpr struct {
+	object Expr
+
+	bracket Token
+
+	start Expr
+
+	end Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *SliceExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitSliceExpr(b)
+}
+
+func (b *SliceExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*SliceExpr)(nil)
+
+// define the subtype Interpolation (5.2.2 Metaprogramming the trees)
+type InterpolationExpr struct {
+	parts []Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *InterpolationExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitInterpolationExpr(b)
+}
+
+func (b *InterpolationExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*InterpolationExpr)(nil)
+
+// define the subtype Compound (5.2.2 Metaprogramming the trees)
+type CompoundExpr struct {
+	target Expr
+
+	operator Token
+
+	value Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *CompoundExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitCompoundExpr(b)
+}
+
+func (b *CompoundExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*CompoundExpr)(nil)
+
+// define the subtype Increment (5.2.2 Metaprogramming the trees)
+type IncrementExpr struct {
+	target Expr
+
+	operator Token
+
+	prefix bool
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *IncrementExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitIncrementExpr(b)
+}
+
+func (b *IncrementExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*IncrementExpr)(nil)
+
+// define the subtype Conditional (5.2.2 Metaprogramming the trees)
+type ConditionalExpr struct {
+	condition Expr
+
+	thenBranch Expr
+
+	elseBranch Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ConditionalExpr) Accept(visitor ExprVisitor) (result interface{}, err error) {
+	return visitor.VisitConditionalExpr(b)
+}
+
+func (b *ConditionalExpr) Span() Span {
+	return b.span
+}
+
+var _ Expr = (*ConditionalExpr)(nil)
+
+// define the base Stmt (5.2.2 Metaprogramming the trees)
+type Stmt interface {
+	// define the abstract accept() function (5.3.3 Visitors for expressions)
+	Accept(visitor StmtVisitor) (result interface{}, err error)
+	// Span is the part of the source the node was parsed from
+	Span() Span
+}
+
+// define the visitor interface (5.3.3 Visitors for expressions)
+type StmtVisitor interface {
+	VisitExpressionStmt(v *ExpressionStmt) (result interface{}, err error)
+
+	VisitPrintStmt(v *PrintStmt) (result interface{}, err error)
+
+	VisitVarStmt(v *VarStmt) (result interface{}, err error)
+
+	VisitFunctionStmt(v *FunctionStmt) (result interface{}, err error)
+
+	VisitReturnStmt(v *ReturnStmt) (result interface{}, err error)
+
+	VisitBlockStmt(v *BlockStmt) (result interface{}, err error)
+
+	VisitIfStmt(v *IfStmt) (result interface{}, err error)
+
+	VisitWhileStmt(v *WhileStmt) (result interface{}, err error)
+
+	VisitForStmt(v *ForStmt) (result interface{}, err error)
+
+	VisitBreakStmt(v *BreakStmt) (result interface{}, err error)
+
+	VisitContinueStmt(v *ContinueStmt) (result interface{}, err error)
+
+	VisitThrowStmt(v *ThrowStmt) (result interface{}, err error)
+
+	VisitTryStmt(v *TryStmt) (result interface{}, err error)
+
+	VisitImportStmt(v *ImportStmt) (result interface{}, err error)
+
+	VisitExportStmt(v *ExportStmt) (result interface{}, err error)
+
+	VisitClassStmt(v *ClassStmt) (result interface{}, err error)
+}
+
+type StubStmtVisitor struct{}
+
+// type assertion to ensure stub implements all
+var _ StmtVisitor = StubStmtVisitor{}
+
+func (s StubStmtVisitor) VisitExpressionStmt(_ *ExpressionStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for ExpressionStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitPrintStmt(_ *PrintStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for PrintStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitVarStmt(_ *VarStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for VarStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitFunctionStmt(_ *FunctionStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for FunctionStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitReturnStmt(_ *ReturnStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for ReturnStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitBlockStmt(_ *BlockStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for BlockStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitIfStmt(_ *IfStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for IfStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitWhileStmt(_ *WhileStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for WhileStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitForStmt(_ *ForStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for ForStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitBreakStmt(_ *BreakStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for BreakStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitContinueStmt(_ *ContinueStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for ContinueStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitThrowStmt(_ *ThrowStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for ThrowStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitTryStmt(_ *TryStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for TryStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitImportStmt(_ *ImportStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for ImportStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitExportStmt(_ *ExportStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for ExportStmt is not implemented")
+}
+
+func (s StubStmtVisitor) VisitClassStmt(_ *ClassStmt) (result interface{}, err error) {
+	return nil, errors.New("visit func for ClassStmt is not implemented")
+}
+
+// define the subtype Expression (5.2.2 Metaprogramming the trees)
+type ExpressionStmt struct {
+	expression Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ExpressionStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitExpressionStmt(b)
+}
+
+func (b *ExpressionStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*ExpressionStmt)(nil)
+
+// define the subtype Print (5.2.2 Metaprogramming the trees)
+type PrintStmt struct {
+	expression Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *PrintStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitPrintStmt(b)
+}
+
+func (b *PrintStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*PrintStmt)(nil)
+
+// define the subtype Var (5.2.2 Metaprogramming the trees)
+type VarStmt struct {
+	varName Token
+
+	initializerExpression Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *VarStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitVarStmt(b)
+}
+
+func (b *VarStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*VarStmt)(nil)
+
+// define the subtype Function (5.2.2 Metaprogramming the trees)
+type FunctionStmt struct {
+	name Token
+
+	parameters []Token
+
+	body []Stmt
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *FunctionStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitFunctionStmt(b)
+}
+
+func (b *FunctionStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*FunctionStmt)(nil)
+
+// define the subtype Return (5.2.2 Metaprogramming the trees)
+type ReturnStmt struct {
+	keyword Token
+
+	value Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ReturnStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitReturnStmt(b)
+}
+
+func (b *ReturnStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*ReturnStmt)(nil)
+
+// define the subtype Block (5.2.2 Metaprogramming the trees)
+type BlockStmt struct {
+	statements []Stmt
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *BlockStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitBlockStmt(b)
+}
+
+func (b *BlockStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*BlockStmt)(nil)
+
+// define the subtype If (5.2.2 Metaprogramming the trees)
+type IfStmt struct {
+	condition Expr
+
+	thenBranch Stmt
+
+	elseBranch Stmt
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *IfStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitIfStmt(b)
+}
+
+func (b *IfStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*IfStmt)(nil)
+
+// define the subtype While (5.2.2 Metaprogramming the trees)
+type WhileStmt struct {
+	condition Expr
+
+	loopBody Stmt
+
+	label *Token
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *WhileStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitWhileStmt(b)
+}
+
+func (b *WhileStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*WhileStmt)(nil)
+
+// define the subtype For (5.2.2 Metaprogramming the trees)
+type ForStmt struct {
+	init Stmt
+
+	condition Expr
+
+	iteration Expr
+
+	loopBody Stmt
+
+	label *Token
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ForStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitForStmt(b)
+}
+
+func (b *ForStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*ForStmt)(nil)
+
+// define the subtype Break (5.2.2 Metaprogramming the trees)
+type BreakStmt struct {
+	keyword Token
+
+	label *Token
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *BreakStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitBreakStmt(b)
+}
+
+func (b *BreakStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*BreakStmt)(nil)
+
+// define the subtype Continue (5.2.2 Metaprogramming the trees)
+type ContinueStmt struct {
+	keyword Token
+
+	label *Token
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ContinueStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitContinueStmt(b)
+}
+
+func (b *ContinueStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*ContinueStmt)(nil)
+
+// define the subtype Throw (5.2.2 Metaprogramming the trees)
+type ThrowStmt struct {
+	keyword Token
+
+	value Expr
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ThrowStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitThrowStmt(b)
+}
+
+func (b *ThrowStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*ThrowStmt)(nil)
+
+// define the subtype Try (5.2.2 Metaprogramming the trees)
+type TryStmt struct {
+	keyword Token
+
+	body Stmt
+
+	catchName *Token
+
+	catchBody Stmt
+
+	finallyBody Stmt
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *TryStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitTryStmt(b)
+}
+
+func (b *TryStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*TryStmt)(nil)
+
+// define the subtype Import (5.2.2 Metaprogramming the trees)
+type ImportStmt struct {
+	keyword Token
+
+	path Token
+
+	alias *Token
+
+	names []Token
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ImportStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitImportStmt(b)
+}
+
+func (b *ImportStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*ImportStmt)(nil)
+
+// define the subtype Export (5.2.2 Metaprogramming the trees)
+type ExportStmt struct {
+	keyword Token
+
+	declaration Stmt
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ExportStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitExportStmt(b)
+}
+
+func (b *ExportStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*ExportStmt)(nil)
+
+// define the subtype Class (5.2.2 Metaprogramming the trees)
+type ClassStmt struct {
+	name Token
+
+	superclass *VariableExpr
+
+	methods []*FunctionStmt
+
+	span Span
+}
+
+// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+func (b *ClassStmt) Accept(visitor StmtVisitor) (result interface{}, err error) {
+	return visitor.VisitClassStmt(b)
+}
+
+func (b *ClassStmt) Span() Span {
+	return b.span
+}
+
+var _ Stmt = (*ClassStmt)(nil)
+
+// decodeExpr reconstructs a Expr from its JSON object, whose "type" names the node type; null decodes to nil
+func decodeExpr(data json.RawMessage) (Expr, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var tag struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return nil, err
+	}
+
+	var node Expr
+	switch tag.Type {
+
+	case "BinaryExpr":
+		node = &BinaryExpr{}
+
+	case "UnaryExpr":
+		node = &UnaryExpr{}
+
+	case "GroupingExpr":
+		node = &GroupingExpr{}
+
+	case "LiteralExpr":
+		node = &LiteralExpr{}
+
+	case "VariableExpr":
+		node = &VariableExpr{}
+
+	case "AssignExpr":
+		node = &AssignExpr{}
+
+	case "LogicalExpr":
+		node = &LogicalExpr{}
+
+	case "CallExpr":
+		node = &CallExpr{}
+
+	case "GetExpr":
+		node = &GetExpr{}
+
+	case "SetExpr":
+		node = &SetExpr{}
+
+	case "ThisExpr":
+		node = &ThisExpr{}
+
+	case "SuperExpr":
+		node = &SuperExpr{}
+
+	case "LambdaExpr":
+		node = &LambdaExpr{}
+
+	case "ListExpr":
+		node = &ListExpr{}
+
+	case "MapExpr":
+		node = &MapExpr{}
+
+	case "IndexExpr":
+		node = &IndexExpr{}
+
+	case "IndexSetExpr":
+		node = &IndexSetExpr{}
+
+	case "SliceExpr":
+		node = &SliceExpr{}
+
+	case "InterpolationExpr":
+		node = &InterpolationExpr{}
+
+	case "CompoundExpr":
+		node = &CompoundExpr{}
+
+	case "IncrementExpr":
+		node = &IncrementExpr{}
+
+	case "ConditionalExpr":
+		node = &ConditionalExpr{}
+
+	default:
+		return nil, fmt.Errorf("unknown Expr type %q", tag.Type)
+	}
+	if err := json.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// decodeExprs decodes a list of nodes, none of which may be null
+func decodeExprs(data []json.RawMessage) ([]Expr, error) {
+	if data == nil {
+		return nil, nil
+	}
+	nodes := make([]Expr, len(data))
+	for i, item := range data {
+		node, err := decodeExpr(item)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		if node == nil {
+			return nil, fmt.Errorf("element %d is null", i)
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
+
+// decodeExprAs decodes a field holding a particular type of Expr
+func decodeExprAs[T Expr](data json.RawMessage) (T, error) {
+	var typed T
+	node, err := decodeExpr(data)
+	if err != nil || node == nil {
+		return typed, err
+	}
+	typed, ok := node.(T)
+	if !ok {
+		return typed, fmt.Errorf("expected %T, got %T", typed, node)
+	}
+	return typed, nil
+}
+
+func decodeExprsAs[T Expr](data []json.RawMessage) ([]T, error) {
+	nodes, err := decodeExprs(data)
+	if err != nil || nodes == nil {
+		return nil, err
+	}
+	typed := make([]T, len(nodes))
+	for i, node := range nodes {
+		var ok bool
+		if typed[i], ok = node.(T); !ok {
+			return nil, fmt.Errorf("element %d: expected %T, got %T", i, typed[i], node)
+		}
+	}
+	return typed, nil
+}
+
+func (b *BinaryExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Left Expr `json:"left"`
+
+		Operator Token `json:"operator"`
+
+		Right Expr `json:"right"`
+	}{"BinaryExpr", b.span, b.left, b.operator, b.right})
+}
+
+func (b *BinaryExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Left json.RawMessage `json:"left"`
+
+		Operator Token `json:"operator"`
+
+		Right json.RawMessage `json:"right"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("BinaryExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.left, err = decodeExpr(fields.Left); err != nil {
+		return fmt.Errorf("BinaryExpr.left: %w", err)
+	}
+
+	if b.left == nil {
+		return errors.New("BinaryExpr.left is missing")
+	}
+
+	b.operator = fields.Operator
+
+	if b.right, err = decodeExpr(fields.Right); err != nil {
+		return fmt.Errorf("BinaryExpr.right: %w", err)
+	}
+
+	if b.right == nil {
+		return errors.New("BinaryExpr.right is missing")
+	}
+
+	return nil
+}
+
+func (b *UnaryExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Operator Token `json:"operator"`
+
+		Right Expr `json:"right"`
+	}{"UnaryExpr", b.span, b.operator, b.right})
+}
+
+func (b *UnaryExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Operator Token `json:"operator"`
+
+		Right json.RawMessage `json:"right"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("UnaryExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.operator = fields.Operator
+
+	if b.right, err = decodeExpr(fields.Right); err != nil {
+		return fmt.Errorf("UnaryExpr.right: %w", err)
+	}
+
+	if b.right == nil {
+		return errors.New("UnaryExpr.right is missing")
+	}
+
+	return nil
+}
+
+func (b *GroupingExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Expr Expr `json:"expr"`
+	}{"GroupingExpr", b.span, b.expr})
+}
+
+func (b *GroupingExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Expr json.RawMessage `json:"expr"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("GroupingExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.expr, err = decodeExpr(fields.Expr); err != nil {
+		return fmt.Errorf("GroupingExpr.expr: %w", err)
+	}
+
+	if b.expr == nil {
+		return errors.New("GroupingExpr.expr is missing")
+	}
+
+	return nil
+}
+
+func (b *LiteralExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Value interface{} `json:"value"`
+	}{"LiteralExpr", b.span, b.value})
+}
+
+func (b *LiteralExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("LiteralExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	b.value = fields.Value
+
+	return nil
+}
+
+func (b *VariableExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		VariableName Token `json:"variableName"`
+	}{"VariableExpr", b.span, b.variableName})
+}
+
+func (b *VariableExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		VariableName Token `json:"variableName"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("VariableExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	b.variableName = fields.VariableName
+
+	return nil
+}
+
+func (b *AssignExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		VariableName Token `json:"variableName"`
+
+		AssignValue Expr `json:"assignValue"`
+	}{"AssignExpr", b.span, b.variableName, b.assignValue})
+}
+
+func (b *AssignExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		VariableName Token `json:"variableName"`
+
+		AssignValue json.RawMessage `json:"assignValue"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("AssignExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.variableName = fields.VariableName
+
+	if b.assignValue, err = decodeExpr(fields.AssignValue); err != nil {
+		return fmt.Errorf("AssignExpr.assignValue: %w", err)
+	}
+
+	if b.assignValue == nil {
+		return errors.New("AssignExpr.assignValue is missing")
+	}
+
+	return nil
+}
+
+func (b *LogicalExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Left Expr `json:"left"`
+
+		Operator Token `json:"operator"`
+
+		Right Expr `json:"right"`
+	}{"LogicalExpr", b.span, b.left, b.operator, b.right})
+}
+
+func (b *LogicalExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Left json.RawMessage `json:"left"`
+
+		Operator Token `json:"operator"`
+
+		Right json.RawMessage `json:"right"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("LogicalExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.left, err = decodeExpr(fields.Left); err != nil {
+		return fmt.Errorf("LogicalExpr.left: %w", err)
+	}
+
+	if b.left == nil {
+		return errors.New("LogicalExpr.left is missing")
+	}
+
+	b.operator = fields.Operator
+
+	if b.right, err = decodeExpr(fields.Right); err != nil {
+		return fmt.Errorf("LogicalExpr.right: %w", err)
+	}
+
+	if b.right == nil {
+		return errors.New("LogicalExpr.right is missing")
+	}
+
+	return nil
+}
+
+func (b *CallExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Callee Expr `json:"callee"`
+
+		Arguments []Expr `json:"arguments"`
+
+		ClosingParen Token `json:"closingParen"`
+	}{"CallExpr", b.span, b.callee, b.arguments, b.closingParen})
+}
+
+func (b *CallExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Callee json.RawMessage `json:"callee"`
+
+		Arguments []json.RawMessage `json:"arguments"`
+
+		ClosingParen Token `json:"closingParen"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("CallExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.callee, err = decodeExpr(fields.Callee); err != nil {
+		return fmt.Errorf("CallExpr.callee: %w", err)
+	}
+
+	if b.callee == nil {
+		return errors.New("CallExpr.callee is missing")
+	}
+
+	if b.arguments, err = decodeExprs(fields.Arguments); err != nil {
+		return fmt.Errorf("CallExpr.arguments: %w", err)
+	}
+
+	b.closingParen = fields.ClosingParen
+
+	return nil
+}
+
+func (b *GetExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Object Expr `json:"object"`
+
+		Name Token `json:"name"`
+	}{"GetExpr", b.span, b.object, b.name})
+}
+
+func (b *GetExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Object json.RawMessage `json:"object"`
+
+		Name Token `json:"name"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("GetExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.object, err = decodeExpr(fields.Object); err != nil {
+		return fmt.Errorf("GetExpr.object: %w", err)
+	}
+
+	if b.object == nil {
+		return errors.New("GetExpr.object is missing")
+	}
+
+	b.name = fields.Name
+
+	return nil
+}
+
+func (b *SetExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Object Expr `json:"object"`
+
+		Name Token `json:"name"`
+
+		Value Expr `json:"value"`
+	}{"SetExpr", b.span, b.object, b.name, b.value})
+}
+
+func (b *SetExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Object json.RawMessage `json:"object"`
+
+		Name Token `json:"name"`
+
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("SetExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.object, err = decodeExpr(fields.Object); err != nil {
+		return fmt.Errorf("SetExpr.object: %w", err)
+	}
+
+	if b.object == nil {
+		return errors.New("SetExpr.object is missing")
+	}
+
+	b.name = fields.Name
+
+	if b.value, err = decodeExpr(fields.Value); err != nil {
+		return fmt.Errorf("SetExpr.value: %w", err)
+	}
+
+	if b.value == nil {
+		return errors.New("SetExpr.value is missing")
+	}
+
+	return nil
+}
+
+func (b *ThisExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Keyword Token `json:"keyword"`
+	}{"ThisExpr", b.span, b.keyword})
+}
+
+func (b *ThisExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Keyword Token `json:"keyword"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ThisExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	b.keyword = fields.Keyword
+
+	return nil
+}
+
+func (b *SuperExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Method Token `json:"method"`
+	}{"SuperExpr", b.span, b.keyword, b.method})
+}
+
+func (b *SuperExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Method Token `json:"method"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("SuperExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	b.keyword = fields.Keyword
+
+	b.method = fields.Method
+
+	return nil
+}
+
+func (b *LambdaExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Parameters []Token `json:"parameters"`
+
+		Body []Stmt `json:"body"`
+	}{"LambdaExpr", b.span, b.keyword, b.parameters, b.body})
+}
+
+func (b *LambdaExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Parameters []Token `json:"parameters"`
+
+		Body []json.RawMessage `json:"body"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("LambdaExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.keyword = fields.Keyword
+
+	b.parameters = fields.Parameters
+
+	if b.body, err = decodeStmts(fields.Body); err != nil {
+		return fmt.Errorf("LambdaExpr.body: %w", err)
+	}
+
+	return nil
+}
+
+func (b *ListExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Elements []Expr `json:"elements"`
+	}{"ListExpr", b.span, b.elements})
+}
+
+func (b *ListExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Elements []json.RawMessage `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ListExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.elements, err = decodeExprs(fields.Elements); err != nil {
+		return fmt.Errorf("ListExpr.elements: %w", err)
+	}
+
+	return nil
+}
+
+func (b *MapExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Brace Token `json:"brace"`
+
+		Keys []Expr `json:"keys"`
+
+		Values []Expr `json:"values"`
+	}{"MapExpr", b.span, b.brace, b.keys, b.values})
+}
+
+func (b *MapExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Brace Token `json:"brace"`
+
+		Keys []json.RawMessage `json:"keys"`
+
+		Values []json.RawMessage `json:"values"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("MapExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.brace = fields.Brace
+
+	if b.keys, err = decodeExprs(fields.Keys); err != nil {
+		return fmt.Errorf("MapExpr.keys: %w", err)
+	}
+
+	if b.values, err = decodeExprs(fields.Values); err != nil {
+		return fmt.Errorf("MapExpr.values: %w", err)
+	}
+
+	return nil
+}
+
+func (b *IndexExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Object Expr `json:"object"`
+
+		Bracket Token `json:"bracket"`
+
+		Index Expr `json:"index"`
+	}{"IndexExpr", b.span, b.object, b.bracket, b.index})
+}
+
+func (b *IndexExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Object json.RawMessage `json:"object"`
+
+		Bracket Token `json:"bracket"`
+
+		Index json.RawMessage `json:"index"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("IndexExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.object, err = decodeExpr(fields.Object); err != nil {
+		return fmt.Errorf("IndexExpr.object: %w", err)
+	}
+
+	if b.object == nil {
+		return errors.New("IndexExpr.object is missing")
+	}
+
+	b.bracket = fields.Bracket
+
+	if b.index, err = decodeExpr(fields.Index); err != nil {
+		return fmt.Errorf("IndexExpr.index: %w", err)
+	}
+
+	if b.index == nil {
+		return errors.New("IndexExpr.index is missing")
+	}
+
+	return nil
+}
+
+func (b *IndexSetExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Object Expr `json:"object"`
+
+		Bracket Token `json:"bracket"`
+
+		Index Expr `json:"index"`
+
+		Value Expr `json:"value"`
+	}{"IndexSetExpr", b.span, b.object, b.bracket, b.index, b.value})
+}
+
+func (b *IndexSetExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Object json.RawMessage `json:"object"`
+
+		Bracket Token `json:"bracket"`
+
+		Index json.RawMessage `json:"index"`
+
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("IndexSetExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.object, err = decodeExpr(fields.Object); err != nil {
+		return fmt.Errorf("IndexSetExpr.object: %w", err)
+	}
+
+	if b.object == nil {
+		return errors.New("IndexSetExpr.object is missing")
+	}
+
+	b.bracket = fields.Bracket
+
+	if b.index, err = decodeExpr(fields.Index); err != nil {
+		return fmt.Errorf("IndexSetExpr.index: %w", err)
+	}
+
+	if b.index == nil {
+		return errors.New("IndexSetExpr.index is missing")
+	}
+
+	if b.value, err = decodeExpr(fields.Value); err != nil {
+		return fmt.Errorf("IndexSetExpr.value: %w", err)
+	}
+
+	if b.value == nil {
+		return errors.New("IndexSetExpr.value is missing")
+	}
+
+	return nil
+}
+
+func (b *SliceExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Object Expr `json:"object"`
+
+		Bracket Token `json:"bracket"`
+
+		Start Expr `json:"start"`
+
+		End Expr `json:"end"`
+	}{"SliceExpr", b.span, b.object, b.bracket, b.start, b.end})
+}
+
+func (b *SliceExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Object json.RawMessage `json:"object"`
+
+		Bracket Token `json:"bracket"`
+
+		Start json.RawMessage `json:"start"`
+
+		End json.RawMessage `json:"end"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("SliceExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.object, err = decodeExpr(fields.Object); err != nil {
+		return fmt.Errorf("SliceExpr.object: %w", err)
+	}
+
+	if b.object == nil {
+		return errors.New("SliceExpr.object is missing")
+	}
+
+	b.bracket = fields.Bracket
+
+	if b.start, err = decodeExpr(fields.Start); err != nil {
+		return fmt.Errorf("SliceExpr.start: %w", err)
+	}
+
+	if b.end, err = decodeExpr(fields.End); err != nil {
+		return fmt.Errorf("SliceExpr.end: %w", err)
+	}
+
+	return nil
+}
+
+func (b *InterpolationExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Parts []Expr `json:"parts"`
+	}{"InterpolationExpr", b.span, b.parts})
+}
+
+func (b *InterpolationExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Parts []json.RawMessage `json:"parts"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("InterpolationExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.parts, err = decodeExprs(fields.Parts); err != nil {
+		return fmt.Errorf("InterpolationExpr.parts: %w", err)
+	}
+
+	return nil
+}
+
+func (b *CompoundExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Target Expr `json:"target"`
+
+		Operator Token `json:"operator"`
+
+		Value Expr `json:"value"`
+	}{"CompoundExpr", b.span, b.target, b.operator, b.value})
+}
+
+func (b *CompoundExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Target json.RawMessage `json:"target"`
+
+		Operator Token `json:"operator"`
+
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("CompoundExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.target, err = decodeExpr(fields.Target); err != nil {
+		return fmt.Errorf("CompoundExpr.target: %w", err)
+	}
+
+	if b.target == nil {
+		return errors.New("CompoundExpr.target is missing")
+	}
+
+	b.operator = fields.Operator
+
+	if b.value, err = decodeExpr(fields.Value); err != nil {
+		return fmt.Errorf("CompoundExpr.value: %w", err)
+	}
+
+	if b.value == nil {
+		return errors.New("CompoundExpr.value is missing")
+	}
+
+	return nil
+}
+
+func (b *IncrementExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Target Expr `json:"target"`
+
+		Operator Token `json:"operator"`
+
+		Prefix bool `json:"prefix"`
+	}{"IncrementExpr", b.span, b.target, b.operator, b.prefix})
+}
+
+func (b *IncrementExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Target json.RawMessage `json:"target"`
+
+		Operator Token `json:"operator"`
+
+		Prefix bool `json:"prefix"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("IncrementExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.target, err = decodeExpr(fields.Target); err != nil {
+		return fmt.Errorf("IncrementExpr.target: %w", err)
+	}
+
+	if b.target == nil {
+		return errors.New("IncrementExpr.target is missing")
+	}
+
+	b.operator = fields.Operator
+
+	b.prefix = fields.Prefix
+
+	return nil
+}
+
+func (b *ConditionalExpr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Condition Expr `json:"condition"`
+
+		ThenBranch Expr `json:"thenBranch"`
+
+		ElseBranch Expr `json:"elseBranch"`
+	}{"ConditionalExpr", b.span, b.condition, b.thenBranch, b.elseBranch})
+}
+
+func (b *ConditionalExpr) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Condition json.RawMessage `json:"condition"`
+
+		ThenBranch json.RawMessage `json:"thenBranch"`
+
+		ElseBranch json.RawMessage `json:"elseBranch"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ConditionalExpr: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.condition, err = decodeExpr(fields.Condition); err != nil {
+		return fmt.Errorf("ConditionalExpr.condition: %w", err)
+	}
+
+	if b.condition == nil {
+		return errors.New("ConditionalExpr.condition is missing")
+	}
+
+	if b.thenBranch, err = decodeExpr(fields.ThenBranch); err != nil {
+		return fmt.Errorf("ConditionalExpr.thenBranch: %w", err)
+	}
+
+	if b.thenBranch == nil {
+		return errors.New("ConditionalExpr.thenBranch is missing")
+	}
+
+	if b.elseBranch, err = decodeExpr(fields.ElseBranch); err != nil {
+		return fmt.Errorf("ConditionalExpr.elseBranch: %w", err)
+	}
+
+	if b.elseBranch == nil {
+		return errors.New("ConditionalExpr.elseBranch is missing")
+	}
+
+	return nil
+}
+
+// decodeStmt reconstructs a Stmt from its JSON object, whose "type" names the node type; null decodes to nil
+func decodeStmt(data json.RawMessage) (Stmt, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var tag struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return nil, err
+	}
+
+	var node Stmt
+	switch tag.Type {
+
+	case "ExpressionStmt":
+		node = &ExpressionStmt{}
+
+	case "PrintStmt":
+		node = &PrintStmt{}
+
+	case "VarStmt":
+		node = &VarStmt{}
+
+	case "FunctionStmt":
+		node = &FunctionStmt{}
+
+	case "ReturnStmt":
+		node = &ReturnStmt{}
+
+	case "BlockStmt":
+		node = &BlockStmt{}
+
+	case "IfStmt":
+		node = &IfStmt{}
+
+	case "WhileStmt":
+		node = &WhileStmt{}
+
+	case "ForStmt":
+		node = &ForStmt{}
+
+	case "BreakStmt":
+		node = &BreakStmt{}
+
+	case "ContinueStmt":
+		node = &ContinueStmt{}
+
+	case "ThrowStmt":
+		node = &ThrowStmt{}
+
+	case "TryStmt":
+		node = &TryStmt{}
+
+	case "ImportStmt":
+		node = &ImportStmt{}
+
+	case "ExportStmt":
+		node = &ExportStmt{}
+
+	case "ClassStmt":
+		node = &ClassStmt{}
+
+	default:
+		return nil, fmt.Errorf("unknown Stmt type %q", tag.Type)
+	}
+	if err := json.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// decodeStmts decodes a list of nodes, none of which may be null
+func decodeStmts(data []json.RawMessage) ([]Stmt, error) {
+	if data == nil {
+		return nil, nil
+	}
+	nodes := make([]Stmt, len(data))
+	for i, item := range data {
+		node, err := decodeStmt(item)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		if node == nil {
+			return nil, fmt.Errorf("element %d is null", i)
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
+
+// decodeStmtAs decodes a field holding a particular type of Stmt
+func decodeStmtAs[T Stmt](data json.RawMessage) (T, error) {
+	var typed T
+	node, err := decodeStmt(data)
+	if err != nil || node == nil {
+		return typed, err
+	}
+	typed, ok := node.(T)
+	if !ok {
+		return typed, fmt.Errorf("expected %T, got %T", typed, node)
+	}
+	return typed, nil
+}
+
+func decodeStmtsAs[T Stmt](data []json.RawMessage) ([]T, error) {
+	nodes, err := decodeStmts(data)
+	if err != nil || nodes == nil {
+		return nil, err
+	}
+	typed := make([]T, len(nodes))
+	for i, node := range nodes {
+		var ok bool
+		if typed[i], ok = node.(T); !ok {
+			return nil, fmt.Errorf("element %d: expected %T, got %T", i, typed[i], node)
+		}
+	}
+	return typed, nil
+}
+
+func (b *ExpressionStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Expression Expr `json:"expression"`
+	}{"ExpressionStmt", b.span, b.expression})
+}
+
+func (b *ExpressionStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Expression json.RawMessage `json:"expression"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ExpressionStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.expression, err = decodeExpr(fields.Expression); err != nil {
+		return fmt.Errorf("ExpressionStmt.expression: %w", err)
+	}
+
+	if b.expression == nil {
+		return errors.New("ExpressionStmt.expression is missing")
+	}
+
+	return nil
+}
+
+func (b *PrintStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Expression Expr `json:"expression"`
+	}{"PrintStmt", b.span, b.expression})
+}
+
+func (b *PrintStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Expression json.RawMessage `json:"expression"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("PrintStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.expression, err = decodeExpr(fields.Expression); err != nil {
+		return fmt.Errorf("PrintStmt.expression: %w", err)
+	}
+
+	if b.expression == nil {
+		return errors.New("PrintStmt.expression is missing")
+	}
+
+	return nil
+}
+
+func (b *VarStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		VarName Token `json:"varName"`
+
+		InitializerExpression Expr `json:"initializerExpression"`
+	}{"VarStmt", b.span, b.varName, b.initializerExpression})
+}
+
+func (b *VarStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		VarName Token `json:"varName"`
+
+		InitializerExpression json.RawMessage `json:"initializerExpression"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("VarStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.varName = fields.VarName
+
+	if b.initializerExpression, err = decodeExpr(fields.InitializerExpression); err != nil {
+		return fmt.Errorf("VarStmt.initializerExpression: %w", err)
+	}
+
+	return nil
+}
+
+func (b *FunctionStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Name Token `json:"name"`
+
+		Parameters []Token `json:"parameters"`
+
+		Body []Stmt `json:"body"`
+	}{"FunctionStmt", b.span, b.name, b.parameters, b.body})
+}
+
+func (b *FunctionStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Name Token `json:"name"`
+
+		Parameters []Token `json:"parameters"`
+
+		Body []json.RawMessage `json:"body"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("FunctionStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.name = fields.Name
+
+	b.parameters = fields.Parameters
+
+	if b.body, err = decodeStmts(fields.Body); err != nil {
+		return fmt.Errorf("FunctionStmt.body: %w", err)
+	}
+
+	return nil
+}
+
+func (b *ReturnStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Value Expr `json:"value"`
+	}{"ReturnStmt", b.span, b.keyword, b.value})
+}
+
+func (b *ReturnStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ReturnStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.keyword = fields.Keyword
+
+	if b.value, err = decodeExpr(fields.Value); err != nil {
+		return fmt.Errorf("ReturnStmt.value: %w", err)
+	}
+
+	return nil
+}
+
+func (b *BlockStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Statements []Stmt `json:"statements"`
+	}{"BlockStmt", b.span, b.statements})
+}
+
+func (b *BlockStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Statements []json.RawMessage `json:"statements"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("BlockStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.statements, err = decodeStmts(fields.Statements); err != nil {
+		return fmt.Errorf("BlockStmt.statements: %w", err)
+	}
+
+	return nil
+}
+
+func (b *IfStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Condition Expr `json:"condition"`
+
+		ThenBranch Stmt `json:"thenBranch"`
+
+		ElseBranch Stmt `json:"elseBranch"`
+	}{"IfStmt", b.span, b.condition, b.thenBranch, b.elseBranch})
+}
+
+func (b *IfStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Condition json.RawMessage `json:"condition"`
+
+		ThenBranch json.RawMessage `json:"thenBranch"`
+
+		ElseBranch json.RawMessage `json:"elseBranch"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("IfStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.condition, err = decodeExpr(fields.Condition); err != nil {
+		return fmt.Errorf("IfStmt.condition: %w", err)
+	}
+
+	if b.condition == nil {
+		return errors.New("IfStmt.condition is missing")
+	}
+
+	if b.thenBranch, err = decodeStmt(fields.ThenBranch); err != nil {
+		return fmt.Errorf("IfStmt.thenBranch: %w", err)
+	}
+
+	if b.thenBranch == nil {
+		return errors.New("IfStmt.thenBranch is missing")
+	}
+
+	if b.elseBranch, err = decodeStmt(fields.ElseBranch); err != nil {
+		return fmt.Errorf("IfStmt.elseBranch: %w", err)
+	}
+
+	return nil
+}
+
+func (b *WhileStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Condition Expr `json:"condition"`
+
+		LoopBody Stmt `json:"loopBody"`
+
+		Label *Token `json:"label"`
+	}{"WhileStmt", b.span, b.condition, b.loopBody, b.label})
+}
+
+func (b *WhileStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Condition json.RawMessage `json:"condition"`
+
+		LoopBody json.RawMessage `json:"loopBody"`
+
+		Label *Token `json:"label"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("WhileStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.condition, err = decodeExpr(fields.Condition); err != nil {
+		return fmt.Errorf("WhileStmt.condition: %w", err)
+	}
+
+	if b.condition == nil {
+		return errors.New("WhileStmt.condition is missing")
+	}
+
+	if b.loopBody, err = decodeStmt(fields.LoopBody); err != nil {
+		return fmt.Errorf("WhileStmt.loopBody: %w", err)
+	}
+
+	if b.loopBody == nil {
+		return errors.New("WhileStmt.loopBody is missing")
+	}
+
+	b.label = fields.Label
+
+	return nil
+}
+
+func (b *ForStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Init Stmt `json:"init"`
+
+		Condition Expr `json:"condition"`
+
+		Iteration Expr `json:"iteration"`
+
+		LoopBody Stmt `json:"loopBody"`
+
+		Label *Token `json:"label"`
+	}{"ForStmt", b.span, b.init, b.condition, b.iteration, b.loopBody, b.label})
+}
+
+func (b *ForStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Init json.RawMessage `json:"init"`
+
+		Condition json.RawMessage `json:"condition"`
+
+		Iteration json.RawMessage `json:"iteration"`
+
+		LoopBody json.RawMessage `json:"loopBody"`
+
+		Label *Token `json:"label"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ForStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	if b.init, err = decodeStmt(fields.Init); err != nil {
+		return fmt.Errorf("ForStmt.init: %w", err)
+	}
+
+	if b.condition, err = decodeExpr(fields.Condition); err != nil {
+		return fmt.Errorf("ForStmt.condition: %w", err)
+	}
+
+	if b.iteration, err = decodeExpr(fields.Iteration); err != nil {
+		return fmt.Errorf("ForStmt.iteration: %w", err)
+	}
+
+	if b.loopBody, err = decodeStmt(fields.LoopBody); err != nil {
+		return fmt.Errorf("ForStmt.loopBody: %w", err)
+	}
+
+	if b.loopBody == nil {
+		return errors.New("ForStmt.loopBody is missing")
+	}
+
+	b.label = fields.Label
+
+	return nil
+}
+
+func (b *BreakStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Label *Token `json:"label"`
+	}{"BreakStmt", b.span, b.keyword, b.label})
+}
+
+func (b *BreakStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Label *Token `json:"label"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("BreakStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	b.keyword = fields.Keyword
+
+	b.label = fields.Label
+
+	return nil
+}
+
+func (b *ContinueStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Label *Token `json:"label"`
+	}{"ContinueStmt", b.span, b.keyword, b.label})
+}
+
+func (b *ContinueStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Label *Token `json:"label"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ContinueStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	b.keyword = fields.Keyword
+
+	b.label = fields.Label
+
+	return nil
+}
+
+func (b *ThrowStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Value Expr `json:"value"`
+	}{"ThrowStmt", b.span, b.keyword, b.value})
+}
+
+func (b *ThrowStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ThrowStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.keyword = fields.Keyword
+
+	if b.value, err = decodeExpr(fields.Value); err != nil {
+		return fmt.Errorf("ThrowStmt.value: %w", err)
+	}
+
+	if b.value == nil {
+		return errors.New("ThrowStmt.value is missing")
+	}
+
+	return nil
+}
+
+func (b *TryStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Body Stmt `json:"body"`
+
+		CatchName *Token `json:"catchName"`
+
+		CatchBody Stmt `json:"catchBody"`
+
+		FinallyBody Stmt `json:"finallyBody"`
+	}{"TryStmt", b.span, b.keyword, b.body, b.catchName, b.catchBody, b.finallyBody})
+}
+
+func (b *TryStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Body json.RawMessage `json:"body"`
+
+		CatchName *Token `json:"catchName"`
+
+		CatchBody json.RawMessage `json:"catchBody"`
+
+		FinallyBody json.RawMessage `json:"finallyBody"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("TryStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.keyword = fields.Keyword
+
+	if b.body, err = decodeStmt(fields.Body); err != nil {
+		return fmt.Errorf("TryStmt.body: %w", err)
+	}
+
+	if b.body == nil {
+		return errors.New("TryStmt.body is missing")
+	}
+
+	b.catchName = fields.CatchName
+
+	if b.catchBody, err = decodeStmt(fields.CatchBody); err != nil {
+		return fmt.Errorf("TryStmt.catchBody: %w", err)
+	}
+
+	if b.finallyBody, err = decodeStmt(fields.FinallyBody); err != nil {
+		return fmt.Errorf("TryStmt.finallyBody: %w", err)
+	}
+
+	return nil
+}
+
+func (b *ImportStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Path Token `json:"path"`
+
+		Alias *Token `json:"alias"`
+
+		Names []Token `json:"names"`
+	}{"ImportStmt", b.span, b.keyword, b.path, b.alias, b.names})
+}
+
+func (b *ImportStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Path Token `json:"path"`
+
+		Alias *Token `json:"alias"`
+
+		Names []Token `json:"names"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ImportStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	b.keyword = fields.Keyword
+
+	b.path = fields.Path
+
+	b.alias = fields.Alias
+
+	b.names = fields.Names
+
+	return nil
+}
+
+func (b *ExportStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Declaration Stmt `json:"declaration"`
+	}{"ExportStmt", b.span, b.keyword, b.declaration})
+}
+
+func (b *ExportStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Keyword Token `json:"keyword"`
+
+		Declaration json.RawMessage `json:"declaration"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ExportStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.keyword = fields.Keyword
+
+	if b.declaration, err = decodeStmt(fields.Declaration); err != nil {
+		return fmt.Errorf("ExportStmt.declaration: %w", err)
+	}
+
+	if b.declaration == nil {
+		return errors.New("ExportStmt.declaration is missing")
+	}
+
+	return nil
+}
+
+func (b *ClassStmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Span Span   `json:"span"`
+
+		Name Token `json:"name"`
+
+		Superclass *VariableExpr `json:"superclass"`
+
+		Methods []*FunctionStmt `json:"methods"`
+	}{"ClassStmt", b.span, b.name, b.superclass, b.methods})
+}
+
+func (b *ClassStmt) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Span Span `json:"span"`
+
+		Name Token `json:"name"`
+
+		Superclass json.RawMessage `json:"superclass"`
+
+		Methods []json.RawMessage `json:"methods"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("ClassStmt: %w", err)
+	}
+
+	b.span = fields.Span
+
+	var err error
+
+	b.name = fields.Name
+
+	if b.superclass, err = decodeExprAs[*VariableExpr](fields.Superclass); err != nil {
+		return fmt.Errorf("ClassStmt.superclass: %w", err)
+	}
+
+	if b.methods, err = decodeStmtsAs[*FunctionStmt](fields.Methods); err != nil {
+		return fmt.Errorf("ClassStmt.methods: %w", err)
+	}
+
+	return nil
+}
+
+// Node is an Expr or a Stmt
+type Node interface {
+	Span() Span
+}
+
+// Walk calls fn for node and, unless it returns false, walks each child of node in the order of its
+// fields, depth-first. Missing children are skipped.
+func Walk(node Node, fn func(Node) bool) {
+	switch n := node.(type) {
+
+	case *BinaryExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.left, fn)
+
+		Walk(n.right, fn)
+
+	case *UnaryExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.right, fn)
+
+	case *GroupingExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.expr, fn)
+
+	case *LiteralExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+	case *VariableExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+	case *AssignExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.assignValue, fn)
+
+	case *LogicalExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.left, fn)
+
+		Walk(n.right, fn)
+
+	case *CallExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.callee, fn)
+
+		for _, child := range n.arguments {
+			Walk(child, fn)
+		}
+
+	case *GetExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.object, fn)
+
+	case *SetExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.object, fn)
+
+		Walk(n.value, fn)
+
+	case *ThisExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+	case *SuperExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+	case *LambdaExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		for _, child := range n.body {
+			Walk(child, fn)
+		}
+
+	case *ListExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		for _, child := range n.elements {
+			Walk(child, fn)
+		}
+
+	case *MapExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		for _, child := range n.keys {
+			Walk(child, fn)
+		}
+
+		for _, child := range n.values {
+			Walk(child, fn)
+		}
+
+	case *IndexExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.object, fn)
+
+		Walk(n.index, fn)
+
+	case *IndexSetExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.object, fn)
+
+		Walk(n.index, fn)
+
+		Walk(n.value, fn)
+
+	case *SliceExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.object, fn)
+
+		Walk(n.start, fn)
+
+		Walk(n.end, fn)
+
+	case *InterpolationExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		for _, child := range n.parts {
+			Walk(child, fn)
+		}
+
+	case *CompoundExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.target, fn)
+
+		Walk(n.value, fn)
+
+	case *IncrementExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.target, fn)
+
+	case *ConditionalExpr:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.condition, fn)
+
+		Walk(n.thenBranch, fn)
+
+		Walk(n.elseBranch, fn)
+
+	case *ExpressionStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.expression, fn)
+
+	case *PrintStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.expression, fn)
+
+	case *VarStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.initializerExpression, fn)
+
+	case *FunctionStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		for _, child := range n.body {
+			Walk(child, fn)
+		}
+
+	case *ReturnStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.value, fn)
+
+	case *BlockStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		for _, child := range n.statements {
+			Walk(child, fn)
+		}
+
+	case *IfStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.condition, fn)
+
+		Walk(n.thenBranch, fn)
+
+		Walk(n.elseBranch, fn)
+
+	case *WhileStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.condition, fn)
+
+		Walk(n.loopBody, fn)
+
+	case *ForStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.init, fn)
+
+		Walk(n.condition, fn)
+
+		Walk(n.iteration, fn)
+
+		Walk(n.loopBody, fn)
+
+	case *BreakStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+	case *ContinueStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+	case *ThrowStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.value, fn)
+
+	case *TryStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.body, fn)
+
+		Walk(n.catchBody, fn)
+
+		Walk(n.finallyBody, fn)
+
+	case *ImportStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+	case *ExportStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.declaration, fn)
+
+	case *ClassStmt:
+		if n == nil || !fn(n) {
+			return
+		}
+
+		Walk(n.superclass, fn)
+
+		for _, child := range n.methods {
+			Walk(child, fn)
+		}
+
+	}
+}
+
+// Transform rewrites the tree under node bottom-up: the children of a node are transformed and
+// replaced by the results first, then fn is called with the node and returns its replacement, or
+// the node itself to keep it. Returning nil removes a node from a list. Nodes are changed in place,
+// so Clone a tree to keep the original. A replacement must fit where the node was, an Expr for an
+// Expr, a *FunctionStmt for a method and so on, or Transform panics.
+func Transform[T Node](node T, fn func(Node) Node) T {
+	var zero T
+	if Node(node) == nil {
+		return zero
+	}
+	return transformedAs[T](transform(node, fn), node)
+}
+
+func transform(node Node, fn func(Node) Node) Node {
+	switch n := node.(type) {
+
+	case *BinaryExpr:
+		if n == nil {
+			return n
+		}
+
+		n.left = Transform(n.left, fn)
+
+		n.right = Transform(n.right, fn)
+
+	case *UnaryExpr:
+		if n == nil {
+			return n
+		}
+
+		n.right = Transform(n.right, fn)
+
+	case *GroupingExpr:
+		if n == nil {
+			return n
+		}
+
+		n.expr = Transform(n.expr, fn)
+
+	case *LiteralExpr:
+		if n == nil {
+			return n
+		}
+
+	case *VariableExpr:
+		if n == nil {
+			return n
+		}
+
+	case *AssignExpr:
+		if n == nil {
+			return n
+		}
+
+		n.assignValue = Transform(n.assignValue, fn)
+
+	case *LogicalExpr:
+		if n == nil {
+			return n
+		}
+
+		n.left = Transform(n.left, fn)
+
+		n.right = Transform(n.right, fn)
+
+	case *CallExpr:
+		if n == nil {
+			return n
+		}
+
+		n.callee = Transform(n.callee, fn)
+
+		n.arguments = transformAll(n.arguments, fn)
+
+	case *GetExpr:
+		if n == nil {
+			return n
+		}
+
+		n.object = Transform(n.object, fn)
+
+	case *SetExpr:
+		if n == nil {
+			return n
+		}
+
+		n.object = Transform(n.object, fn)
+
+		n.value = Transform(n.value, fn)
+
+	case *ThisExpr:
+		if n == nil {
+			return n
+		}
+
+	case *SuperExpr:
+		if n == nil {
+			return n
+		}
+
+	case *LambdaExpr:
+		if n == nil {
+			return n
+		}
+
+		n.body = transformAll(n.body, fn)
+
+	case *ListExpr:
+		if n == nil {
+			return n
+		}
+
+		n.elements = transformAll(n.elements, fn)
+
+	case *MapExpr:
+		if n == nil {
+			return n
+		}
+
+		n.keys = transformAll(n.keys, fn)
+
+		n.values = transformAll(n.values, fn)
+
+	case *IndexExpr:
+		if n == nil {
+			return n
+		}
+
+		n.object = Transform(n.object, fn)
+
+		n.index = Transform(n.index, fn)
+
+	case *IndexSetExpr:
+		if n == nil {
+			return n
+		}
+
+		n.object = Transform(n.object, fn)
+
+		n.index = Transform(n.index, fn)
+
+		n.value = Transform(n.value, fn)
+
+	case *SliceExpr:
+		if n == nil {
+			return n
+		}
+
+		n.object = Transform(n.object, fn)
+
+		n.start = Transform(n.start, fn)
+
+		n.end = Transform(n.end, fn)
+
+	case *InterpolationExpr:
+		if n == nil {
+			return n
+		}
+
+		n.parts = transformAll(n.parts, fn)
+
+	case *CompoundExpr:
+		if n == nil {
+			return n
+		}
+
+		n.target = Transform(n.target, fn)
+
+		n.value = Transform(n.value, fn)
+
+	case *IncrementExpr:
+		if n == nil {
+			return n
+		}
+
+		n.target = Transform(n.target, fn)
+
+	case *ConditionalExpr:
+		if n == nil {
+			return n
+		}
+
+		n.condition = Transform(n.condition, fn)
+
+		n.thenBranch = Transform(n.thenBranch, fn)
+
+		n.elseBranch = Transform(n.elseBranch, fn)
+
+	case *ExpressionStmt:
+		if n == nil {
+			return n
+		}
+
+		n.expression = Transform(n.expression, fn)
+
+	case *PrintStmt:
+		if n == nil {
+			return n
+		}
+
+		n.expression = Transform(n.expression, fn)
+
+	case *VarStmt:
+		if n == nil {
+			return n
+		}
+
+		n.initializerExpression = Transform(n.initializerExpression, fn)
+
+	case *FunctionStmt:
+		if n == nil {
+			return n
+		}
+
+		n.body = transformAll(n.body, fn)
+
+	case *ReturnStmt:
+		if n == nil {
+			return n
+		}
+
+		n.value = Transform(n.value, fn)
+
+	case *BlockStmt:
+		if n == nil {
+			return n
+		}
+
+		n.statements = transformAll(n.statements, fn)
+
+	case *IfStmt:
+		if n == nil {
+			return n
+		}
+
+		n.condition = Transform(n.condition, fn)
+
+		n.thenBranch = Transform(n.thenBranch, fn)
+
+		n.elseBranch = Transform(n.elseBranch, fn)
+
+	case *WhileStmt:
+		if n == nil {
+			return n
+		}
+
+		n.condition = Transform(n.condition, fn)
+
+		n.loopBody = Transform(n.loopBody, fn)
+
+	case *ForStmt:
+		if n == nil {
+			return n
+		}
+
+		n.init = Transform(n.init, fn)
+
+		n.condition = Transform(n.condition, fn)
+
+		n.iteration = Transform(n.iteration, fn)
+
+		n.loopBody = Transform(n.loopBody, fn)
+
+	case *BreakStmt:
+		if n == nil {
+			return n
+		}
+
+	case *ContinueStmt:
+		if n == nil {
+			return n
+		}
+
+	case *ThrowStmt:
+		if n == nil {
+			return n
+		}
+
+		n.value = Transform(n.value, fn)
+
+	case *TryStmt:
+		if n == nil {
+			return n
+		}
+
+		n.body = Transform(n.body, fn)
+
+		n.catchBody = Transform(n.catchBody, fn)
+
+		n.finallyBody = Transform(n.finallyBody, fn)
+
+	case *ImportStmt:
+		if n == nil {
+			return n
+		}
+
+	case *ExportStmt:
+		if n == nil {
+			return n
+		}
+
+		n.declaration = Transform(n.declaration, fn)
+
+	case *ClassStmt:
+		if n == nil {
+			return n
+		}
+
+		n.superclass = Transform(n.superclass, fn)
+
+		n.methods = transformAll(n.methods, fn)
+
+	}
+	return fn(node)
+}
+
+func transformAll[T Node](nodes []T, fn func(Node) Node) []T {
+	if nodes == nil {
+		return nil
+	}
+	transformed := make([]T, 0, len(nodes))
+	for _, node := range nodes {
+		if result := transform(node, fn); result != nil {
+			transformed = append(transformed, transformedAs[T](result, node))
+		}
+	}
+	return transformed
+}
+
+// transformedAs checks that the replacement of node has the type of the field holding node
+func transformedAs[T Node](result, node Node) T {
+	typed, ok := result.(T)
+	if !ok && result != nil {
+		panic(fmt.Sprintf("Transform: %T can't replace %T", result, node))
+	}
+	return typed
+}
+
+// Clone copies the tree under node, so that either copy can be changed without affecting the other
+func Clone[T Node](node T) T {
+	clone, _ := cloneNode(node).(T)
+	return clone
+}
+
+func cloneNode(node Node) Node {
+	switch n := node.(type) {
+
+	case *BinaryExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.left = Clone(n.left)
+
+		clone.right = Clone(n.right)
+
+		return &clone
+
+	case *UnaryExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.right = Clone(n.right)
+
+		return &clone
+
+	case *GroupingExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.expr = Clone(n.expr)
+
+		return &clone
+
+	case *LiteralExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		return &clone
+
+	case *VariableExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		return &clone
+
+	case *AssignExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.assignValue = Clone(n.assignValue)
+
+		return &clone
+
+	case *LogicalExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.left = Clone(n.left)
+
+		clone.right = Clone(n.right)
+
+		return &clone
+
+	case *CallExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.callee = Clone(n.callee)
+
+		clone.arguments = cloneAll(n.arguments)
+
+		return &clone
+
+	case *GetExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.object = Clone(n.object)
+
+		return &clone
+
+	case *SetExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.object = Clone(n.object)
+
+		clone.value = Clone(n.value)
+
+		return &clone
+
+	case *ThisExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		return &clone
+
+	case *SuperExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		return &clone
+
+	case *LambdaExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.parameters = slices.Clone(n.parameters)
+
+		clone.body = cloneAll(n.body)
+
+		return &clone
+
+	case *ListExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.elements = cloneAll(n.elements)
+
+		return &clone
+
+	case *MapExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.keys = cloneAll(n.keys)
+
+		clone.values = cloneAll(n.values)
+
+		return &clone
+
+	case *IndexExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.object = Clone(n.object)
+
+		clone.index = Clone(n.index)
+
+		return &clone
+
+	case *IndexSetExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.object = Clone(n.object)
+
+		clone.index = Clone(n.index)
+
+		clone.value = Clone(n.value)
+
+		return &clone
+
+	case *SliceExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.object = Clone(n.object)
+
+		clone.start = Clone(n.start)
+
+		clone.end = Clone(n.end)
+
+		return &clone
+
+	case *InterpolationExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.parts = cloneAll(n.parts)
+
+		return &clone
+
+	case *CompoundExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.target = Clone(n.target)
+
+		clone.value = Clone(n.value)
+
+		return &clone
+
+	case *IncrementExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.target = Clone(n.target)
+
+		return &clone
+
+	case *ConditionalExpr:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.condition = Clone(n.condition)
+
+		clone.thenBranch = Clone(n.thenBranch)
+
+		clone.elseBranch = Clone(n.elseBranch)
+
+		return &clone
+
+	case *ExpressionStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.expression = Clone(n.expression)
+
+		return &clone
+
+	case *PrintStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.expression = Clone(n.expression)
+
+		return &clone
+
+	case *VarStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.initializerExpression = Clone(n.initializerExpression)
+
+		return &clone
+
+	case *FunctionStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.parameters = slices.Clone(n.parameters)
+
+		clone.body = cloneAll(n.body)
+
+		return &clone
+
+	case *ReturnStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.value = Clone(n.value)
+
+		return &clone
+
+	case *BlockStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.statements = cloneAll(n.statements)
+
+		return &clone
+
+	case *IfStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.condition = Clone(n.condition)
+
+		clone.thenBranch = Clone(n.thenBranch)
+
+		clone.elseBranch = Clone(n.elseBranch)
+
+		return &clone
+
+	case *WhileStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.condition = Clone(n.condition)
+
+		clone.loopBody = Clone(n.loopBody)
+
+		if n.label != nil {
+			token := *n.label
+			clone.label = &token
+		}
+
+		return &clone
+
+	case *ForStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.init = Clone(n.init)
+
+		clone.condition = Clone(n.condition)
+
+		clone.iteration = Clone(n.iteration)
+
+		clone.loopBody = Clone(n.loopBody)
+
+		if n.label != nil {
+			token := *n.label
+			clone.label = &token
+		}
+
+		return &clone
+
+	case *BreakStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		if n.label != nil {
+			token := *n.label
+			clone.label = &token
+		}
+
+		return &clone
+
+	case *ContinueStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		if n.label != nil {
+			token := *n.label
+			clone.label = &token
+		}
+
+		return &clone
+
+	case *ThrowStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.value = Clone(n.value)
+
+		return &clone
+
+	case *TryStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.body = Clone(n.body)
+
+		if n.catchName != nil {
+			token := *n.catchName
+			clone.catchName = &token
+		}
+
+		clone.catchBody = Clone(n.catchBody)
+
+		clone.finallyBody = Clone(n.finallyBody)
+
+		return &clone
+
+	case *ImportStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		if n.alias != nil {
+			token := *n.alias
+			clone.alias = &token
+		}
+
+		clone.names = slices.Clone(n.names)
+
+		return &clone
+
+	case *ExportStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.declaration = Clone(n.declaration)
+
+		return &clone
+
+	case *ClassStmt:
+		if n == nil {
+			return n
+		}
+		clone := *n
+
+		clone.superclass = Clone(n.superclass)
+
+		clone.methods = cloneAll(n.methods)
+
+		return &clone
+
+	}
+	return node
+}
+
+func cloneAll[T Node](nodes []T) []T {
+	if nodes == nil {
+		return nil
+	}
+	clones := make([]T, len(nodes))
+	for i, node := range nodes {
+		clones[i] = Clone(node)
+	}
+	return clones
+}
+
+// EqualNodes reports whether a and b are the same tree: nodes of the same types with equal fields. Tokens
+// are compared by type, lexeme and literal, while spans, lines, columns and comments are ignored,
+// so the same program formatted differently is equal.
+func EqualNodes(a, b Node) bool {
+	switch x := a.(type) {
+
+	case *BinaryExpr:
+		y, ok := b.(*BinaryExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.left, y.left) && equalTokens(x.operator, y.operator) && EqualNodes(x.right, y.right) && true
+
+	case *UnaryExpr:
+		y, ok := b.(*UnaryExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.operator, y.operator) && EqualNodes(x.right, y.right) && true
+
+	case *GroupingExpr:
+		y, ok := b.(*GroupingExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.expr, y.expr) && true
+
+	case *LiteralExpr:
+		y, ok := b.(*LiteralExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return x.value == y.value && true
+
+	case *VariableExpr:
+		y, ok := b.(*VariableExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.variableName, y.variableName) && true
+
+	case *AssignExpr:
+		y, ok := b.(*AssignExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.variableName, y.variableName) && EqualNodes(x.assignValue, y.assignValue) && true
+
+	case *LogicalExpr:
+		y, ok := b.(*LogicalExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.left, y.left) && equalTokens(x.operator, y.operator) && EqualNodes(x.right, y.right) && true
+
+	case *CallExpr:
+		y, ok := b.(*CallExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.callee, y.callee) && equalAll(x.arguments, y.arguments) && equalTokens(x.closingParen, y.closingParen) && true
+
+	case *GetExpr:
+		y, ok := b.(*GetExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.object, y.object) && equalTokens(x.name, y.name) && true
+
+	case *SetExpr:
+		y, ok := b.(*SetExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.object, y.object) && equalTokens(x.name, y.name) && EqualNodes(x.value, y.value) && true
+
+	case *ThisExpr:
+		y, ok := b.(*ThisExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.keyword, y.keyword) && true
+
+	case *SuperExpr:
+		y, ok := b.(*SuperExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.keyword, y.keyword) && equalTokens(x.method, y.method) && true
+
+	case *LambdaExpr:
+		y, ok := b.(*LambdaExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.keyword, y.keyword) && slices.EqualFunc(x.parameters, y.parameters, equalTokens) && equalAll(x.body, y.body) && true
+
+	case *ListExpr:
+		y, ok := b.(*ListExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalAll(x.elements, y.elements) && true
+
+	case *MapExpr:
+		y, ok := b.(*MapExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.brace, y.brace) && equalAll(x.keys, y.keys) && equalAll(x.values, y.values) && true
+
+	case *IndexExpr:
+		y, ok := b.(*IndexExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.object, y.object) && equalTokens(x.bracket, y.bracket) && EqualNodes(x.index, y.index) && true
+
+	case *IndexSetExpr:
+		y, ok := b.(*IndexSetExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.object, y.object) && equalTokens(x.bracket, y.bracket) && EqualNodes(x.index, y.index) && EqualNodes(x.value, y.value) && true
+
+	case *SliceExpr:
+		y, ok := b.(*SliceExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.object, y.object) && equalTokens(x.bracket, y.bracket) && EqualNodes(x.start, y.start) && EqualNodes(x.end, y.end) && true
+
+	case *InterpolationExpr:
+		y, ok := b.(*InterpolationExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalAll(x.parts, y.parts) && true
+
+	case *CompoundExpr:
+		y, ok := b.(*CompoundExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.target, y.target) && equalTokens(x.operator, y.operator) && EqualNodes(x.value, y.value) && true
+
+	case *IncrementExpr:
+		y, ok := b.(*IncrementExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.target, y.target) && equalTokens(x.operator, y.operator) && x.prefix == y.prefix && true
+
+	case *ConditionalExpr:
+		y, ok := b.(*ConditionalExpr)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.condition, y.condition) && EqualNodes(x.thenBranch, y.thenBranch) && EqualNodes(x.elseBranch, y.elseBranch) && true
+
+	case *ExpressionStmt:
+		y, ok := b.(*ExpressionStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.expression, y.expression) && true
+
+	case *PrintStmt:
+		y, ok := b.(*PrintStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.expression, y.expression) && true
+
+	case *VarStmt:
+		y, ok := b.(*VarStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.varName, y.varName) && EqualNodes(x.initializerExpression, y.initializerExpression) && true
+
+	case *FunctionStmt:
+		y, ok := b.(*FunctionStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.name, y.name) && slices.EqualFunc(x.parameters, y.parameters, equalTokens) && equalAll(x.body, y.body) && true
+
+	case *ReturnStmt:
+		y, ok := b.(*ReturnStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.keyword, y.keyword) && EqualNodes(x.value, y.value) && true
+
+	case *BlockStmt:
+		y, ok := b.(*BlockStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalAll(x.statements, y.statements) && true
+
+	case *IfStmt:
+		y, ok := b.(*IfStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.condition, y.condition) && EqualNodes(x.thenBranch, y.thenBranch) && EqualNodes(x.elseBranch, y.elseBranch) && true
+
+	case *WhileStmt:
+		y, ok := b.(*WhileStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.condition, y.condition) && EqualNodes(x.loopBody, y.loopBody) && (x.label == nil) == (y.label == nil) && (x.label == nil || equalTokens(*x.label, *y.label)) && true
+
+	case *ForStmt:
+		y, ok := b.(*ForStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return EqualNodes(x.init, y.init) && EqualNodes(x.condition, y.condition) && EqualNodes(x.iteration, y.iteration) && EqualNodes(x.loopBody, y.loopBody) && (x.label == nil) == (y.label == nil) && (x.label == nil || equalTokens(*x.label, *y.label)) && true
+
+	case *BreakStmt:
+		y, ok := b.(*BreakStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.keyword, y.keyword) && (x.label == nil) == (y.label == nil) && (x.label == nil || equalTokens(*x.label, *y.label)) && true
+
+	case *ContinueStmt:
+		y, ok := b.(*ContinueStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.keyword, y.keyword) && (x.label == nil) == (y.label == nil) && (x.label == nil || equalTokens(*x.label, *y.label)) && true
+
+	case *ThrowStmt:
+		y, ok := b.(*ThrowStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.keyword, y.keyword) && EqualNodes(x.value, y.value) && true
+
+	case *TryStmt:
+		y, ok := b.(*TryStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.keyword, y.keyword) && EqualNodes(x.body, y.body) && (x.catchName == nil) == (y.catchName == nil) && (x.catchName == nil || equalTokens(*x.catchName, *y.catchName)) && EqualNodes(x.catchBody, y.catchBody) && EqualNodes(x.finallyBody, y.finallyBody) && true
+
+	case *ImportStmt:
+		y, ok := b.(*ImportStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.keyword, y.keyword) && equalTokens(x.path, y.path) && (x.alias == nil) == (y.alias == nil) && (x.alias == nil || equalTokens(*x.alias, *y.alias)) && slices.EqualFunc(x.names, y.names, equalTokens) && true
+
+	case *ExportStmt:
+		y, ok := b.(*ExportStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.keyword, y.keyword) && EqualNodes(x.declaration, y.declaration) && true
+
+	case *ClassStmt:
+		y, ok := b.(*ClassStmt)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return equalTokens(x.name, y.name) && EqualNodes(x.superclass, y.superclass) && equalAll(x.methods, y.methods) && true
+
+	}
+	return a == nil && b == nil
+}
+
+func equalAll[T Node](a, b []T) bool {
+	return slices.EqualFunc(a, b, func(x, y T) bool { return EqualNodes(x, y) })
+}
+
+func equalTokens(a, b Token) bool {
+	return a.Type == b.Type && a.Lexeme == b.Lexeme && a.Literal == b.Literal
+}
+
+// NewBinaryExpr creates a BinaryExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewBinaryExpr(left Expr, operator Token, right Expr, span Span) *BinaryExpr {
+	return &BinaryExpr{left: left, operator: operator, right: right, span: span}
+}
+
+func (b *BinaryExpr) Left() Expr {
+	return b.left
+}
+
+func (b *BinaryExpr) Operator() Token {
+	return b.operator
+}
+
+func (b *BinaryExpr) Right() Expr {
+	return b.right
+}
+
+// NewUnaryExpr creates a UnaryExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewUnaryExpr(operator Token, right Expr, span Span) *UnaryExpr {
+	return &UnaryExpr{operator: operator, right: right, span: span}
+}
+
+func (b *UnaryExpr) Operator() Token {
+	return b.operator
+}
+
+func (b *UnaryExpr) Right() Expr {
+	return b.right
+}
+
+// NewGroupingExpr creates a GroupingExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewGroupingExpr(expr Expr, span Span) *GroupingExpr {
+	return &GroupingExpr{expr: expr, span: span}
+}
+
+func (b *GroupingExpr) Expr() Expr {
+	return b.expr
+}
+
+// NewLiteralExpr creates a LiteralExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewLiteralExpr(value interface{}, span Span) *LiteralExpr {
+	return &LiteralExpr{value: value, span: span}
+}
+
+func (b *LiteralExpr) Value() interface{} {
+	return b.value
+}
+
+// NewVariableExpr creates a VariableExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewVariableExpr(variableName Token, span Span) *VariableExpr {
+	return &VariableExpr{variableName: variableName, span: span}
+}
+
+func (b *VariableExpr) VariableName() Token {
+	return b.variableName
+}
+
+// NewAssignExpr creates a AssignExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewAssignExpr(variableName Token, assignValue Expr, span Span) *AssignExpr {
+	return &AssignExpr{variableName: variableName, assignValue: assignValue, span: span}
+}
+
+func (b *AssignExpr) VariableName() Token {
+	return b.variableName
+}
+
+func (b *AssignExpr) AssignValue() Expr {
+	return b.assignValue
+}
+
+// NewLogicalExpr creates a LogicalExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewLogicalExpr(left Expr, operator Token, right Expr, span Span) *LogicalExpr {
+	return &LogicalExpr{left: left, operator: operator, right: right, span: span}
+}
+
+func (b *LogicalExpr) Left() Expr {
+	return b.left
+}
+
+func (b *LogicalExpr) Operator() Token {
+	return b.operator
+}
+
+func (b *LogicalExpr) Right() Expr {
+	return b.right
+}
+
+// NewCallExpr creates a CallExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewCallExpr(callee Expr, arguments []Expr, closingParen Token, span Span) *CallExpr {
+	return &CallExpr{callee: callee, arguments: arguments, closingParen: closingParen, span: span}
+}
+
+func (b *CallExpr) Callee() Expr {
+	return b.callee
+}
+
+func (b *CallExpr) Arguments() []Expr {
+	return b.arguments
+}
+
+func (b *CallExpr) ClosingParen() Token {
+	return b.closingParen
+}
+
+// NewGetExpr creates a GetExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewGetExpr(object Expr, name Token, span Span) *GetExpr {
+	return &GetExpr{object: object, name: name, span: span}
+}
+
+func (b *GetExpr) Object() Expr {
+	return b.object
+}
+
+func (b *GetExpr) Name() Token {
+	return b.name
+}
+
+// NewSetExpr creates a SetExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewSetExpr(object Expr, name Token, value Expr, span Span) *SetExpr {
+	return &SetExpr{object: object, name: name, value: value, span: span}
+}
+
+func (b *SetExpr) Object() Expr {
+	return b.object
+}
+
+func (b *SetExpr) Name() Token {
+	return b.name
+}
+
+func (b *SetExpr) Value() Expr {
+	return b.value
+}
+
+// NewThisExpr creates a ThisExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewThisExpr(keyword Token, span Span) *ThisExpr {
+	return &ThisExpr{keyword: keyword, span: span}
+}
+
+func (b *ThisExpr) Keyword() Token {
+	return b.keyword
+}
+
+// NewSuperExpr creates a SuperExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewSuperExpr(keyword Token, method Token, span Span) *SuperExpr {
+	return &SuperExpr{keyword: keyword, method: method, span: span}
+}
+
+func (b *SuperExpr) Keyword() Token {
+	return b.keyword
+}
+
+func (b *SuperExpr) Method() Token {
+	return b.method
+}
+
+// NewLambdaExpr creates a LambdaExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewLambdaExpr(keyword Token, parameters []Token, body []Stmt, span Span) *LambdaExpr {
+	return &LambdaExpr{keyword: keyword, parameters: parameters, body: body, span: span}
+}
+
+func (b *LambdaExpr) Keyword() Token {
+	return b.keyword
+}
+
+func (b *LambdaExpr) Parameters() []Token {
+	return b.parameters
+}
+
+func (b *LambdaExpr) Body() []Stmt {
+	return b.body
+}
+
+// NewListExpr creates a ListExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewListExpr(elements []Expr, span Span) *ListExpr {
+	return &ListExpr{elements: elements, span: span}
+}
+
+func (b *ListExpr) Elements() []Expr {
+	return b.elements
+}
+
+// NewMapExpr creates a MapExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewMapExpr(brace Token, keys []Expr, values []Expr, span Span) *MapExpr {
+	return &MapExpr{brace: brace, keys: keys, values: values, span: span}
+}
+
+func (b *MapExpr) Brace() Token {
+	return b.brace
+}
+
+func (b *MapExpr) Keys() []Expr {
+	return b.keys
+}
+
+func (b *MapExpr) Values() []Expr {
+	return b.values
+}
+
+// NewIndexExpr creates a IndexExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewIndexExpr(object Expr, bracket Token, index Expr, span Span) *IndexExpr {
+	return &IndexExpr{object: object, bracket: bracket, index: index, span: span}
+}
+
+func (b *IndexExpr) Object() Expr {
+	return b.object
+}
+
+func (b *IndexExpr) Bracket() Token {
+	return b.bracket
+}
+
+func (b *IndexExpr) Index() Expr {
+	return b.index
+}
+
+// NewIndexSetExpr creates a IndexSetExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewIndexSetExpr(object Expr, bracket Token, index Expr, value Expr, span Span) *IndexSetExpr {
+	return &IndexSetExpr{object: object, bracket: bracket, index: index, value: value, span: span}
+}
+
+func (b *IndexSetExpr) Object() Expr {
+	return b.object
+}
+
+func (b *IndexSetExpr) Bracket() Token {
+	return b.bracket
+}
+
+func (b *IndexSetExpr) Index() Expr {
+	return b.index
+}
+
+func (b *IndexSetExpr) Value() Expr {
+	return b.value
+}
+
+// NewSliceExpr creates a SliceExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewSliceExpr(object Expr, bracket Token, start Expr, end Expr, span Span) *SliceExpr {
+	return &SliceExpr{object: object, bracket: bracket, start: start, end: end, span: span}
+}
+
+func (b *SliceExpr) Object() Expr {
+	return b.object
+}
+
+func (b *SliceExpr) Bracket() Token {
+	return b.bracket
+}
+
+func (b *SliceExpr) Start() Expr {
+	return b.start
+}
+
+func (b *SliceExpr) End() Expr {
+	return b.end
+}
+
+// NewInterpolationExpr creates a InterpolationExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewInterpolationExpr(parts []Expr, span Span) *InterpolationExpr {
+	return &InterpolationExpr{parts: parts, span: span}
+}
+
+func (b *InterpolationExpr) Parts() []Expr {
+	return b.parts
+}
+
+// NewCompoundExpr creates a CompoundExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewCompoundExpr(target Expr, operator Token, value Expr, span Span) *CompoundExpr {
+	return &CompoundExpr{target: target, operator: operator, value: value, span: span}
+}
+
+func (b *CompoundExpr) Target() Expr {
+	return b.target
+}
+
+func (b *CompoundExpr) Operator() Token {
+	return b.operator
+}
+
+func (b *CompoundExpr) Value() Expr {
+	return b.value
+}
+
+// NewIncrementExpr creates a IncrementExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewIncrementExpr(target Expr, operator Token, prefix bool, span Span) *IncrementExpr {
+	return &IncrementExpr{target: target, operator: operator, prefix: prefix, span: span}
+}
+
+func (b *IncrementExpr) Target() Expr {
+	return b.target
+}
+
+func (b *IncrementExpr) Operator() Token {
+	return b.operator
+}
+
+func (b *IncrementExpr) Prefix() bool {
+	return b.prefix
+}
+
+// NewConditionalExpr creates a ConditionalExpr covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewConditionalExpr(condition Expr, thenBranch Expr, elseBranch Expr, span Span) *ConditionalExpr {
+	return &ConditionalExpr{condition: condition, thenBranch: thenBranch, elseBranch: elseBranch, span: span}
+}
+
+func (b *ConditionalExpr) Condition() Expr {
+	return b.condition
+}
+
+func (b *ConditionalExpr) ThenBranch() Expr {
+	return b.thenBranch
+}
+
+func (b *ConditionalExpr) ElseBranch() Expr {
+	return b.elseBranch
+}
+
+// NewExpressionStmt creates a ExpressionStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewExpressionStmt(expression Expr, span Span) *ExpressionStmt {
+	return &ExpressionStmt{expression: expression, span: span}
+}
+
+func (b *ExpressionStmt) Expression() Expr {
+	return b.expression
+}
+
+// NewPrintStmt creates a PrintStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewPrintStmt(expression Expr, span Span) *PrintStmt {
+	return &PrintStmt{expression: expression, span: span}
+}
+
+func (b *PrintStmt) Expression() Expr {
+	return b.expression
+}
+
+// NewVarStmt creates a VarStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewVarStmt(varName Token, initializerExpression Expr, span Span) *VarStmt {
+	return &VarStmt{varName: varName, initializerExpression: initializerExpression, span: span}
+}
+
+func (b *VarStmt) VarName() Token {
+	return b.varName
+}
+
+func (b *VarStmt) InitializerExpression() Expr {
+	return b.initializerExpression
+}
+
+// NewFunctionStmt creates a FunctionStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewFunctionStmt(name Token, parameters []Token, body []Stmt, span Span) *FunctionStmt {
+	return &FunctionStmt{name: name, parameters: parameters, body: body, span: span}
+}
+
+func (b *FunctionStmt) Name() Token {
+	return b.name
+}
+
+func (b *FunctionStmt) Parameters() []Token {
+	return b.parameters
+}
+
+func (b *FunctionStmt) Body() []Stmt {
+	return b.body
+}
+
+// NewReturnStmt creates a ReturnStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewReturnStmt(keyword Token, value Expr, span Span) *ReturnStmt {
+	return &ReturnStmt{keyword: keyword, value: value, span: span}
+}
+
+func (b *ReturnStmt) Keyword() Token {
+	return b.keyword
+}
+
+func (b *ReturnStmt) Value() Expr {
+	return b.value
+}
+
+// NewBlockStmt creates a BlockStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewBlockStmt(statements []Stmt, span Span) *BlockStmt {
+	return &BlockStmt{statements: statements, span: span}
+}
+
+func (b *BlockStmt) Statements() []Stmt {
+	return b.statements
+}
+
+// NewIfStmt creates a IfStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewIfStmt(condition Expr, thenBranch Stmt, elseBranch Stmt, span Span) *IfStmt {
+	return &IfStmt{condition: condition, thenBranch: thenBranch, elseBranch: elseBranch, span: span}
+}
+
+func (b *IfStmt) Condition() Expr {
+	return b.condition
+}
+
+func (b *IfStmt) ThenBranch() Stmt {
+	return b.thenBranch
+}
+
+func (b *IfStmt) ElseBranch() Stmt {
+	return b.elseBranch
+}
+
+// NewWhileStmt creates a WhileStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewWhileStmt(condition Expr, loopBody Stmt, label *Token, span Span) *WhileStmt {
+	return &WhileStmt{condition: condition, loopBody: loopBody, label: label, span: span}
+}
+
+func (b *WhileStmt) Condition() Expr {
+	return b.condition
+}
+
+func (b *WhileStmt) LoopBody() Stmt {
+	return b.loopBody
+}
+
+func (b *WhileStmt) Label() *Token {
+	return b.label
+}
+
+// NewForStmt creates a ForStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewForStmt(init Stmt, condition Expr, iteration Expr, loopBody Stmt, label *Token, span Span) *ForStmt {
+	return &ForStmt{init: init, condition: condition, iteration: iteration, loopBody: loopBody, label: label, span: span}
+}
+
+func (b *ForStmt) Init() Stmt {
+	return b.init
+}
+
+func (b *ForStmt) Condition() Expr {
+	return b.condition
+}
+
+func (b *ForStmt) Iteration() Expr {
+	return b.iteration
+}
+
+func (b *ForStmt) LoopBody() Stmt {
+	return b.loopBody
+}
+
+func (b *ForStmt) Label() *Token {
+	return b.label
+}
+
+// NewBreakStmt creates a BreakStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewBreakStmt(keyword Token, label *Token, span Span) *BreakStmt {
+	return &BreakStmt{keyword: keyword, label: label, span: span}
+}
+
+func (b *BreakStmt) Keyword() Token {
+	return b.keyword
+}
+
+func (b *BreakStmt) Label() *Token {
+	return b.label
+}
+
+// NewContinueStmt creates a ContinueStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewContinueStmt(keyword Token, label *Token, span Span) *ContinueStmt {
+	return &ContinueStmt{keyword: keyword, label: label, span: span}
+}
+
+func (b *ContinueStmt) Keyword() Token {
+	return b.keyword
+}
+
+func (b *ContinueStmt) Label() *Token {
+	return b.label
+}
+
+// NewThrowStmt creates a ThrowStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewThrowStmt(keyword Token, value Expr, span Span) *ThrowStmt {
+	return &ThrowStmt{keyword: keyword, value: value, span: span}
+}
+
+func (b *ThrowStmt) Keyword() Token {
+	return b.keyword
+}
+
+func (b *ThrowStmt) Value() Expr {
+	return b.value
+}
+
+// NewTryStmt creates a TryStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewTryStmt(keyword Token, body Stmt, catchName *Token, catchBody Stmt, finallyBody Stmt, span Span) *TryStmt {
+	return &TryStmt{keyword: keyword, body: body, catchName: catchName, catchBody: catchBody, finallyBody: finallyBody, span: span}
+}
+
+func (b *TryStmt) Keyword() Token {
+	return b.keyword
+}
+
+func (b *TryStmt) Body() Stmt {
+	return b.body
+}
+
+func (b *TryStmt) CatchName() *Token {
+	return b.catchName
+}
+
+func (b *TryStmt) CatchBody() Stmt {
+	return b.catchBody
+}
+
+func (b *TryStmt) FinallyBody() Stmt {
+	return b.finallyBody
+}
+
+// NewImportStmt creates a ImportStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewImportStmt(keyword Token, path Token, alias *Token, names []Token, span Span) *ImportStmt {
+	return &ImportStmt{keyword: keyword, path: path, alias: alias, names: names, span: span}
+}
+
+func (b *ImportStmt) Keyword() Token {
+	return b.keyword
+}
+
+func (b *ImportStmt) Path() Token {
+	return b.path
+}
+
+func (b *ImportStmt) Alias() *Token {
+	return b.alias
+}
+
+func (b *ImportStmt) Names() []Token {
+	return b.names
+}
+
+// NewExportStmt creates a ExportStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewExportStmt(keyword Token, declaration Stmt, span Span) *ExportStmt {
+	return &ExportStmt{keyword: keyword, declaration: declaration, span: span}
+}
+
+func (b *ExportStmt) Keyword() Token {
+	return b.keyword
+}
+
+func (b *ExportStmt) Declaration() Stmt {
+	return b.declaration
+}
+
+// NewClassStmt creates a ClassStmt covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+func NewClassStmt(name Token, superclass *VariableExpr, methods []*FunctionStmt, span Span) *ClassStmt {
+	return &ClassStmt{name: name, superclass: superclass, methods: methods, span: span}
+}
+
+func (b *ClassStmt) Name() Token {
+	return b.name
+}
+
+func (b *ClassStmt) Superclass() *VariableExpr {
+	return b.superclass
+}
+
+func (b *ClassStmt) Methods() []*FunctionStmt {
+	return b.methods
+}
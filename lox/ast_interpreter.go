@@ -0,0 +1,962 @@
+package lox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type AstInterpreter struct {
+	StubExprVisitor
+	StubStmtVisitor
+	Globals *Environment
+	env     *Environment
+	stdout  io.Writer
+	// execution stops with the context's error once it is cancelled
+	ctx  context.Context
+	done <-chan struct{}
+	// scope distance of each local variable access, filled in by the Resolver
+	locals map[Expr]int
+	// number of active Lox calls, bounded like the VM's frame stack
+	callDepth int
+	// name of the function being executed, empty for the top-level script
+	function string
+	// module whose code is being executed; Globals are its globals
+	module *LoxModule
+	// loads the modules of import statements, nil when imports aren't available
+	modules *moduleLoader
+}
+
+func NewAstInterpreter(stdout io.Writer) *AstInterpreter {
+	initialEnv := &Environment{
+		Values: builtinGlobals(),
+	}
+
+	return &AstInterpreter{
+		Globals: initialEnv,
+		env:     initialEnv,
+		stdout:  stdout,
+		ctx:     context.Background(),
+		locals:  make(map[Expr]int),
+		module:  &LoxModule{globals: initialEnv},
+	}
+}
+
+func (itp *AstInterpreter) resolve(expr Expr, depth int) {
+	itp.locals[expr] = depth
+}
+
+func (itp *AstInterpreter) lookUpVariable(name Token, expr Expr) (interface{}, error) {
+	if distance, ok := itp.locals[expr]; ok {
+		return itp.env.GetAt(distance, name.Lexeme), nil
+	}
+	return itp.Globals.Get(name)
+}
+
+// Interpret executes stmts in order, stopping at the first runtime error.
+// The result is the value of the last statement when it is an expression statement.
+func (itp *AstInterpreter) Interpret(ctx context.Context, stmts []Stmt) (result interface{}, err error) {
+	itp.ctx, itp.done = ctx, ctx.Done()
+	defer func() { itp.ctx, itp.done = context.Background(), nil }()
+
+	for _, stmt := range stmts {
+		result, err = stmt.Accept(itp)
+		if err != nil {
+			return nil, traceScript(err)
+		}
+		if _, ok := stmt.(*ExpressionStmt); !ok {
+			result = nil
+		}
+	}
+	return result, nil
+}
+
+func (itp *AstInterpreter) InterpretExpr(ctx context.Context, e Expr) (result interface{}, err error) {
+	itp.ctx, itp.done = ctx, ctx.Done()
+	defer func() { itp.ctx, itp.done = context.Background(), nil }()
+
+	result, err = e.Accept(itp)
+	return result, traceScript(err)
+}
+
+// traceScript completes the trace of an error that escaped function calls with the top-level frame
+func traceScript(err error) error {
+	if runtimeErr, ok := err.(*RuntimeError); ok && len(runtimeErr.Trace) > 0 {
+		runtimeErr.enterFrame("")
+	}
+	return err
+}
+
+// interrupted returns the context's error once it is cancelled; it is checked on every loop iteration and call
+func (itp *AstInterpreter) interrupted() error {
+	select {
+	case <-itp.done:
+		return itp.ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// runModule executes the top-level statements of an imported module in the module's globals
+func (itp *AstInterpreter) runModule(stmts []Stmt, module *LoxModule) error {
+	previousEnv, previousModule := itp.env, itp.module
+	itp.env, itp.module, itp.Globals = module.globals, module, module.globals
+	defer func() { itp.env, itp.module, itp.Globals = previousEnv, previousModule, previousModule.globals }()
+
+	for _, stmt := range stmts {
+		if _, err := stmt.Accept(itp); err != nil {
+			if runtimeErr, ok := err.(*RuntimeError); ok {
+				if len(runtimeErr.Trace) == 0 {
+					runtimeErr.module = module
+				}
+				// the module's top level is a frame of its own, as in the VM
+				runtimeErr.enterModule(module)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (itp *AstInterpreter) VisitImportStmt(s *ImportStmt) (result interface{}, err error) {
+	if itp.modules == nil {
+		return nil, newRuntimeError(s.path, "Can't import modules without an Interpreter.")
+	}
+	module, err := itp.modules.load(s.path.Literal.(string))
+	if err != nil {
+		if runtimeErr, ok := locateError(err, s.path).(*RuntimeError); ok {
+			// the importing file is executing the import
+			runtimeErr.line = s.path.Line + 1
+		}
+		return nil, err
+	}
+
+	if s.alias != nil {
+		itp.env.Define(s.alias.Lexeme, module)
+		return nil, nil
+	}
+	for _, name := range s.names {
+		value, err := module.export(name.Lexeme)
+		if err != nil {
+			return nil, locateError(err, name)
+		}
+		itp.env.Define(name.Lexeme, value)
+	}
+	return nil, nil
+}
+
+func (itp *AstInterpreter) VisitExportStmt(s *ExportStmt) (result interface{}, err error) {
+	return s.declaration.Accept(itp)
+}
+
+func (itp *AstInterpreter) VisitFunctionStmt(s *FunctionStmt) (result interface{}, err error) {
+	loxFunc := &LoxFunction{
+		name:       s.name.Lexeme,
+		parameters: s.parameters,
+		body:       s.body,
+		closure:    itp.env, // store in memory the environment (hierarchy) that was active on function declaration
+		module:     itp.module,
+	}
+	itp.env.Define(s.name.Lexeme, loxFunc)
+	return nil, nil
+}
+
+func (itp *AstInterpreter) VisitClassStmt(s *ClassStmt) (result interface{}, err error) {
+	var superclass *LoxClass
+	if s.superclass != nil {
+		superclassValue, err := s.superclass.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		superclass, ok = superclassValue.(*LoxClass)
+		if !ok {
+			return nil, newRuntimeError(s.superclass.variableName, "Superclass must be a class.")
+		}
+	}
+
+	itp.env.Define(s.name.Lexeme, nil)
+
+	methodsEnv := itp.env
+	if superclass != nil {
+		// methods of a subclass close over an extra environment holding "super"
+		methodsEnv = &Environment{
+			Enclosing: itp.env,
+			Values:    make(map[string]interface{}),
+		}
+		methodsEnv.Define("super", superclass)
+	}
+
+	methods := make(map[string]*LoxFunction)
+	for _, method := range s.methods {
+		methods[method.name.Lexeme] = &LoxFunction{
+			name:          method.name.Lexeme,
+			parameters:    method.parameters,
+			body:          method.body,
+			closure:       methodsEnv,
+			isInitializer: method.name.Lexeme == "init",
+			module:        itp.module,
+		}
+	}
+
+	class := &LoxClass{
+		name:       s.name.Lexeme,
+		superclass: superclass,
+		methods:    methods,
+	}
+
+	return nil, itp.env.Assign(s.name, class)
+}
+
+func (itp *AstInterpreter) VisitReturnStmt(s *ReturnStmt) (result interface{}, err error) {
+	var returnValue interface{} // empty return value defaults to nil
+	if s.value != nil {
+		returnValue, err = s.value.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, &ReturnUnwindCallstack{returnValue}
+}
+
+func (itp *AstInterpreter) VisitThrowStmt(s *ThrowStmt) (result interface{}, err error) {
+	value, err := s.value.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+	return nil, locateError(thrownError(value), s.keyword)
+}
+
+func (itp *AstInterpreter) VisitTryStmt(s *TryStmt) (result interface{}, err error) {
+	_, err = s.body.Accept(itp)
+
+	if runtimeErr, ok := err.(*RuntimeError); ok && s.catchBody != nil {
+		// the frames the error unwound through, then the one catching it
+		frame := StackFrame{Function: itp.function, Line: runtimeErr.line}
+		if itp.function == "" {
+			frame.Module = itp.module.name
+		}
+		stack := append(runtimeErr.Trace, frame)
+
+		previousEnv := itp.env
+		itp.env = &Environment{
+			Enclosing: previousEnv,
+			Values:    make(map[string]interface{}),
+		}
+		itp.env.Define(s.catchName.Lexeme, caughtValue(runtimeErr, stack))
+		_, err = s.catchBody.Accept(itp)
+		itp.env = previousEnv
+	}
+
+	// finally also runs when a return, break or continue leaves the try or catch block, and its own
+	// completion replaces theirs; only a cancelled program stops without it, as in the VM
+	if s.finallyBody != nil && (err == nil || err != itp.ctx.Err()) {
+		if _, finallyErr := s.finallyBody.Accept(itp); finallyErr != nil {
+			err = finallyErr
+		}
+	}
+	return nil, err
+}
+
+func (itp *AstInterpreter) VisitBreakStmt(s *BreakStmt) (result interface{}, err error) {
+	return nil, &LoopUnwind{Keyword: s.keyword, Label: s.label}
+}
+
+func (itp *AstInterpreter) VisitContinueStmt(s *ContinueStmt) (result interface{}, err error) {
+	return nil, &LoopUnwind{Keyword: s.keyword, Label: s.label}
+}
+
+// LoopUnwind unwinds the statements of a loop body up to the loop a break or continue statement targets:
+// the innermost loop, or the enclosing loop with the same label
+type LoopUnwind struct {
+	Keyword Token
+	Label   *Token
+}
+
+func (l *LoopUnwind) Error() string {
+	return l.Keyword.Lexeme + " statement - everything OK, no errors"
+}
+
+// endIteration handles the error a loop body finished with: a break or continue targeting the loop
+// labeled label is consumed, stop reporting whether it was a break; any other error is returned
+func endIteration(err error, label *Token) (stop bool, remaining error) {
+	unwind, ok := err.(*LoopUnwind)
+	if !ok {
+		return false, err
+	}
+	if unwind.Label != nil && (label == nil || label.Lexeme != unwind.Label.Lexeme) {
+		return false, err
+	}
+	return unwind.Keyword.Type == Break, nil
+}
+
+// TODO: fix useless result for statements (remove)
+func (itp *AstInterpreter) VisitForStmt(s *ForStmt) (result interface{}, err error) {
+	// variables declared in the for header are scoped to the loop
+	previousEnv := itp.env
+	itp.env = &Environment{
+		Enclosing: previousEnv,
+		Values:    make(map[string]interface{}),
+	}
+	defer func() { itp.env = previousEnv }()
+
+	if s.init != nil {
+		_, err = s.init.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var condition Expr
+	if s.condition != nil {
+		condition = s.condition
+	} else {
+		condition = &LiteralExpr{value: true}
+	}
+
+	condResult, err := condition.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	for isTruthy(condResult) {
+		if err := itp.interrupted(); err != nil {
+			return nil, err
+		}
+
+		_, err = s.loopBody.Accept(itp)
+		if stop, err := endIteration(err, s.label); err != nil {
+			return nil, err
+		} else if stop {
+			break
+		}
+
+		// a continue still runs the iteration clause
+		if s.iteration != nil {
+			_, err = s.iteration.Accept(itp)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		condResult, err = condition.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+func (itp *AstInterpreter) VisitWhileStmt(s *WhileStmt) (result interface{}, err error) {
+	condResult, err := s.condition.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	for isTruthy(condResult) {
+		if err := itp.interrupted(); err != nil {
+			return nil, err
+		}
+
+		_, err = s.loopBody.Accept(itp)
+		if stop, err := endIteration(err, s.label); err != nil {
+			return nil, err
+		} else if stop {
+			break
+		}
+
+		condResult, err = s.condition.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+func (itp *AstInterpreter) VisitIfStmt(s *IfStmt) (result interface{}, err error) {
+	condResult, err := s.condition.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTruthy(condResult) {
+		return s.thenBranch.Accept(itp)
+	} else if s.elseBranch != nil {
+		return s.elseBranch.Accept(itp)
+	}
+
+	// if cond was false and no else branch, noop
+	return nil, nil
+}
+
+func (itp *AstInterpreter) VisitBlockStmt(s *BlockStmt) (result interface{}, err error) {
+	previousEnv := itp.env
+	itp.env = &Environment{
+		Enclosing: previousEnv,
+		Values:    make(map[string]interface{}),
+	}
+	defer func() { itp.env = previousEnv }()
+
+	for _, stmt := range s.statements {
+		result, err = stmt.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+func (itp *AstInterpreter) VisitVarStmt(s *VarStmt) (result interface{}, err error) {
+	var varValue interface{}
+	if s.initializerExpression != nil {
+		varValue, err = s.initializerExpression.Accept(itp)
+	}
+
+	itp.env.Define(s.varName.Lexeme, varValue)
+	return nil, err
+}
+
+func (itp *AstInterpreter) VisitPrintStmt(s *PrintStmt) (result interface{}, err error) {
+	result, err = s.expression.Accept(itp)
+	if err == nil {
+		fmt.Fprintln(itp.stdout, loxStringify(result))
+	}
+	return nil, err
+}
+
+func (itp *AstInterpreter) VisitExpressionStmt(s *ExpressionStmt) (result interface{}, err error) {
+	result, err = s.expression.Accept(itp)
+	return result, err
+}
+
+func (itp *AstInterpreter) VisitCallExpr(e *CallExpr) (result interface{}, err error) {
+	callee, err := e.callee.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+	var args []interface{}
+	for _, arg := range e.arguments {
+		argResult, err := arg.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, argResult)
+	}
+
+	if function, ok := callee.(LoxCallable); ok {
+		if arity := function.Arity(); arity >= 0 && arity != len(args) {
+			return nil, newRuntimeError(e.closingParen, "Expected %d arguments but got %d.", arity, len(args))
+		}
+
+		// the top-level script takes up a frame as well, as in the VM
+		if itp.callDepth+1 == maxFrames {
+			return nil, newRuntimeError(e.closingParen, "Stack overflow.")
+		}
+		if err := itp.interrupted(); err != nil {
+			return nil, err
+		}
+		itp.callDepth++
+		defer func() { itp.callDepth-- }()
+
+		callResult, err := function.Call(itp, args)
+		if err != nil {
+			if runtimeErr, ok := locateError(err, e.closingParen).(*RuntimeError); ok {
+				// the caller's frame is executing the call
+				runtimeErr.line = e.closingParen.Line + 1
+			}
+			return nil, err
+		}
+
+		return callResult, nil
+	}
+
+	return nil, newRuntimeError(e.closingParen, "Can only call functions and classes.")
+}
+
+func (itp *AstInterpreter) VisitGetExpr(e *GetExpr) (result interface{}, err error) {
+	object, err := e.object.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance, ok := object.(*LoxInstance); ok {
+		return instance.Get(e.name)
+	}
+	if module, ok := object.(*LoxModule); ok {
+		value, err := module.export(e.name.Lexeme)
+		return value, locateError(err, e.name)
+	}
+	if loxErr, ok := object.(*LoxError); ok {
+		if value, ok := loxErr.field(e.name.Lexeme); ok {
+			return value, nil
+		}
+		return nil, newRuntimeError(e.name, "Undefined property '%s'.", e.name.Lexeme)
+	}
+	if collection, ok := asCollection(object); ok {
+		if method, ok := collection.method(e.name.Lexeme); ok {
+			return method, nil
+		}
+		return nil, newRuntimeError(e.name, "Undefined property '%s'.", e.name.Lexeme)
+	}
+
+	return nil, newRuntimeError(e.name, "Only instances have properties.")
+}
+
+func (itp *AstInterpreter) VisitSetExpr(e *SetExpr) (result interface{}, err error) {
+	object, err := e.object.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := object.(*LoxInstance)
+	if !ok {
+		return nil, newRuntimeError(e.name, "Only instances have fields.")
+	}
+
+	value, err := e.value.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	instance.Set(e.name, value)
+	return value, nil
+}
+
+func (itp *AstInterpreter) VisitLambdaExpr(e *LambdaExpr) (result interface{}, err error) {
+	return &LoxFunction{
+		name:       anonymousFunction,
+		parameters: e.parameters,
+		body:       e.body,
+		closure:    itp.env,
+		module:     itp.module,
+	}, nil
+}
+
+func (itp *AstInterpreter) VisitListExpr(e *ListExpr) (result interface{}, err error) {
+	elements := make([]interface{}, len(e.elements))
+	for i, element := range e.elements {
+		elements[i], err = element.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &LoxList{elements: elements}, nil
+}
+
+// VisitInterpolationExpr concatenates the parts of the string, printing embedded values like print does
+func (itp *AstInterpreter) VisitInterpolationExpr(e *InterpolationExpr) (result interface{}, err error) {
+	var built strings.Builder
+	for _, part := range e.parts {
+		value, err := part.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+		built.WriteString(loxStringify(value))
+	}
+	return built.String(), nil
+}
+
+func (itp *AstInterpreter) VisitMapExpr(e *MapExpr) (result interface{}, err error) {
+	m := newLoxMap()
+	for i, keyExpr := range e.keys {
+		key, err := keyExpr.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+		value, err := e.values[i].Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.Set(key, value); err != nil {
+			return nil, locateError(err, e.brace)
+		}
+	}
+	return m, nil
+}
+
+func (itp *AstInterpreter) VisitIndexExpr(e *IndexExpr) (result interface{}, err error) {
+	object, err := e.object.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+	index, err := e.index.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, ok := asCollection(object)
+	if !ok {
+		return nil, newRuntimeError(e.bracket, "Only lists, maps and strings can be indexed.")
+	}
+	result, err = collection.Get(index)
+	return result, locateError(err, e.bracket)
+}
+
+func (itp *AstInterpreter) VisitIndexSetExpr(e *IndexSetExpr) (result interface{}, err error) {
+	object, err := e.object.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+	index, err := e.index.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+	value, err := e.value.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, ok := asCollection(object)
+	if !ok {
+		return nil, newRuntimeError(e.bracket, "Only lists, maps and strings can be indexed.")
+	}
+	if err := collection.Set(index, value); err != nil {
+		return nil, locateError(err, e.bracket)
+	}
+	return value, nil
+}
+
+func (itp *AstInterpreter) VisitSliceExpr(e *SliceExpr) (result interface{}, err error) {
+	object, err := e.object.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	// omitted bounds evaluate to nil, as in the VM
+	var bounds [2]interface{}
+	for i, bound := range []Expr{e.start, e.end} {
+		if bound == nil {
+			continue
+		}
+		bounds[i], err = bound.Accept(itp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err = sliceValue(object, bounds[0], bounds[1])
+	return result, locateError(err, e.bracket)
+}
+
+func (itp *AstInterpreter) VisitThisExpr(e *ThisExpr) (result interface{}, err error) {
+	return itp.lookUpVariable(e.keyword, e)
+}
+
+func (itp *AstInterpreter) VisitSuperExpr(e *SuperExpr) (result interface{}, err error) {
+	distance := itp.locals[e]
+	superclass := itp.env.GetAt(distance, "super").(*LoxClass)
+	// "this" is bound in the environment right inside the one holding "super"
+	instance := itp.env.GetAt(distance-1, "this")
+
+	method := superclass.findMethod(e.method.Lexeme)
+	if method == nil {
+		return nil, newRuntimeError(e.method, "Undefined property '%s'.", e.method.Lexeme)
+	}
+
+	return method.bind(instance.(*LoxInstance)), nil
+}
+
+func (itp *AstInterpreter) VisitLogicalExpr(e *LogicalExpr) (result interface{}, err error) {
+	leftResult, err := e.left.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	// short-circuit
+	if e.operator.Type == And {
+		if !isTruthy(leftResult) {
+			return leftResult, nil
+		}
+	} else if e.operator.Type == Or {
+		if isTruthy(leftResult) {
+			return leftResult, nil
+		}
+	} else {
+		panic("Unsupported logical operator " + e.operator.Lexeme)
+	}
+
+	return e.right.Accept(itp)
+}
+
+func (itp *AstInterpreter) VisitAssignExpr(e *AssignExpr) (result interface{}, err error) {
+	result, err = e.assignValue.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	if distance, ok := itp.locals[e]; ok {
+		itp.env.AssignAt(distance, e.variableName, result)
+		return result, nil
+	}
+
+	assignErr := itp.Globals.Assign(e.variableName, result)
+	if assignErr != nil {
+		return nil, assignErr
+	}
+
+	return result, nil
+}
+
+func (itp *AstInterpreter) VisitVariableExpr(e *VariableExpr) (result interface{}, err error) {
+	return itp.lookUpVariable(e.variableName, e)
+}
+
+func (itp *AstInterpreter) VisitBinaryExpr(e *BinaryExpr) (result interface{}, err error) {
+	leftExpr, err := e.left.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+	rightExpr, err := e.right.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = binaryOperation(e.operator.Type, leftExpr, rightExpr)
+	return result, locateError(err, e.operator)
+}
+
+// binaryOperation applies a binary operator other than "and" and "or" to two values
+func binaryOperation(operator TokenType, leftExpr, rightExpr interface{}) (interface{}, error) {
+	leftNumber, okLeftNumber := leftExpr.(float64)
+	rightNumber, okRightNumber := rightExpr.(float64)
+
+	switch operator {
+	case Star, Slash, Percent, TildeSlash, StarStar, Minus, Greater, GreaterEqual, Less, LessEqual:
+		if !(okLeftNumber && okRightNumber) {
+			return nil, runtimeErrorf("Operands must be numbers.")
+		}
+
+		switch operator {
+		case Star:
+			return leftNumber * rightNumber, nil
+		case Slash:
+			return leftNumber / rightNumber, nil
+		case Percent:
+			return modulo(leftNumber, rightNumber), nil
+		case TildeSlash:
+			return math.Floor(leftNumber / rightNumber), nil
+		case StarStar:
+			return math.Pow(leftNumber, rightNumber), nil
+		case Minus:
+			return leftNumber - rightNumber, nil
+		case Greater:
+			return leftNumber > rightNumber, nil
+		case GreaterEqual:
+			return leftNumber >= rightNumber, nil
+		case Less:
+			return leftNumber < rightNumber, nil
+		case LessEqual:
+			return leftNumber <= rightNumber, nil
+		}
+		panic("unreachable")
+	case Plus:
+		if okLeftNumber && okRightNumber {
+			return leftNumber + rightNumber, nil
+		}
+		leftString, okLeft := leftExpr.(string)
+		rightString, okRight := rightExpr.(string)
+		if okLeft && okRight {
+			return leftString + rightString, nil
+		}
+		return nil, runtimeErrorf("Operands must be two numbers or two strings.")
+	case EqualEqual:
+		return leftExpr == rightExpr, nil
+	case BangEqual:
+		return leftExpr != rightExpr, nil
+	}
+	panic("Unsupported binary operator!")
+}
+
+// place is the target of a compound assignment or increment, with its object and index evaluated
+type place struct {
+	get func() (interface{}, error)
+	set func(value interface{}) error
+}
+
+// place evaluates the object and index of a target once, so that reading and then writing it
+// doesn't repeat their side effects
+func (itp *AstInterpreter) place(target Expr) (place, error) {
+	switch target := target.(type) {
+	case *VariableExpr:
+		return place{
+			get: func() (interface{}, error) { return itp.lookUpVariable(target.variableName, target) },
+			set: func(value interface{}) error {
+				if distance, ok := itp.locals[target]; ok {
+					itp.env.AssignAt(distance, target.variableName, value)
+					return nil
+				}
+				return itp.Globals.Assign(target.variableName, value)
+			},
+		}, nil
+	case *GetExpr:
+		object, err := target.object.Accept(itp)
+		if err != nil {
+			return place{}, err
+		}
+		instance, ok := object.(*LoxInstance)
+		if !ok {
+			return place{}, newRuntimeError(target.name, "Only instances have fields.")
+		}
+		return place{
+			get: func() (interface{}, error) { return instance.Get(target.name) },
+			set: func(value interface{}) error {
+				instance.Set(target.name, value)
+				return nil
+			},
+		}, nil
+	case *IndexExpr:
+		object, err := target.object.Accept(itp)
+		if err != nil {
+			return place{}, err
+		}
+		index, err := target.index.Accept(itp)
+		if err != nil {
+			return place{}, err
+		}
+		collection, ok := asCollection(object)
+		if !ok {
+			return place{}, newRuntimeError(target.bracket, "Only lists, maps and strings can be indexed.")
+		}
+		return place{
+			get: func() (interface{}, error) {
+				value, err := collection.Get(index)
+				return value, locateError(err, target.bracket)
+			},
+			set: func(value interface{}) error { return locateError(collection.Set(index, value), target.bracket) },
+		}, nil
+	}
+	panic("Unsupported assignment target!")
+}
+
+// VisitCompoundExpr reads the target before evaluating the value, then stores the combination back
+func (itp *AstInterpreter) VisitCompoundExpr(e *CompoundExpr) (result interface{}, err error) {
+	target, err := itp.place(e.target)
+	if err != nil {
+		return nil, err
+	}
+	current, err := target.get()
+	if err != nil {
+		return nil, err
+	}
+	value, err := e.value.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = binaryOperation(compoundOperators[e.operator.Type], current, value)
+	if err != nil {
+		return nil, locateError(err, e.operator)
+	}
+	if err := target.set(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// VisitIncrementExpr adds or subtracts one; a prefix increment evaluates to the new value, a postfix
+// one to the value before
+func (itp *AstInterpreter) VisitIncrementExpr(e *IncrementExpr) (result interface{}, err error) {
+	target, err := itp.place(e.target)
+	if err != nil {
+		return nil, err
+	}
+	current, err := target.get()
+	if err != nil {
+		return nil, err
+	}
+	number, ok := current.(float64)
+	if !ok {
+		return nil, newRuntimeError(e.operator, "Operand must be a number.")
+	}
+
+	updated := number + 1
+	if e.operator.Type == MinusMinus {
+		updated = number - 1
+	}
+	if err := target.set(updated); err != nil {
+		return nil, err
+	}
+	if e.prefix {
+		return updated, nil
+	}
+	return number, nil
+}
+
+func (itp *AstInterpreter) VisitConditionalExpr(e *ConditionalExpr) (result interface{}, err error) {
+	condition, err := e.condition.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+	if isTruthy(condition) {
+		return e.thenBranch.Accept(itp)
+	}
+	return e.elseBranch.Accept(itp)
+}
+
+func (itp *AstInterpreter) VisitUnaryExpr(e *UnaryExpr) (result interface{}, err error) {
+	rightExpr, err := e.right.Accept(itp)
+	if err != nil {
+		return nil, err
+	}
+	switch e.operator.Type {
+	case Bang:
+		return !isTruthy(rightExpr), err
+	case Minus:
+		rightNumber, ok := rightExpr.(float64)
+		if !ok {
+			return nil, newRuntimeError(e.operator, "Operand must be a number.")
+		}
+		return -rightNumber, err
+	}
+	panic("Unsupported unary operator!")
+}
+
+func (itp *AstInterpreter) VisitGroupingExpr(e *GroupingExpr) (result interface{}, err error) {
+	return e.expr.Accept(itp)
+}
+
+func (itp *AstInterpreter) VisitLiteralExpr(e *LiteralExpr) (result interface{}, err error) {
+	return e.value, nil
+}
+
+// modulo is the remainder of the floored division of a by b, so that it takes the sign of b and
+// a == (a ~/ b) * b + a % b
+func modulo(a, b float64) float64 {
+	remainder := math.Mod(a, b)
+	if remainder != 0 && (remainder < 0) != (b < 0) {
+		remainder += b
+	}
+	return remainder
+}
+
+func isTruthy(val interface{}) bool {
+	if val == nil {
+		return false
+	}
+	valAsBool, ok := val.(bool)
+	if ok {
+		return valAsBool
+	}
+
+	return true
+}
+
+func loxStringify(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
@@ -0,0 +1,269 @@
+package lox
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// MarshalProgram encodes stmts as a JSON array for tools that work on the AST. Every node is an
+// object with its Go type name as "type", its "span" and its fields under their grammar.json names:
+//
+//	{"type": "PrintStmt", "span": {"start": 0, "end": 8}, "expression": {"type": "LiteralExpr", ...}}
+//
+// Child nodes are nested objects, or null when missing; tokens are objects with their type, lexeme,
+// literal, line and column (both 1-based) and span. The encoding of each node is generated from
+// grammar.json along with the node types.
+func MarshalProgram(stmts []Stmt) ([]byte, error) {
+	if stmts == nil {
+		stmts = []Stmt{}
+	}
+	return json.MarshalIndent(stmts, "", "  ")
+}
+
+// UnmarshalProgram rebuilds the statements encoded by MarshalProgram. The program may have been
+// generated or changed by a tool, so anything the parser would have rejected or never produces is an
+// error: a missing child, an operator the node doesn't apply, a literal value Lox doesn't have, a
+// break outside of a loop and so on. Names are resolved by Interpreter.Exec, as for any program.
+func UnmarshalProgram(data []byte) ([]Stmt, error) {
+	var nodes []json.RawMessage
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return nil, err
+	}
+	stmts, err := decodeStmts(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	checker := &decodedChecker{}
+	for _, stmt := range stmts {
+		checker.check(stmt)
+	}
+	if checker.err != nil {
+		return nil, checker.err
+	}
+	return stmts, nil
+}
+
+// UnmarshalExpr rebuilds an expression from the JSON object json.Marshal encodes it as, checked like
+// the statements of UnmarshalProgram
+func UnmarshalExpr(data []byte) (Expr, error) {
+	expr, err := decodeExpr(data)
+	if err != nil {
+		return nil, err
+	}
+	if expr == nil {
+		return nil, fmt.Errorf("expression is null")
+	}
+
+	checker := &decodedChecker{}
+	checker.check(expr)
+	if checker.err != nil {
+		return nil, checker.err
+	}
+	return expr, nil
+}
+
+// the token types the parser builds each kind of operator node with
+var (
+	unaryOperators     = []TokenType{Minus, Bang}
+	binaryOperators    = []TokenType{EqualEqual, BangEqual, Greater, GreaterEqual, Less, LessEqual, Plus, Minus, Star, Slash, Percent, TildeSlash, StarStar}
+	logicalOperators   = []TokenType{And, Or}
+	incrementOperators = []TokenType{PlusPlus, MinusMinus}
+)
+
+// decodedChecker finds what the parser checks for, or never produces, in a decoded program, so
+// that the backends needn't expect it
+type decodedChecker struct {
+	// labels of the loops around the current statement in its function, innermost last; "" for a loop
+	// without one
+	loops []string
+	// the first problem found
+	err error
+}
+
+func (c *decodedChecker) check(node Node) {
+	Walk(node, c.visit)
+}
+
+func (c *decodedChecker) errorf(node string, token Token, format string, a ...any) {
+	if c.err == nil {
+		c.err = fmt.Errorf("%s at line %d: %s", node, token.Line+1, fmt.Sprintf(format, a...))
+	}
+}
+
+func (c *decodedChecker) visit(node Node) bool {
+	if c.err != nil {
+		return false
+	}
+
+	switch n := node.(type) {
+	case *VariableExpr:
+		c.name("VariableExpr", n.variableName)
+	case *AssignExpr:
+		c.name("AssignExpr", n.variableName)
+	case *VarStmt:
+		c.name("VarStmt", n.varName)
+	case *ClassStmt:
+		c.name("ClassStmt", n.name)
+	case *UnaryExpr:
+		c.operator("UnaryExpr", n.operator, unaryOperators)
+	case *BinaryExpr:
+		c.operator("BinaryExpr", n.operator, binaryOperators)
+	case *LogicalExpr:
+		c.operator("LogicalExpr", n.operator, logicalOperators)
+	case *CompoundExpr:
+		if _, ok := compoundOperators[n.operator.Type]; !ok {
+			c.errorf("CompoundExpr", n.operator, "unsupported operator %q", n.operator.Type)
+		}
+		c.target("CompoundExpr", n.operator, n.target)
+	case *IncrementExpr:
+		c.operator("IncrementExpr", n.operator, incrementOperators)
+		c.target("IncrementExpr", n.operator, n.target)
+	case *LiteralExpr:
+		switch n.value.(type) {
+		case nil, bool, float64, string:
+		default:
+			if c.err == nil {
+				c.err = fmt.Errorf("LiteralExpr: unsupported value of type %T", n.value)
+			}
+		}
+	case *ThisExpr:
+		c.keyword("ThisExpr", n.keyword, "this")
+	case *SuperExpr:
+		c.keyword("SuperExpr", n.keyword, "super")
+	case *MapExpr:
+		if len(n.keys) != len(n.values) {
+			c.errorf("MapExpr", n.brace, "%d keys but %d values", len(n.keys), len(n.values))
+		}
+	case *CallExpr:
+		if len(n.arguments) > 255 {
+			c.errorf("CallExpr", n.closingParen, "more than 255 arguments")
+		}
+	case *LambdaExpr:
+		c.function("LambdaExpr", n.keyword, n.parameters, n.body)
+		return false
+	case *FunctionStmt:
+		c.name("FunctionStmt", n.name)
+		c.function("FunctionStmt", n.name, n.parameters, n.body)
+		return false
+	case *WhileStmt:
+		c.loop(n.label, n.condition, n.loopBody)
+		return false
+	case *ForStmt:
+		c.check(n.init)
+		c.loop(n.label, n.condition, n.iteration, n.loopBody)
+		return false
+	case *BreakStmt:
+		c.loopControl("BreakStmt", n.keyword, n.label)
+	case *ContinueStmt:
+		c.loopControl("ContinueStmt", n.keyword, n.label)
+	case *TryStmt:
+		if n.catchName != nil {
+			c.name("TryStmt", *n.catchName)
+		}
+		switch {
+		case n.catchBody == nil && n.finallyBody == nil:
+			c.errorf("TryStmt", n.keyword, "neither catchBody nor finallyBody")
+		case (n.catchName == nil) != (n.catchBody == nil):
+			c.errorf("TryStmt", n.keyword, "catchName and catchBody must be given together")
+		}
+	case *ImportStmt:
+		if _, ok := n.path.Literal.(string); !ok {
+			c.errorf("ImportStmt", n.keyword, "path has no string literal")
+		} else if (n.alias == nil) == (len(n.names) == 0) {
+			c.errorf("ImportStmt", n.keyword, "needs either an alias or names")
+		}
+		if n.alias != nil {
+			c.name("ImportStmt", *n.alias)
+		}
+		for _, name := range n.names {
+			c.name("ImportStmt", name)
+		}
+	case *ExportStmt:
+		switch n.declaration.(type) {
+		case *VarStmt, *FunctionStmt, *ClassStmt:
+		default:
+			c.errorf("ExportStmt", n.keyword, "can't export a %s", nodeName(n.declaration))
+		}
+	}
+	return true
+}
+
+func (c *decodedChecker) operator(node string, operator Token, allowed []TokenType) {
+	if !slices.Contains(allowed, operator.Type) {
+		c.errorf(node, operator, "unsupported operator %q", operator.Type)
+	}
+}
+
+// name checks a token naming a variable, which the backends rely on being an identifier as the
+// scanner reads them
+func (c *decodedChecker) name(node string, name Token) {
+	runes := []rune(name.Lexeme)
+	ok := len(runes) > 0 && isIdentifierStart(runes[0]) && ReservedKeywords[name.Lexeme] == ""
+	for _, r := range runes[min(1, len(runes)):] {
+		ok = ok && isIdentifierPart(r)
+	}
+	if !ok {
+		c.errorf(node, name, "%q is not an identifier", name.Lexeme)
+	}
+}
+
+// keyword checks the keyword of a this or super expression, which is resolved like a variable by its
+// lexeme
+func (c *decodedChecker) keyword(node string, keyword Token, lexeme string) {
+	if keyword.Lexeme != lexeme {
+		c.errorf(node, keyword, "keyword must be %q", lexeme)
+	}
+}
+
+// target checks the target of a compound assignment or increment, see isAssignable
+func (c *decodedChecker) target(node string, operator Token, target Expr) {
+	if !isAssignable(target) {
+		c.errorf(node, operator, "can't assign to a %s", nodeName(target))
+	}
+}
+
+// function checks a function body, where no loop is around a break or continue statement
+func (c *decodedChecker) function(node string, name Token, parameters []Token, body []Stmt) {
+	if len(parameters) > 255 {
+		c.errorf(node, name, "more than 255 parameters")
+	}
+	for _, parameter := range parameters {
+		c.name(node, parameter)
+	}
+
+	loops := c.loops
+	c.loops = nil
+	for _, stmt := range body {
+		c.check(stmt)
+	}
+	c.loops = loops
+}
+
+func (c *decodedChecker) loop(label *Token, parts ...Node) {
+	name := ""
+	if label != nil {
+		name = label.Lexeme
+	}
+	c.loops = append(c.loops, name)
+	for _, part := range parts {
+		c.check(part)
+	}
+	c.loops = c.loops[:len(c.loops)-1]
+}
+
+func (c *decodedChecker) loopControl(node string, keyword Token, label *Token) {
+	switch {
+	case len(c.loops) == 0:
+		c.errorf(node, keyword, "outside of a loop")
+	case label != nil && !slices.Contains(c.loops, label.Lexeme):
+		c.errorf(node, *label, "no enclosing loop is labeled %q", label.Lexeme)
+	}
+}
+
+// nodeName is the name of the type of node, e.g. "BinaryExpr"
+func nodeName(node Node) string {
+	return reflect.TypeOf(node).Elem().Name()
+}
@@ -0,0 +1,171 @@
+package lox
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"empty", ``},
+		{"expressions", `print 1 + 2 * -3; print !true or nil and "s"; print 2 ** 3 ~/ 2 % 5; print 1 < 2 ? "a" : "b";`},
+		{"literals", `print 0xFF; print 1_000.5; print "a\tb${1 + 2}c"; print nil; print false;`},
+		{"assignments", `var x = 1; x = 2; x += 3; x++; --x; var l = [1]; l[0] *= 2; print l[0:1];`},
+		{"functions", `fun add(a, b) { return a + b; } var f = fun (x) { return x; }; var g = (x) => x * 2; print add(f(1), g(2));`},
+		{"classes", `class A { init(x) { this.x = x; } make() { return A(1); } } class B < A { get() { return super.x; } }`},
+		{"control flow", `
+outer: for (var i = 0; i < 3; i++) {
+  while (true) { if (i == 1) continue outer; else break; }
+}
+for (;;) break;
+if (true) print 1;`},
+		{"collections", `var m = {"a": [1, 2], "b": {}}; print m["a"][1];`},
+		{"exceptions", `try { throw "x"; } catch (e) { print e; } finally { print "done"; } try {} finally {}`},
+		{"modules", `import "m.lox" as m; from "n.lox" import a, b; export var x = 1; export fun f() {}`},
+		{"comments", "// a\nprint 1; /* b */\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want, err := Parse(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := MarshalProgram(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := UnmarshalProgram(data)
+			if err != nil {
+				t.Fatalf("UnmarshalProgram: %v\n%s", err, data)
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("got %d statements back instead of %d", len(got), len(want))
+			}
+			for i := range want {
+				if !EqualNodes(got[i], want[i]) {
+					t.Errorf("statement %d changed in the round trip:\n%s", i+1, data)
+				}
+			}
+			again, err := MarshalProgram(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(again, data) {
+				t.Errorf("encoding the decoded program gives\n%s\ninstead of\n%s", again, data)
+			}
+		})
+	}
+}
+
+func TestJSONTokenPosition(t *testing.T) {
+	stmts, err := Parse("\n  print x;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(stmts[0].(*PrintStmt).Expression().(*VariableExpr).VariableName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var position struct{ Line, Column int }
+	if err := json.Unmarshal(data, &position); err != nil {
+		t.Fatal(err)
+	}
+	if position.Line != 2 || position.Column != 9 {
+		t.Errorf("x is encoded at line %d, column %d instead of line 2, column 9: %s", position.Line, position.Column, data)
+	}
+}
+
+// jsonToken encodes a token of type with lexeme at line 1, column 1 as JSON
+func jsonToken(tokenType TokenType, lexeme string) string {
+	data, err := json.Marshal(Token{Type: tokenType, Lexeme: lexeme, Column: 1})
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+const (
+	oneJSON  = `{"type": "LiteralExpr", "value": 1}`
+	twoJSON  = `{"type": "LiteralExpr", "value": 2}`
+	noopJSON = `{"type": "ExpressionStmt", "expression": ` + oneJSON + `}`
+)
+
+func TestUnmarshalProgramErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		// a part of the error message
+		want string
+	}{
+		{"not JSON", `[{`, "unexpected end"},
+		{"not an array", `{}`, "cannot unmarshal"},
+		{"null statement", `[null]`, "element 0 is null"},
+		{"unknown node", `[{"type": "GotoStmt"}]`, "GotoStmt"},
+		{"missing child", `[{"type": "PrintStmt"}]`, "PrintStmt.expression is missing"},
+		{"null child", `[{"type": "PrintStmt", "expression": null}]`, "PrintStmt.expression is missing"},
+		{"missing nested child", `[{"type": "PrintStmt", "expression": {"type": "UnaryExpr", "operator": ` + jsonToken(Minus, "-") + `}}]`, "UnaryExpr.right is missing"},
+		{"null in a block", `[{"type": "BlockStmt", "statements": [` + noopJSON + `, null]}]`, "BlockStmt.statements: element 1 is null"},
+		{"if without a then branch", `[{"type": "IfStmt", "condition": ` + oneJSON + `}]`, "IfStmt.thenBranch is missing"},
+		{"expression as a statement", `[` + oneJSON + `]`, "LiteralExpr"},
+		{"statement as an expression", `[{"type": "PrintStmt", "expression": ` + noopJSON + `}]`, "ExpressionStmt"},
+		{"binary and", `[{"type": "ExpressionStmt", "expression": {"type": "BinaryExpr", "left": ` + oneJSON + `, "operator": ` + jsonToken(And, "and") + `, "right": ` + twoJSON + `}}]`, `unsupported operator "AND"`},
+		{"logical plus", `[{"type": "ExpressionStmt", "expression": {"type": "LogicalExpr", "left": ` + oneJSON + `, "operator": ` + jsonToken(Plus, "+") + `, "right": ` + twoJSON + `}}]`, `unsupported operator "PLUS"`},
+		{"unary star", `[{"type": "ExpressionStmt", "expression": {"type": "UnaryExpr", "operator": ` + jsonToken(Star, "*") + `, "right": ` + oneJSON + `}}]`, `unsupported operator "STAR"`},
+		{"list literal value", `[{"type": "ExpressionStmt", "expression": {"type": "LiteralExpr", "value": [1]}}]`, "unsupported value"},
+		{"object literal value", `[{"type": "ExpressionStmt", "expression": {"type": "LiteralExpr", "value": {}}}]`, "unsupported value"},
+		{"assignment to a literal", `[{"type": "ExpressionStmt", "expression": {"type": "IncrementExpr", "operator": ` + jsonToken(PlusPlus, "++") + `, "target": ` + oneJSON + `, "prefix": true}}]`, "can't assign to a LiteralExpr"},
+		{"keyword as a name", `[{"type": "VarStmt", "varName": ` + jsonToken(Identifier, "class") + `}]`, `"class" is not an identifier`},
+		{"empty name", `[{"type": "ExpressionStmt", "expression": {"type": "VariableExpr", "variableName": ` + jsonToken(Identifier, "") + `}}]`, `"" is not an identifier`},
+		{"this with another keyword", `[{"type": "ExpressionStmt", "expression": {"type": "ThisExpr", "keyword": ` + jsonToken(Identifier, "self") + `}}]`, `keyword must be "this"`},
+		{"map keys without values", `[{"type": "ExpressionStmt", "expression": {"type": "MapExpr", "brace": ` + jsonToken(LeftBrace, "{") + `, "keys": [` + oneJSON + `], "values": []}}]`, "1 keys but 0 values"},
+		{"break outside of a loop", `[{"type": "BreakStmt", "keyword": ` + jsonToken(Break, "break") + `}]`, "outside of a loop"},
+		{"break in a function in a loop", `[{"type": "WhileStmt", "condition": ` + oneJSON + `, "loopBody": {"type": "FunctionStmt", "name": ` + jsonToken(Identifier, "f") + `, "parameters": [], "body": [{"type": "BreakStmt", "keyword": ` + jsonToken(Break, "break") + `}]}}]`, "outside of a loop"},
+		{"continue to a missing label", `[{"type": "WhileStmt", "condition": ` + oneJSON + `, "loopBody": {"type": "ContinueStmt", "keyword": ` + jsonToken(Continue, "continue") + `, "label": ` + jsonToken(Identifier, "outer") + `}}]`, `no enclosing loop is labeled "outer"`},
+		{"try without catch or finally", `[{"type": "TryStmt", "keyword": ` + jsonToken(Try, "try") + `, "body": {"type": "BlockStmt", "statements": []}}]`, "neither catchBody nor finallyBody"},
+		{"token without a line", `[{"type": "VarStmt", "varName": {"type": "IDENTIFIER", "lexeme": "x", "column": 1}}]`, "VarStmt: token at line 0, column 1: the line and column must be at least 1"},
+		{"token without a column", `[{"type": "VarStmt", "varName": {"type": "IDENTIFIER", "lexeme": "x", "line": 1}}]`, "column 0"},
+		{"negative line", `[{"type": "ExpressionStmt", "expression": {"type": "VariableExpr", "variableName": {"type": "IDENTIFIER", "lexeme": "x", "line": -3, "column": 1}}}]`, "token at line -3"},
+		{"export of a statement", `[{"type": "ExportStmt", "keyword": ` + jsonToken(Export, "export") + `, "declaration": ` + noopJSON + `}]`, "can't export a ExpressionStmt"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmts, err := UnmarshalProgram([]byte(test.json))
+			if err == nil {
+				t.Fatalf("UnmarshalProgram(%s) = %v, want an error", test.json, stmts)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("UnmarshalProgram(%s) fails with %q, want %q in it", test.json, err, test.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalExprErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"null", `null`, "expression is null"},
+		{"statement", noopJSON, "ExpressionStmt"},
+		{"missing operand", `{"type": "BinaryExpr", "left": ` + oneJSON + `, "operator": ` + jsonToken(Plus, "+") + `}`, "BinaryExpr.right is missing"},
+		{"unsupported operator", `{"type": "BinaryExpr", "left": ` + oneJSON + `, "operator": ` + jsonToken(Or, "or") + `, "right": ` + twoJSON + `}`, `unsupported operator "OR"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expr, err := UnmarshalExpr([]byte(test.json))
+			if err == nil {
+				t.Fatalf("UnmarshalExpr(%s) = %v, want an error", test.json, expr)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("UnmarshalExpr(%s) fails with %q, want %q in it", test.json, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+package lox
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// AstPrettyPrinter prints the AST as S-expressions in the style of Crafting Interpreters: every node
+// is a parenthesized list of its operator or keyword and its children, e.g. (+ 1.0 (* 2.0 3.0)).
+// An optional child that is missing prints as ().
+type AstPrettyPrinter struct{}
+
+var _ ExprVisitor = (*AstPrettyPrinter)(nil)
+var _ StmtVisitor = (*AstPrettyPrinter)(nil)
+
+// Print writes each statement on a line of its own
+func (s *AstPrettyPrinter) Print(w io.Writer, stmts []Stmt) {
+	for _, stmt := range stmts {
+		result, _ := stmt.Accept(s)
+		fmt.Fprintln(w, result)
+	}
+}
+
+func (s *AstPrettyPrinter) PrintExpr(w io.Writer, e Expr) {
+	result, _ := e.Accept(s)
+	fmt.Fprintln(w, result)
+}
+
+func (s *AstPrettyPrinter) VisitBinaryExpr(e *BinaryExpr) (result interface{}, err error) {
+	return s.parenthesize(e.operator.Lexeme, e.left, e.right), nil
+}
+
+func (s *AstPrettyPrinter) VisitUnaryExpr(e *UnaryExpr) (result interface{}, err error) {
+	return s.parenthesize(e.operator.Lexeme, e.right), nil
+}
+
+func (s *AstPrettyPrinter) VisitGroupingExpr(e *GroupingExpr) (result interface{}, err error) {
+	return s.parenthesize("group", e.expr), nil
+}
+
+func (s *AstPrettyPrinter) VisitLiteralExpr(e *LiteralExpr) (result interface{}, err error) {
+	var valueAsString string
+	switch v := e.value.(type) {
+	case float64:
+		// return smallest number of digits necessary
+		valueAsString = strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(valueAsString, ".") {
+			valueAsString += ".0"
+		}
+	case nil:
+		valueAsString = "nil"
+	default:
+		// Fallback for other types
+		valueAsString = fmt.Sprintf("%v", v)
+	}
+
+	return valueAsString, nil
+}
+
+func (s *AstPrettyPrinter) VisitVariableExpr(e *VariableExpr) (result interface{}, err error) {
+	return e.variableName.Lexeme, nil
+}
+
+func (s *AstPrettyPrinter) VisitGetExpr(e *GetExpr) (result interface{}, err error) {
+	object, _ := e.object.Accept(s)
+	return fmt.Sprintf("(. %v %s)", object, e.name.Lexeme), nil
+}
+
+func (s *AstPrettyPrinter) VisitIndexExpr(e *IndexExpr) (result interface{}, err error) {
+	return s.parenthesize("[]", e.object, e.index), nil
+}
+
+func (s *AstPrettyPrinter) VisitCompoundExpr(e *CompoundExpr) (result interface{}, err error) {
+	return s.parenthesize(e.operator.Lexeme, e.target, e.value), nil
+}
+
+// VisitIncrementExpr prints the operator on the side of the target it was written on: (++ x) or (x ++)
+func (s *AstPrettyPrinter) VisitIncrementExpr(e *IncrementExpr) (result interface{}, err error) {
+	if e.prefix {
+		return s.parenthesize(e.operator.Lexeme, e.target), nil
+	}
+	target, _ := e.target.Accept(s)
+	return fmt.Sprintf("(%v %s)", target, e.operator.Lexeme), nil
+}
+
+func (s *AstPrettyPrinter) VisitConditionalExpr(e *ConditionalExpr) (result interface{}, err error) {
+	return s.parenthesize("?:", e.condition, e.thenBranch, e.elseBranch), nil
+}
+
+func (s *AstPrettyPrinter) VisitAssignExpr(e *AssignExpr) (result interface{}, err error) {
+	return s.parenthesizeParts("=", e.variableName, e.assignValue), nil
+}
+
+func (s *AstPrettyPrinter) VisitLogicalExpr(e *LogicalExpr) (result interface{}, err error) {
+	return s.parenthesize(e.operator.Lexeme, e.left, e.right), nil
+}
+
+func (s *AstPrettyPrinter) VisitCallExpr(e *CallExpr) (result interface{}, err error) {
+	return s.parenthesizeParts("call", e.callee, e.arguments), nil
+}
+
+func (s *AstPrettyPrinter) VisitSetExpr(e *SetExpr) (result interface{}, err error) {
+	return s.parenthesizeParts("=", e.object, e.name, e.value), nil
+}
+
+func (s *AstPrettyPrinter) VisitThisExpr(e *ThisExpr) (result interface{}, err error) {
+	return "this", nil
+}
+
+func (s *AstPrettyPrinter) VisitSuperExpr(e *SuperExpr) (result interface{}, err error) {
+	return s.parenthesizeParts("super", e.method), nil
+}
+
+// VisitLambdaExpr prints function expressions like declarations without a name; the body of an arrow
+// function is the return statement it is turned into
+func (s *AstPrettyPrinter) VisitLambdaExpr(e *LambdaExpr) (result interface{}, err error) {
+	return s.parenthesizeParts("fun", parameterList(e.parameters), e.body), nil
+}
+
+func (s *AstPrettyPrinter) VisitListExpr(e *ListExpr) (result interface{}, err error) {
+	return s.parenthesizeParts("list", e.elements), nil
+}
+
+// VisitMapExpr prints each entry as (: key value)
+func (s *AstPrettyPrinter) VisitMapExpr(e *MapExpr) (result interface{}, err error) {
+	entries := make([]string, len(e.keys))
+	for i := range e.keys {
+		entries[i] = s.parenthesize(":", e.keys[i], e.values[i])
+	}
+	return s.parenthesizeParts("map", strings.Join(entries, " ")), nil
+}
+
+func (s *AstPrettyPrinter) VisitIndexSetExpr(e *IndexSetExpr) (result interface{}, err error) {
+	return s.parenthesize("[]=", e.object, e.index, e.value), nil
+}
+
+func (s *AstPrettyPrinter) VisitSliceExpr(e *SliceExpr) (result interface{}, err error) {
+	return s.parenthesize("[:]", e.object, e.start, e.end), nil
+}
+
+func (s *AstPrettyPrinter) VisitInterpolationExpr(e *InterpolationExpr) (result interface{}, err error) {
+	return s.parenthesize("interpolate", e.parts...), nil
+}
+
+func (s *AstPrettyPrinter) VisitExpressionStmt(stmt *ExpressionStmt) (result interface{}, err error) {
+	return s.parenthesize(";", stmt.expression), nil
+}
+
+func (s *AstPrettyPrinter) VisitPrintStmt(stmt *PrintStmt) (result interface{}, err error) {
+	return s.parenthesize("print", stmt.expression), nil
+}
+
+// VisitVarStmt prints (var x = value), or (var x) without an initializer
+func (s *AstPrettyPrinter) VisitVarStmt(stmt *VarStmt) (result interface{}, err error) {
+	if stmt.initializerExpression == nil {
+		return s.parenthesizeParts("var", stmt.varName), nil
+	}
+	return s.parenthesizeParts("var", stmt.varName, "=", stmt.initializerExpression), nil
+}
+
+// VisitFunctionStmt prints (fun name(a b) body...)
+func (s *AstPrettyPrinter) VisitFunctionStmt(stmt *FunctionStmt) (result interface{}, err error) {
+	return s.parenthesizeParts("fun", stmt.name.Lexeme+parameterList(stmt.parameters), stmt.body), nil
+}
+
+func (s *AstPrettyPrinter) VisitReturnStmt(stmt *ReturnStmt) (result interface{}, err error) {
+	if stmt.value == nil {
+		return "(return)", nil
+	}
+	return s.parenthesize("return", stmt.value), nil
+}
+
+func (s *AstPrettyPrinter) VisitBlockStmt(stmt *BlockStmt) (result interface{}, err error) {
+	return s.parenthesizeParts("block", stmt.statements), nil
+}
+
+// VisitIfStmt prints (if condition then) or (if-else condition then else)
+func (s *AstPrettyPrinter) VisitIfStmt(stmt *IfStmt) (result interface{}, err error) {
+	if stmt.elseBranch == nil {
+		return s.parenthesizeParts("if", stmt.condition, stmt.thenBranch), nil
+	}
+	return s.parenthesizeParts("if-else", stmt.condition, stmt.thenBranch, stmt.elseBranch), nil
+}
+
+// VisitWhileStmt prints (while condition body), labeled loops as (label: while condition body)
+func (s *AstPrettyPrinter) VisitWhileStmt(stmt *WhileStmt) (result interface{}, err error) {
+	return s.parenthesizeParts(loopKeyword("while", stmt.label), stmt.condition, stmt.loopBody), nil
+}
+
+// VisitForStmt prints (for initializer condition increment body), see VisitWhileStmt
+func (s *AstPrettyPrinter) VisitForStmt(stmt *ForStmt) (result interface{}, err error) {
+	return s.parenthesizeParts(loopKeyword("for", stmt.label), stmt.init, stmt.condition, stmt.iteration, stmt.loopBody), nil
+}
+
+func (s *AstPrettyPrinter) VisitBreakStmt(stmt *BreakStmt) (result interface{}, err error) {
+	return s.parenthesizeParts("break", stmt.label), nil
+}
+
+func (s *AstPrettyPrinter) VisitContinueStmt(stmt *ContinueStmt) (result interface{}, err error) {
+	return s.parenthesizeParts("continue", stmt.label), nil
+}
+
+func (s *AstPrettyPrinter) VisitThrowStmt(stmt *ThrowStmt) (result interface{}, err error) {
+	return s.parenthesize("throw", stmt.value), nil
+}
+
+// VisitTryStmt prints (try body (catch name body) (finally body)) without the clauses that are missing
+func (s *AstPrettyPrinter) VisitTryStmt(stmt *TryStmt) (result interface{}, err error) {
+	var clauses []string
+	if stmt.catchBody != nil {
+		clauses = append(clauses, s.parenthesizeParts("catch", stmt.catchName, stmt.catchBody))
+	}
+	if stmt.finallyBody != nil {
+		clauses = append(clauses, s.parenthesizeParts("finally", stmt.finallyBody))
+	}
+	return s.parenthesizeParts("try", stmt.body, strings.Join(clauses, " ")), nil
+}
+
+// VisitImportStmt prints (import "path" as name) or (from "path" import a b)
+func (s *AstPrettyPrinter) VisitImportStmt(stmt *ImportStmt) (result interface{}, err error) {
+	if stmt.alias != nil {
+		return s.parenthesizeParts("import", stmt.path, "as", stmt.alias), nil
+	}
+	return s.parenthesizeParts("from", stmt.path, "import", stmt.names), nil
+}
+
+func (s *AstPrettyPrinter) VisitExportStmt(stmt *ExportStmt) (result interface{}, err error) {
+	return s.parenthesizeParts("export", stmt.declaration), nil
+}
+
+// VisitClassStmt prints (class Name < Superclass method...), methods like function declarations
+func (s *AstPrettyPrinter) VisitClassStmt(stmt *ClassStmt) (result interface{}, err error) {
+	parts := []interface{}{stmt.name}
+	if stmt.superclass != nil {
+		parts = append(parts, "<", stmt.superclass.variableName)
+	}
+	for _, method := range stmt.methods {
+		parts = append(parts, method)
+	}
+	return s.parenthesizeParts("class", parts...), nil
+}
+
+// parameterList prints parameters the way function declarations show them, e.g. (a b)
+func parameterList(params []Token) string {
+	names := make([]string, len(params))
+	for i, param := range params {
+		names[i] = param.Lexeme
+	}
+	return "(" + strings.Join(names, " ") + ")"
+}
+
+func loopKeyword(keyword string, label *Token) string {
+	if label == nil {
+		return keyword
+	}
+	return label.Lexeme + ": " + keyword
+}
+
+func (p *AstPrettyPrinter) parenthesize(name string, exprs ...Expr) string {
+	parts := make([]interface{}, len(exprs))
+	for i, expr := range exprs {
+		parts[i] = expr
+	}
+	return p.parenthesizeParts(name, parts...)
+}
+
+// parenthesizeParts is parenthesize for nodes with parts other than expressions: statements, tokens,
+// lists of them, and strings printed as they are. An empty string or list adds nothing, a missing
+// node or token prints as ().
+func (p *AstPrettyPrinter) parenthesizeParts(name string, parts ...interface{}) string {
+	var builder strings.Builder
+
+	builder.WriteString("(")
+	builder.WriteString(name)
+	for _, part := range parts {
+		var text string
+		switch part := part.(type) {
+		case Expr:
+			text = printed(part.Accept(p))
+		case Stmt:
+			text = printed(part.Accept(p))
+		case []Expr:
+			items := make([]string, len(part))
+			for i, expr := range part {
+				items[i] = printed(expr.Accept(p))
+			}
+			text = strings.Join(items, " ")
+		case []Stmt:
+			items := make([]string, len(part))
+			for i, stmt := range part {
+				items[i] = printed(stmt.Accept(p))
+			}
+			text = strings.Join(items, " ")
+		case Token:
+			text = part.Lexeme
+		case *Token:
+			text = "()"
+			if part != nil {
+				text = part.Lexeme
+			}
+		case []Token:
+			items := make([]string, len(part))
+			for i, tok := range part {
+				items[i] = tok.Lexeme
+			}
+			text = strings.Join(items, " ")
+		case string:
+			text = part
+		case nil:
+			text = "()"
+		}
+		if text != "" {
+			builder.WriteString(" ")
+			builder.WriteString(text)
+		}
+	}
+	builder.WriteString(")")
+
+	return builder.String()
+}
+
+// printed formats the result of visiting a node, EROR if visiting it failed
+func printed(result interface{}, err error) string {
+	if err != nil {
+		return "EROR"
+	}
+	return fmt.Sprintf("%v", result)
+}
@@ -0,0 +1,138 @@
+package lox
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrintTree writes stmts as an indented tree: one line per node giving its type and the lines of
+// source it was parsed from, followed by its fields, one per line, nested nodes indented below them.
+//
+//	VarStmt (line 1)
+//	  varName: x
+//	  initializerExpression: BinaryExpr (line 1)
+//	    left: LiteralExpr (line 1)
+//	      value: 1
+//	    operator: +
+//	    ...
+func PrintTree(w io.Writer, source string, stmts []Stmt) {
+	printer := newTreePrinter(w, source)
+	for _, stmt := range stmts {
+		printer.node(reflect.ValueOf(stmt), 0, "")
+	}
+}
+
+// PrintExprTree writes expr as an indented tree, see PrintTree
+func PrintExprTree(w io.Writer, source string, expr Expr) {
+	newTreePrinter(w, source).node(reflect.ValueOf(expr), 0, "")
+}
+
+var (
+	tokenType = reflect.TypeOf(Token{})
+	exprType  = reflect.TypeOf((*Expr)(nil)).Elem()
+	stmtType  = reflect.TypeOf((*Stmt)(nil)).Elem()
+)
+
+// treePrinter walks the fields of AST nodes by reflection, so it needs no changes when productions
+// are added to grammar.json
+type treePrinter struct {
+	w io.Writer
+	// offsets of the newlines in the source, to find the line of a span
+	newlines []int
+}
+
+func newTreePrinter(w io.Writer, source string) *treePrinter {
+	printer := &treePrinter{w: w}
+	for i := range source {
+		if source[i] == '\n' {
+			printer.newlines = append(printer.newlines, i)
+		}
+	}
+	return printer
+}
+
+// node writes the node v points to, its first line starting with label
+func (t *treePrinter) node(v reflect.Value, depth int, label string) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	node := v.Elem()
+	span := node.FieldByName("span")
+	fmt.Fprintf(t.w, "%s%s%s (%s)\n", strings.Repeat("  ", depth), label, node.Type().Name(), t.lines(int(span.FieldByName("Start").Int()), int(span.FieldByName("End").Int())))
+
+	for i := 0; i < node.NumField(); i++ {
+		if name := node.Type().Field(i).Name; name != "span" {
+			t.field(name, node.Field(i), depth+1)
+		}
+	}
+}
+
+// field writes a field of a node; missing nodes and empty lists are left out
+func (t *treePrinter) field(name string, v reflect.Value, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch {
+	case v.Type() == tokenType:
+		fmt.Fprintf(t.w, "%s%s: %s\n", indent, name, v.FieldByName("Lexeme").String())
+	case v.Type() == reflect.PointerTo(tokenType):
+		if !v.IsNil() {
+			fmt.Fprintf(t.w, "%s%s: %s\n", indent, name, v.Elem().FieldByName("Lexeme").String())
+		}
+	case v.Kind() == reflect.Bool:
+		fmt.Fprintf(t.w, "%s%s: %t\n", indent, name, v.Bool())
+	case v.Type() == exprType || v.Type() == stmtType || v.Kind() == reflect.Pointer:
+		if !v.IsNil() {
+			t.node(v, depth, name+": ")
+		}
+	case v.Kind() == reflect.Interface:
+		// the value of a literal
+		fmt.Fprintf(t.w, "%s%s: %s\n", indent, name, literalText(v))
+	case v.Kind() == reflect.Slice && v.Type().Elem() == tokenType:
+		if v.Len() > 0 {
+			lexemes := make([]string, v.Len())
+			for i := range lexemes {
+				lexemes[i] = v.Index(i).FieldByName("Lexeme").String()
+			}
+			fmt.Fprintf(t.w, "%s%s: %s\n", indent, name, strings.Join(lexemes, ", "))
+		}
+	case v.Kind() == reflect.Slice:
+		if v.Len() > 0 {
+			fmt.Fprintf(t.w, "%s%s:\n", indent, name)
+			for i := 0; i < v.Len(); i++ {
+				t.node(v.Index(i), depth+1, "- ")
+			}
+		}
+	}
+}
+
+// literalText formats the value of a literal the way it is written in Lox
+func literalText(v reflect.Value) string {
+	if v.IsNil() {
+		return "nil"
+	}
+	switch v = v.Elem(); v.Kind() {
+	case reflect.String:
+		return quoteString(v.String())
+	case reflect.Float64:
+		return loxStringify(v.Float())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+	return v.Type().String()
+}
+
+// lines describes the 1-based lines of the source from start up to end
+func (t *treePrinter) lines(start, end int) string {
+	first := sort.SearchInts(t.newlines, start) + 1
+	last := first
+	if end > start {
+		last = sort.SearchInts(t.newlines, end-1) + 1
+	}
+	if first == last {
+		return fmt.Sprintf("line %d", first)
+	}
+	return fmt.Sprintf("lines %d-%d", first, last)
+}
@@ -0,0 +1,169 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// run executes source on backend and returns what it printed to stdout and stderr, and the exit
+// status the command line reports for the error it returned
+func run(t *testing.T, backend Backend, source string) (stdout, stderr string, status int) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	interpreter := New(WithBackend(backend), WithStdout(&out), WithStderr(&errOut), WithErrorFormat(HumanErrors), WithSourceName("test.lox"))
+	_, err := interpreter.Eval(context.Background(), source)
+
+	var scanErr *ScanError
+	var parseErr *ParseError
+	var compileErr *CompileError
+	switch {
+	case err == nil:
+	case errors.As(err, &scanErr), errors.As(err, &parseErr), errors.As(err, &compileErr):
+		status = 65
+	default:
+		status = 70
+	}
+	return out.String(), errOut.String(), status
+}
+
+// manyLocals declares n locals in a function and reads the last one, from the function and from a
+// closure capturing all of them
+func manyLocals(n int) string {
+	var source strings.Builder
+	source.WriteString("fun f() {\n")
+	sum := make([]string, n)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&source, "  var v%d = %d;\n", i, i)
+		sum[i] = fmt.Sprintf("v%d", i)
+	}
+	fmt.Fprintf(&source, "  fun g() { return %s; }\n  v%d = v%d + 1;\n  print g();\n  print v%d;\n}\nf();\n", strings.Join(sum, " + "), n-1, n-1, n-1)
+	return source.String()
+}
+
+// longBody makes a loop body and a branch too long for 16-bit jump offsets
+func longBody(statements int) string {
+	body := strings.Repeat("    x = x + 1;\n", statements)
+	return "var x = 0;\nfor (var i = 0; i < 3; i++) {\n  if (i != 1) {\n" + body + "  } else {\n    continue;\n  }\n}\nprint x;\n"
+}
+
+func TestBackendParity(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"arithmetic", `print 1 + 2 * 3 - 4 / 8; print 7 % 3; print 7 ~/ 2; print 2 ** 10; print -(3);`},
+		{"strings", `var s = "lo"; print "hel" + s; print s == "lo"; print "${s}x${1 + 1}"; print s.len();`},
+		{"truthiness", `print !nil; print !0; print nil or "default"; print false and 1; print 1 < 2 ? "yes" : "no";`},
+		{"closures", `
+fun counter() {
+  var count = 0;
+  return fun () { count++; return count; };
+}
+var c = counter();
+c();
+print c();
+var adders = [];
+for (var i = 0; i < 3; i++) adders.push((x) => x + i);
+print adders[0](10);`},
+		{"classes", `
+class Animal {
+  init(name) { this.name = name; }
+  speak() { return this.name + " makes a sound"; }
+}
+class Dog < Animal {
+  speak() { return super.speak() + ": woof"; }
+}
+var d = Dog("Rex");
+print d.speak();
+print d;
+print Dog;`},
+		{"collections", `
+var l = [3, 1, 2];
+l.sort();
+print l;
+print l[1:];
+l[0] += 10;
+print l;
+var m = {"a": 1};
+m["b"] = 2;
+print m.keys();
+print m.has("c");`},
+		{"loops", `
+outer: for (var i = 0; i < 3; i++) {
+  var j = 0;
+  while (true) {
+    j++;
+    if (j > 2) continue outer;
+    if (i == 2) break outer;
+    print i * 10 + j;
+  }
+}`},
+		{"exceptions", `
+fun fail() { throw "boom"; }
+try {
+  fail();
+} catch (e) {
+  print "caught " + e;
+} finally {
+  print "finally";
+}
+try { print 1 - "x"; } catch (e) { print e; }`},
+		{"runtime error", `
+fun inner() { return 1 + nil; }
+fun outer() { return inner(); }
+print "before";
+outer();
+print "after";`},
+		{"recursive local lambda", `
+fun outer() {
+  var fact = fun (n) { if (n <= 1) return 1; return n * fact(n - 1); };
+  return fact(5);
+}
+print outer();
+{
+  var fib = (n) => n < 2 ? n : fib(n - 1) + fib(n - 2);
+  print fib(10);
+}`},
+		{"closure in a shadowing initializer", `
+var a = 1;
+{
+  var a = (() => a)();
+  print a;
+}
+fun f() {
+  var b = "outer";
+  {
+    var b = [() => b];
+    print b[0]() == b;
+  }
+}
+f();`},
+		{"uncaught throw", `throw {"code": 1};`},
+		{"undefined variable", `print missing;`},
+		{"wrong arity", `fun f(a) {} f(1, 2);`},
+		{"syntax error", `print (1 + ;`},
+		{"resolver error", `return 1;`},
+		{"lexical error", `print "unterminated;`},
+		{"many locals", manyLocals(300)},
+		{"long jumps", longBody(25000)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wantOut, wantErr, wantStatus := run(t, TreeWalker, test.source)
+			gotOut, gotErr, gotStatus := run(t, Bytecode, test.source)
+			if gotOut != wantOut {
+				t.Errorf("stdout of the VM:\n%s\ntree-walker:\n%s", gotOut, wantOut)
+			}
+			if gotErr != wantErr {
+				t.Errorf("stderr of the VM:\n%s\ntree-walker:\n%s", gotErr, wantErr)
+			}
+			if gotStatus != wantStatus {
+				t.Errorf("exit status of the VM is %d, of the tree-walker %d", gotStatus, wantStatus)
+			}
+		})
+	}
+}
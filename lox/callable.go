@@ -0,0 +1,192 @@
+package lox
+
+import (
+	"fmt"
+	"time"
+)
+
+type LoxCallable interface {
+	// Arity is the number of arguments Call expects, or -1 if it checks the count itself
+	Arity() int
+	Call(itp *AstInterpreter, arguments []interface{}) (interface{}, error)
+}
+
+type ClockFunc struct{}
+
+func (c ClockFunc) Arity() int {
+	return 0
+}
+
+func (c ClockFunc) Call(itp *AstInterpreter, arguments []interface{}) (interface{}, error) {
+	return float64(time.Now().Unix()), nil
+}
+
+func (c ClockFunc) String() string {
+	return "<native fn>"
+}
+
+// anonymousFunction names the functions created by function expressions
+const anonymousFunction = "anonymous"
+
+// LoxFunction is a function declaration, method or function expression together with the
+// environment it closes over
+type LoxFunction struct {
+	name          string
+	parameters    []Token
+	body          []Stmt
+	closure       *Environment
+	isInitializer bool
+	// module the function was declared in, whose globals it reads
+	module *LoxModule
+}
+
+// bind returns a copy of the method whose closure defines "this" as the given instance
+func (lf LoxFunction) bind(instance *LoxInstance) *LoxFunction {
+	env := &Environment{
+		Enclosing: lf.closure,
+		Values:    make(map[string]interface{}),
+	}
+	env.Define("this", instance)
+	return &LoxFunction{
+		name:          lf.name,
+		parameters:    lf.parameters,
+		body:          lf.body,
+		closure:       env,
+		isInitializer: lf.isInitializer,
+		module:        lf.module,
+	}
+}
+
+func (lf LoxFunction) Arity() int {
+	return len(lf.parameters)
+}
+
+func (lf LoxFunction) Call(itp *AstInterpreter, arguments []interface{}) (interface{}, error) {
+	previousEnv := itp.env
+	itp.env = &Environment{
+		Enclosing: lf.closure, // use the environment (hierarchy) surrounding the function declaration
+		Values:    make(map[string]interface{}),
+	}
+	defer func() { itp.env = previousEnv }()
+
+	for i := 0; i < len(arguments); i++ {
+		itp.env.Define(lf.parameters[i].Lexeme, arguments[i])
+	}
+
+	enclosingFunction, enclosingModule := itp.function, itp.module
+	itp.function, itp.module, itp.Globals = lf.name, lf.module, lf.module.globals
+	defer func() {
+		itp.function, itp.module, itp.Globals = enclosingFunction, enclosingModule, enclosingModule.globals
+	}()
+
+	for _, bodyStmt := range lf.body {
+		_, err := bodyStmt.Accept(itp)
+		switch e := err.(type) {
+		case *ReturnUnwindCallstack:
+			if lf.isInitializer {
+				// an initializer always returns the instance, even on an early bare "return;"
+				return lf.closure.Values["this"], nil
+			}
+			return e.Value, nil
+		case nil:
+			// do nothing; proceed with next statement
+		default:
+			if runtimeErr, ok := err.(*RuntimeError); ok {
+				if len(runtimeErr.Trace) == 0 {
+					runtimeErr.module = lf.module
+				}
+				runtimeErr.enterFrame(lf.name)
+			}
+			return nil, err
+		}
+	}
+
+	if lf.isInitializer {
+		return lf.closure.Values["this"], nil
+	}
+	return nil, nil
+}
+
+func (lf LoxFunction) String() string {
+	return fmt.Sprintf("<fn %s>", lf.name)
+}
+
+type LoxClass struct {
+	name       string
+	superclass *LoxClass
+	methods    map[string]*LoxFunction
+}
+
+func (lc *LoxClass) findMethod(name string) *LoxFunction {
+	if method, ok := lc.methods[name]; ok {
+		return method
+	}
+
+	if lc.superclass != nil {
+		return lc.superclass.findMethod(name)
+	}
+
+	return nil
+}
+
+func (lc *LoxClass) Arity() int {
+	if initializer := lc.findMethod("init"); initializer != nil {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+// Call constructs a new instance and runs the initializer on it, if the class has one
+func (lc *LoxClass) Call(itp *AstInterpreter, arguments []interface{}) (interface{}, error) {
+	instance := &LoxInstance{
+		class:  lc,
+		fields: make(map[string]interface{}),
+	}
+
+	if initializer := lc.findMethod("init"); initializer != nil {
+		_, err := initializer.bind(instance).Call(itp, arguments)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
+}
+
+func (lc *LoxClass) String() string {
+	return lc.name
+}
+
+type LoxInstance struct {
+	class  *LoxClass
+	fields map[string]interface{}
+}
+
+// Get looks up a field first, so fields shadow methods with the same name
+func (li *LoxInstance) Get(name Token) (interface{}, error) {
+	if value, ok := li.fields[name.Lexeme]; ok {
+		return value, nil
+	}
+
+	if method := li.class.findMethod(name.Lexeme); method != nil {
+		return method.bind(li), nil
+	}
+
+	return nil, newRuntimeError(name, "Undefined property '%s'.", name.Lexeme)
+}
+
+func (li *LoxInstance) Set(name Token, value interface{}) {
+	li.fields[name.Lexeme] = value
+}
+
+func (li *LoxInstance) String() string {
+	return li.class.name + " instance"
+}
+
+type ReturnUnwindCallstack struct {
+	Value interface{}
+}
+
+func (r *ReturnUnwindCallstack) Error() string {
+	return "return statement - everything OK, no errors"
+}
@@ -0,0 +1,272 @@
+package lox
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+type OpCode byte
+
+// operand sizes are noted next to each instruction; constant and global name indices are 16 bit,
+// local slots, upvalue indices and argument counts fit in one byte. OpWide in front of an
+// instruction supplies 16 more significant bits of its first operand, so locals and upvalues
+// beyond the 256th and constants beyond the 65536th stay addressable. Jump offsets are 32 bit.
+const (
+	OpConstant     OpCode = iota // u16 constant index
+	OpNil                        //
+	OpTrue                       //
+	OpFalse                      //
+	OpPop                        //
+	OpDup                        // u8 depth of the value to copy, 0 for the top
+	OpRotate                     // u8 count of values the top value moves below
+	OpGetLocal                   // u8 slot
+	OpSetLocal                   // u8 slot
+	OpGetGlobal                  // u16 name constant
+	OpDefineGlobal               // u16 name constant
+	OpSetGlobal                  // u16 name constant
+	OpGetUpvalue                 // u8 upvalue index
+	OpSetUpvalue                 // u8 upvalue index
+	OpGetProperty                // u16 name constant
+	OpSetProperty                // u16 name constant
+	OpCheckFields                // (errors unless the value on top of the stack is an instance)
+	OpGetSuper                   // u16 name constant
+	OpEqual                      //
+	OpNotEqual                   //
+	OpGreater                    //
+	OpGreaterEqual               //
+	OpLess                       //
+	OpLessEqual                  //
+	OpAdd                        //
+	OpSubtract                   //
+	OpMultiply                   //
+	OpDivide                     //
+	OpModulo                     //
+	OpFloorDivide                //
+	OpPower                      //
+	OpNot                        //
+	OpNegate                     //
+	OpIncrement                  //
+	OpDecrement                  //
+	OpPrint                      //
+	OpJump                       // u32 forward offset
+	OpJumpIfFalse                // u32 forward offset (condition is left on the stack)
+	OpLoop                       // u32 backward offset
+	OpCall                       // u8 argument count
+	OpClosure                    // u16 function constant, then (u8 isLocal, u24 index) per upvalue
+	OpCloseUpvalue               //
+	OpReturn                     //
+	OpClass                      // u16 name constant
+	OpInherit                    //
+	OpMethod                     // u16 name constant
+	OpBuildList                  // u16 element count
+	OpBuildMap                   // u16 entry count (keys and values alternate on the stack)
+	OpGetIndex                   //
+	OpSetIndex                   //
+	OpSlice                      // (bounds are nil when omitted)
+	OpImport                     // u16 path constant
+	OpGetExport                  // u16 name constant (the module stays on the stack)
+	OpTry                        // u32 forward offset to the handler
+	OpEndTry                     //
+	OpThrow                      //
+	OpCatch                      // (turns the error pushed by the handler into the value of the error variable)
+	OpRethrow                    //
+	OpBuildString                // u16 part count
+	OpWide                       // u16 high bits of the next instruction's first operand
+)
+
+var opCodeNames = [...]string{
+	OpConstant:     "OP_CONSTANT",
+	OpNil:          "OP_NIL",
+	OpTrue:         "OP_TRUE",
+	OpFalse:        "OP_FALSE",
+	OpPop:          "OP_POP",
+	OpDup:          "OP_DUP",
+	OpRotate:       "OP_ROTATE",
+	OpGetLocal:     "OP_GET_LOCAL",
+	OpSetLocal:     "OP_SET_LOCAL",
+	OpGetGlobal:    "OP_GET_GLOBAL",
+	OpDefineGlobal: "OP_DEFINE_GLOBAL",
+	OpSetGlobal:    "OP_SET_GLOBAL",
+	OpGetUpvalue:   "OP_GET_UPVALUE",
+	OpSetUpvalue:   "OP_SET_UPVALUE",
+	OpGetProperty:  "OP_GET_PROPERTY",
+	OpSetProperty:  "OP_SET_PROPERTY",
+	OpCheckFields:  "OP_CHECK_FIELDS",
+	OpGetSuper:     "OP_GET_SUPER",
+	OpEqual:        "OP_EQUAL",
+	OpNotEqual:     "OP_NOT_EQUAL",
+	OpGreater:      "OP_GREATER",
+	OpGreaterEqual: "OP_GREATER_EQUAL",
+	OpLess:         "OP_LESS",
+	OpLessEqual:    "OP_LESS_EQUAL",
+	OpAdd:          "OP_ADD",
+	OpSubtract:     "OP_SUBTRACT",
+	OpMultiply:     "OP_MULTIPLY",
+	OpDivide:       "OP_DIVIDE",
+	OpModulo:       "OP_MODULO",
+	OpFloorDivide:  "OP_FLOOR_DIVIDE",
+	OpPower:        "OP_POWER",
+	OpNot:          "OP_NOT",
+	OpNegate:       "OP_NEGATE",
+	OpIncrement:    "OP_INCREMENT",
+	OpDecrement:    "OP_DECREMENT",
+	OpPrint:        "OP_PRINT",
+	OpJump:         "OP_JUMP",
+	OpJumpIfFalse:  "OP_JUMP_IF_FALSE",
+	OpLoop:         "OP_LOOP",
+	OpCall:         "OP_CALL",
+	OpClosure:      "OP_CLOSURE",
+	OpCloseUpvalue: "OP_CLOSE_UPVALUE",
+	OpReturn:       "OP_RETURN",
+	OpClass:        "OP_CLASS",
+	OpInherit:      "OP_INHERIT",
+	OpMethod:       "OP_METHOD",
+	OpBuildList:    "OP_BUILD_LIST",
+	OpBuildMap:     "OP_BUILD_MAP",
+	OpGetIndex:     "OP_GET_INDEX",
+	OpSetIndex:     "OP_SET_INDEX",
+	OpSlice:        "OP_SLICE",
+	OpImport:       "OP_IMPORT",
+	OpGetExport:    "OP_GET_EXPORT",
+	OpTry:          "OP_TRY",
+	OpEndTry:       "OP_END_TRY",
+	OpThrow:        "OP_THROW",
+	OpCatch:        "OP_CATCH",
+	OpRethrow:      "OP_RETHROW",
+	OpBuildString:  "OP_BUILD_STRING",
+	OpWide:         "OP_WIDE",
+}
+
+func (op OpCode) String() string {
+	if int(op) < len(opCodeNames) {
+		return opCodeNames[op]
+	}
+	return fmt.Sprintf("OP_UNKNOWN(%d)", byte(op))
+}
+
+// positionRun attributes every byte from offset up to the next run to the source token it was
+// compiled from (run-length encoded position table)
+type positionRun struct {
+	offset int
+	token  Token
+}
+
+// Chunk is a compiled sequence of bytecode together with its constants table and position table (14. Chunks of Bytecode)
+type Chunk struct {
+	Code      []byte
+	Constants []interface{}
+	positions []positionRun
+}
+
+// Write appends a byte compiled from token, which locates runtime errors raised by the instruction
+func (c *Chunk) Write(b byte, token Token) {
+	if n := len(c.positions); n == 0 || c.positions[n-1].token.Span != token.Span || c.positions[n-1].token.Line != token.Line {
+		c.positions = append(c.positions, positionRun{offset: len(c.Code), token: token})
+	}
+	c.Code = append(c.Code, b)
+}
+
+// AddConstant appends value to the constants table and returns its index, reusing an existing
+// slot for identical strings and numbers
+func (c *Chunk) AddConstant(value interface{}) int {
+	switch value.(type) {
+	case string, float64:
+		for i, constant := range c.Constants {
+			if constant == value {
+				return i
+			}
+		}
+	}
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
+
+// Line returns the (0-based) source line of the instruction at offset
+func (c *Chunk) Line(offset int) int {
+	return c.tokenAt(offset).Line
+}
+
+// tokenAt returns the token the instruction at offset was compiled from
+func (c *Chunk) tokenAt(offset int) Token {
+	i := sort.Search(len(c.positions), func(i int) bool { return c.positions[i].offset > offset })
+	if i == 0 {
+		return Token{}
+	}
+	return c.positions[i-1].token
+}
+
+// Disassemble writes a human-readable listing of the chunk, used when debugging the compiler
+func (c *Chunk) Disassemble(w io.Writer, name string) {
+	fmt.Fprintf(w, "== %s ==\n", name)
+	for offset := 0; offset < len(c.Code); {
+		offset = c.disassembleInstruction(w, offset)
+	}
+}
+
+func (c *Chunk) disassembleInstruction(w io.Writer, offset int) int {
+	fmt.Fprintf(w, "%04d %4d ", offset, c.Line(offset)+1)
+
+	op := OpCode(c.Code[offset])
+	name := op.String()
+	// the high bits OpWide adds to the operand
+	wide := 0
+	if op == OpWide {
+		wide = c.readShort(offset + 1)
+		offset += 3
+		op = OpCode(c.Code[offset])
+		name = OpWide.String() + " " + op.String()
+	}
+
+	switch op {
+	case OpConstant, OpGetGlobal, OpDefineGlobal, OpSetGlobal, OpGetProperty, OpSetProperty,
+		OpGetSuper, OpClass, OpMethod, OpImport, OpGetExport:
+		index := wide<<16 | c.readShort(offset+1)
+		fmt.Fprintf(w, "%-18s %4d '%s'\n", name, index, loxStringify(c.Constants[index]))
+		return offset + 3
+	case OpGetLocal, OpSetLocal, OpGetUpvalue, OpSetUpvalue, OpCall, OpDup, OpRotate:
+		fmt.Fprintf(w, "%-18s %4d\n", name, wide<<8|int(c.Code[offset+1]))
+		return offset + 2
+	case OpBuildList, OpBuildMap, OpBuildString:
+		fmt.Fprintf(w, "%-18s %4d\n", name, wide<<16|c.readShort(offset+1))
+		return offset + 3
+	case OpJump, OpJumpIfFalse, OpTry:
+		jump := c.readJump(offset + 1)
+		fmt.Fprintf(w, "%-18s %4d -> %d\n", name, offset, offset+1+jumpSize+jump)
+		return offset + 1 + jumpSize
+	case OpLoop:
+		jump := c.readJump(offset + 1)
+		fmt.Fprintf(w, "%-18s %4d -> %d\n", name, offset, offset+1+jumpSize-jump)
+		return offset + 1 + jumpSize
+	case OpClosure:
+		index := wide<<16 | c.readShort(offset+1)
+		function := c.Constants[index].(*vmFunction)
+		fmt.Fprintf(w, "%-18s %4d %s\n", name, index, function)
+		offset += 3
+		for i := 0; i < function.upvalueCount; i++ {
+			kind := "upvalue"
+			if c.Code[offset] == 1 {
+				kind = "local"
+			}
+			index := int(c.Code[offset+1])<<16 | c.readShort(offset+2)
+			fmt.Fprintf(w, "%04d      |                     %s %d\n", offset, kind, index)
+			offset += 4
+		}
+		return offset
+	default:
+		fmt.Fprintf(w, "%s\n", name)
+		return offset + 1
+	}
+}
+
+func (c *Chunk) readShort(offset int) int {
+	return int(c.Code[offset])<<8 | int(c.Code[offset+1])
+}
+
+// jumpSize is the size of the offset of a jump instruction
+const jumpSize = 4
+
+func (c *Chunk) readJump(offset int) int {
+	return int(binary.BigEndian.Uint32(c.Code[offset:]))
+}
@@ -0,0 +1,1164 @@
+package lox
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// the most locals and upvalues a function can have: OpWide widens their one-byte operands to 24 bits
+const maxLocals = 1 << 24
+const maxUpvalues = 1 << 24
+
+type local struct {
+	name string
+	// scope depth of the declaring block, -1 while the variable's initializer is being compiled
+	depth      int
+	isCaptured bool
+}
+
+type upvalueRef struct {
+	index   int
+	isLocal bool
+}
+
+// funcCompiler holds the state of the function currently being compiled; nested function
+// declarations push a new one linked through enclosing (24.2 Compiling to Function Objects)
+type funcCompiler struct {
+	enclosing  *funcCompiler
+	function   *vmFunction
+	kind       functionType
+	locals     []local
+	upvalues   []upvalueRef
+	scopeDepth int
+	// loops enclosing the statement being compiled, innermost last
+	loops []*loopCompiler
+	// try statements enclosing the statement being compiled, innermost last
+	tries []*tryCompiler
+}
+
+// loopCompiler collects the jumps emitted for the break and continue statements of a loop
+type loopCompiler struct {
+	label string
+	// scope depth outside the loop body; break and continue discard the locals declared deeper
+	scopeDepth int
+	// offset continue jumps back to, or -1 when the target comes after the body (a for loop's iteration clause)
+	continueTarget int
+	continueJumps  []int
+	breakJumps     []int
+}
+
+// tryCompiler tracks what a return, break or continue has to undo when it jumps out of a try statement
+type tryCompiler struct {
+	// finally block to run on the way out, nil if there is none
+	finally Stmt
+	// whether a handler of the statement is installed: in the try block, and in the catch block when
+	// there is a finally block to run should it fail
+	handler bool
+	// scope depth outside the try statement
+	scopeDepth int
+	// number of loops enclosing the try statement
+	loopDepth int
+}
+
+type classCompiler struct {
+	enclosing     *classCompiler
+	hasSuperclass bool
+}
+
+// Compiler walks the AST produced by the Parser and emits bytecode for the VM backend.
+// It expects the program to have passed the Resolver, which reports the static errors.
+type Compiler struct {
+	current      *funcCompiler
+	currentClass *classCompiler
+	// token whose position is attributed to the bytes emitted next
+	token Token
+}
+
+// CompileError is reported for programs exceeding the limits of the bytecode format
+type CompileError struct {
+	// Token is the construct that was being compiled
+	Token   Token
+	Message string
+}
+
+func (e *CompileError) Error() string {
+	return fmt.Sprintf("[line %d] Error: %s", e.Token.Line+1, e.Message)
+}
+
+func NewCompiler() *Compiler {
+	c := &Compiler{}
+	c.beginFunction(plainFunction, "")
+	c.current.kind = noFunction
+	return c
+}
+
+// Compile turns a whole program into the top-level script function. The script returns the value
+// of its last statement when that is an expression statement.
+func (c *Compiler) Compile(stmts []Stmt) (function *vmFunction, err error) {
+	defer func() {
+		// limit violations deep inside the tree unwind straight back here
+		if r := recover(); r != nil {
+			compileErr, ok := r.(*CompileError)
+			if !ok {
+				panic(r)
+			}
+			function, err = nil, compileErr
+		}
+	}()
+
+	for i, stmt := range stmts {
+		if exprStmt, ok := stmt.(*ExpressionStmt); ok && i == len(stmts)-1 {
+			exprStmt.expression.Accept(c)
+			c.emitOp(OpReturn)
+			break
+		}
+		stmt.Accept(c)
+	}
+	return c.endFunction(), nil
+}
+
+// CompileExpr turns a single expression into a script function returning its value
+func (c *Compiler) CompileExpr(expr Expr) (function *vmFunction, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			compileErr, ok := r.(*CompileError)
+			if !ok {
+				panic(r)
+			}
+			function, err = nil, compileErr
+		}
+	}()
+
+	expr.Accept(c)
+	c.emitOp(OpReturn)
+	return c.current.function, nil
+}
+
+func (c *Compiler) error(msg string) {
+	panic(&CompileError{Token: c.token, Message: msg})
+}
+
+func (c *Compiler) chunk() *Chunk {
+	return &c.current.function.chunk
+}
+
+func (c *Compiler) emitByte(b byte) {
+	c.chunk().Write(b, c.token)
+}
+
+func (c *Compiler) emitOp(op OpCode) {
+	c.emitByte(byte(op))
+}
+
+// emitWide writes the bits of an operand that don't fit its instruction in front of it
+func (c *Compiler) emitWide(high int) {
+	c.emitOp(OpWide)
+	c.emitByte(byte(high >> 8))
+	c.emitByte(byte(high))
+}
+
+// emitOpByte writes an instruction with a one-byte operand, widened by OpWide past 255
+func (c *Compiler) emitOpByte(op OpCode, operand int) {
+	if operand > math.MaxUint8 {
+		c.emitWide(operand >> 8)
+	}
+	c.emitOp(op)
+	c.emitByte(byte(operand))
+}
+
+// emitOpShort writes an instruction with a two-byte operand, widened by OpWide past 65535
+func (c *Compiler) emitOpShort(op OpCode, operand int) {
+	if operand > math.MaxUint16 {
+		c.emitWide(operand >> 16)
+	}
+	c.emitOp(op)
+	c.emitByte(byte(operand >> 8))
+	c.emitByte(byte(operand))
+}
+
+func (c *Compiler) makeConstant(value interface{}) int {
+	index := c.chunk().AddConstant(value)
+	if uint64(index) > math.MaxUint32 {
+		c.error("Too many constants in one chunk.")
+	}
+	return index
+}
+
+func (c *Compiler) emitConstant(value interface{}) {
+	c.emitOpShort(OpConstant, c.makeConstant(value))
+}
+
+// emitJump writes a jump with a placeholder offset and returns the position to patch
+func (c *Compiler) emitJump(op OpCode) int {
+	c.emitOp(op)
+	for range jumpSize {
+		c.emitByte(0xff)
+	}
+	return len(c.chunk().Code) - jumpSize
+}
+
+func (c *Compiler) patchJump(offset int) {
+	jump := len(c.chunk().Code) - offset - jumpSize
+	if uint64(jump) > math.MaxUint32 {
+		c.error("Too much code to jump over.")
+	}
+	binary.BigEndian.PutUint32(c.chunk().Code[offset:], uint32(jump))
+}
+
+func (c *Compiler) emitLoop(loopStart int) {
+	offset := len(c.chunk().Code) - loopStart + 1 + jumpSize
+	if uint64(offset) > math.MaxUint32 {
+		c.error("Loop body too large.")
+	}
+	c.emitOp(OpLoop)
+	for _, b := range binary.BigEndian.AppendUint32(nil, uint32(offset)) {
+		c.emitByte(b)
+	}
+}
+
+func (c *Compiler) emitReturn() {
+	if c.current.kind == initializerFunction {
+		// initializers implicitly return the instance in slot zero
+		c.emitOpByte(OpGetLocal, 0)
+	} else {
+		c.emitOp(OpNil)
+	}
+	c.emitOp(OpReturn)
+}
+
+func (c *Compiler) beginFunction(kind functionType, name string) {
+	fc := &funcCompiler{
+		enclosing: c.current,
+		function:  &vmFunction{name: name},
+		kind:      kind,
+	}
+
+	// slot zero holds the callee, or the receiver for methods so "this" resolves to it
+	slotZero := ""
+	if kind == methodFunction || kind == initializerFunction {
+		slotZero = "this"
+	}
+	fc.locals = append(fc.locals, local{name: slotZero, depth: 0})
+	c.current = fc
+}
+
+func (c *Compiler) endFunction() *vmFunction {
+	c.emitReturn()
+	function := c.current.function
+	c.current = c.current.enclosing
+	return function
+}
+
+func (c *Compiler) beginScope() {
+	c.current.scopeDepth++
+}
+
+func (c *Compiler) endScope() {
+	c.current.scopeDepth--
+
+	locals := c.current.locals
+	for len(locals) > 0 && locals[len(locals)-1].depth > c.current.scopeDepth {
+		if locals[len(locals)-1].isCaptured {
+			c.emitOp(OpCloseUpvalue)
+		} else {
+			c.emitOp(OpPop)
+		}
+		locals = locals[:len(locals)-1]
+	}
+	c.current.locals = locals
+}
+
+func (c *Compiler) addLocal(name string) {
+	if len(c.current.locals) == maxLocals {
+		c.error("Too many local variables in function.")
+	}
+	c.current.locals = append(c.current.locals, local{name: name, depth: -1})
+}
+
+// declareVariable registers a local in the current block; globals need no declaration
+func (c *Compiler) declareVariable(name Token) {
+	if c.current.scopeDepth == 0 {
+		return
+	}
+	c.addLocal(name.Lexeme)
+}
+
+func (c *Compiler) markInitialized() {
+	if c.current.scopeDepth == 0 {
+		return
+	}
+	c.current.locals[len(c.current.locals)-1].depth = c.current.scopeDepth
+}
+
+// defineVariable binds the value on top of the stack to the variable just declared
+func (c *Compiler) defineVariable(name Token) {
+	if c.current.scopeDepth > 0 {
+		// the value simply stays on the stack in the local's slot
+		c.markInitialized()
+		return
+	}
+	c.token = name
+	c.emitOpShort(OpDefineGlobal, c.makeConstant(name.Lexeme))
+}
+
+// resolveLocal finds the slot of the innermost local called name in fc. A local still being
+// initialized is skipped unless uninitialized is set: a function nested in its initializer captures
+// it, as the resolver binds it, while a direct read is an error the resolver has already reported.
+func resolveLocal(fc *funcCompiler, name string, uninitialized bool) int {
+	for i := len(fc.locals) - 1; i >= 0; i-- {
+		if fc.locals[i].name == name && (uninitialized || fc.locals[i].depth != -1) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Compiler) addUpvalue(fc *funcCompiler, index int, isLocal bool) int {
+	for i, upvalue := range fc.upvalues {
+		if upvalue.index == index && upvalue.isLocal == isLocal {
+			return i
+		}
+	}
+
+	if len(fc.upvalues) == maxUpvalues {
+		c.error("Too many closure variables in function.")
+	}
+	fc.upvalues = append(fc.upvalues, upvalueRef{index: index, isLocal: isLocal})
+	fc.function.upvalueCount = len(fc.upvalues)
+	return len(fc.upvalues) - 1
+}
+
+// resolveUpvalue looks name up in the enclosing functions, threading an upvalue through each level (25.2 Upvalues)
+func (c *Compiler) resolveUpvalue(fc *funcCompiler, name string) int {
+	if fc.enclosing == nil {
+		return -1
+	}
+
+	if local := resolveLocal(fc.enclosing, name, true); local != -1 {
+		fc.enclosing.locals[local].isCaptured = true
+		return c.addUpvalue(fc, local, true)
+	}
+
+	if upvalue := c.resolveUpvalue(fc.enclosing, name); upvalue != -1 {
+		return c.addUpvalue(fc, upvalue, false)
+	}
+
+	return -1
+}
+
+func (c *Compiler) namedVariable(name Token, assignValue Expr) {
+	variable := c.resolveVariable(name)
+	if assignValue != nil {
+		assignValue.Accept(c)
+		variable.emit(variable.setOp)
+	} else {
+		variable.emit(variable.getOp)
+	}
+}
+
+// variableAccess holds the instructions reading and writing a variable: a local slot, an upvalue
+// or a global
+type variableAccess struct {
+	c            *Compiler
+	name         Token
+	getOp, setOp OpCode
+	operand      int
+	isShort      bool
+}
+
+func (c *Compiler) resolveVariable(name Token) variableAccess {
+	if slot := resolveLocal(c.current, name.Lexeme, false); slot != -1 {
+		return variableAccess{c: c, name: name, getOp: OpGetLocal, setOp: OpSetLocal, operand: slot}
+	}
+	if upvalue := c.resolveUpvalue(c.current, name.Lexeme); upvalue != -1 {
+		return variableAccess{c: c, name: name, getOp: OpGetUpvalue, setOp: OpSetUpvalue, operand: upvalue}
+	}
+	return variableAccess{c: c, name: name, getOp: OpGetGlobal, setOp: OpSetGlobal, operand: c.makeConstant(name.Lexeme), isShort: true}
+}
+
+func (v variableAccess) emit(op OpCode) {
+	v.c.token = v.name
+	if v.isShort {
+		v.c.emitOpShort(op, v.operand)
+	} else {
+		v.c.emitOpByte(op, v.operand)
+	}
+}
+
+// compileFunction emits a closure for a function declaration, method or function expression;
+// token is the name, or the keyword of a function expression, the closure is attributed to
+func (c *Compiler) compileFunction(name string, token Token, parameters []Token, body []Stmt, kind functionType) {
+	c.beginFunction(kind, name)
+	c.token = token
+	c.beginScope()
+
+	c.current.function.arity = len(parameters)
+	for _, param := range parameters {
+		c.declareVariable(param)
+		c.markInitialized()
+	}
+
+	for _, stmt := range body {
+		stmt.Accept(c)
+	}
+
+	upvalues := c.current.upvalues
+	function := c.endFunction()
+
+	c.token = token
+	c.emitOpShort(OpClosure, c.makeConstant(function))
+	for _, upvalue := range upvalues {
+		if upvalue.isLocal {
+			c.emitByte(1)
+		} else {
+			c.emitByte(0)
+		}
+		c.emitByte(byte(upvalue.index >> 16))
+		c.emitByte(byte(upvalue.index >> 8))
+		c.emitByte(byte(upvalue.index))
+	}
+}
+
+func (c *Compiler) VisitExpressionStmt(s *ExpressionStmt) (result interface{}, err error) {
+	s.expression.Accept(c)
+	c.emitOp(OpPop)
+	return nil, nil
+}
+
+func (c *Compiler) VisitPrintStmt(s *PrintStmt) (result interface{}, err error) {
+	s.expression.Accept(c)
+	c.emitOp(OpPrint)
+	return nil, nil
+}
+
+func (c *Compiler) VisitVarStmt(s *VarStmt) (result interface{}, err error) {
+	c.declareVariable(s.varName)
+	switch {
+	case s.initializerExpression == nil:
+		c.emitOp(OpNil)
+	case c.current.scopeDepth > 0 && containsFunction(s.initializerExpression):
+		// a function in the initializer may capture the local, so its slot holds nil rather than
+		// the temporaries of the initializer until the value is stored
+		slot := len(c.current.locals) - 1
+		c.emitOp(OpNil)
+		s.initializerExpression.Accept(c)
+		c.emitOpByte(OpSetLocal, slot)
+		c.emitOp(OpPop)
+	default:
+		s.initializerExpression.Accept(c)
+	}
+	c.defineVariable(s.varName)
+	return nil, nil
+}
+
+// containsFunction reports whether expr has a function expression in it
+func containsFunction(expr Expr) bool {
+	found := false
+	Walk(expr, func(node Node) bool {
+		_, isLambda := node.(*LambdaExpr)
+		found = found || isLambda
+		return !found
+	})
+	return found
+}
+
+func (c *Compiler) VisitFunctionStmt(s *FunctionStmt) (result interface{}, err error) {
+	c.declareVariable(s.name)
+	// a local function may refer to itself recursively, so it is initialized before its body is compiled
+	c.markInitialized()
+	c.compileFunction(s.name.Lexeme, s.name, s.parameters, s.body, plainFunction)
+	c.defineVariable(s.name)
+	return nil, nil
+}
+
+func (c *Compiler) VisitClassStmt(s *ClassStmt) (result interface{}, err error) {
+	nameConstant := c.makeConstant(s.name.Lexeme)
+	c.declareVariable(s.name)
+
+	c.token = s.name
+	c.emitOpShort(OpClass, nameConstant)
+	c.defineVariable(s.name)
+
+	class := &classCompiler{enclosing: c.currentClass}
+	c.currentClass = class
+	defer func() { c.currentClass = class.enclosing }()
+
+	if s.superclass != nil {
+		s.superclass.Accept(c)
+
+		// methods close over "super" like any other local of this hidden scope
+		c.beginScope()
+		c.addLocal("super")
+		c.markInitialized()
+
+		c.namedVariable(s.name, nil)
+		c.token = s.superclass.variableName
+		c.emitOp(OpInherit)
+		class.hasSuperclass = true
+	}
+
+	c.namedVariable(s.name, nil)
+	for _, method := range s.methods {
+		kind := methodFunction
+		if method.name.Lexeme == "init" {
+			kind = initializerFunction
+		}
+		c.compileFunction(method.name.Lexeme, method.name, method.parameters, method.body, kind)
+		c.emitOpShort(OpMethod, c.makeConstant(method.name.Lexeme))
+	}
+	c.emitOp(OpPop)
+
+	if class.hasSuperclass {
+		c.endScope()
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitReturnStmt(s *ReturnStmt) (result interface{}, err error) {
+	c.token = s.keyword
+	if len(c.current.tries) == 0 {
+		if s.value == nil {
+			c.emitReturn()
+		} else {
+			s.value.Accept(c)
+			c.emitOp(OpReturn)
+		}
+		return nil, nil
+	}
+
+	if s.value == nil && c.current.kind == initializerFunction {
+		c.emitOpByte(OpGetLocal, 0)
+	} else if s.value == nil {
+		c.emitOp(OpNil)
+	} else {
+		s.value.Accept(c)
+	}
+
+	// the result waits in a hidden local while the enclosing finally blocks run
+	c.addLocal("")
+	c.markInitialized()
+	c.exitTries(0, false)
+	c.current.locals = c.current.locals[:len(c.current.locals)-1]
+	c.emitOp(OpReturn)
+	return nil, nil
+}
+
+func (c *Compiler) VisitBlockStmt(s *BlockStmt) (result interface{}, err error) {
+	c.beginScope()
+	for _, stmt := range s.statements {
+		stmt.Accept(c)
+	}
+	c.endScope()
+	return nil, nil
+}
+
+func (c *Compiler) VisitIfStmt(s *IfStmt) (result interface{}, err error) {
+	s.condition.Accept(c)
+
+	thenJump := c.emitJump(OpJumpIfFalse)
+	c.emitOp(OpPop)
+	s.thenBranch.Accept(c)
+
+	elseJump := c.emitJump(OpJump)
+	c.patchJump(thenJump)
+	c.emitOp(OpPop)
+
+	if s.elseBranch != nil {
+		s.elseBranch.Accept(c)
+	}
+	c.patchJump(elseJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitWhileStmt(s *WhileStmt) (result interface{}, err error) {
+	loopStart := len(c.chunk().Code)
+	s.condition.Accept(c)
+
+	exitJump := c.emitJump(OpJumpIfFalse)
+	c.emitOp(OpPop)
+	loop := c.beginLoop(s.label, loopStart)
+	s.loopBody.Accept(c)
+	c.emitLoop(loopStart)
+
+	c.patchJump(exitJump)
+	c.emitOp(OpPop)
+	c.endLoop(loop)
+	return nil, nil
+}
+
+func (c *Compiler) VisitForStmt(s *ForStmt) (result interface{}, err error) {
+	c.beginScope()
+	if s.init != nil {
+		s.init.Accept(c)
+	}
+
+	loopStart := len(c.chunk().Code)
+	exitJump := -1
+	if s.condition != nil {
+		s.condition.Accept(c)
+		exitJump = c.emitJump(OpJumpIfFalse)
+		c.emitOp(OpPop)
+	}
+
+	continueTarget := loopStart
+	if s.iteration != nil {
+		continueTarget = -1
+	}
+	loop := c.beginLoop(s.label, continueTarget)
+	s.loopBody.Accept(c)
+
+	if s.iteration != nil {
+		for _, jump := range loop.continueJumps {
+			c.patchJump(jump)
+		}
+		s.iteration.Accept(c)
+		c.emitOp(OpPop)
+	}
+	c.emitLoop(loopStart)
+
+	if exitJump != -1 {
+		c.patchJump(exitJump)
+		c.emitOp(OpPop)
+	}
+	c.endLoop(loop)
+
+	c.endScope()
+	return nil, nil
+}
+
+func (c *Compiler) beginLoop(label *Token, continueTarget int) *loopCompiler {
+	loop := &loopCompiler{scopeDepth: c.current.scopeDepth, continueTarget: continueTarget}
+	if label != nil {
+		loop.label = label.Lexeme
+	}
+	c.current.loops = append(c.current.loops, loop)
+	return loop
+}
+
+// endLoop lands the loop's break jumps on the code following it
+func (c *Compiler) endLoop(loop *loopCompiler) {
+	for _, jump := range loop.breakJumps {
+		c.patchJump(jump)
+	}
+	c.current.loops = c.current.loops[:len(c.current.loops)-1]
+}
+
+// VisitTryStmt lays out a try statement as
+//
+//	    OP_TRY -> handler
+//	    <try block>
+//	    OP_END_TRY
+//	    OP_JUMP -> done
+//	handler:
+//	    OP_CATCH                   (the catch clause, if any)
+//	    OP_TRY -> rethrow          (only with a finally block)
+//	    <catch block>
+//	    OP_END_TRY
+//	    OP_POP                     (the error variable)
+//	    OP_JUMP -> done
+//	rethrow:
+//	    <finally block>
+//	    OP_RETHROW
+//	done:
+//	    <finally block>
+//
+// When an error is raised inside the try block, the VM unwinds to the handler and pushes the error.
+// The finally block is emitted once for every way out of the statement, including the returns,
+// breaks and continues that leave it (see exitTries).
+func (c *Compiler) VisitTryStmt(s *TryStmt) (result interface{}, err error) {
+	fc := c.current
+	try := &tryCompiler{finally: s.finallyBody, handler: true, scopeDepth: fc.scopeDepth, loopDepth: len(fc.loops)}
+	fc.tries = append(fc.tries, try)
+
+	c.token = s.keyword
+	handlerJump := c.emitJump(OpTry)
+	s.body.Accept(c)
+	c.emitOp(OpEndTry)
+	doneJumps := []int{c.emitJump(OpJump)}
+	c.patchJump(handlerJump)
+
+	if s.catchBody == nil {
+		fc.tries = fc.tries[:len(fc.tries)-1]
+		c.rethrowAfterFinally(s.finallyBody, 1)
+	} else {
+		c.beginScope()
+		c.token = *s.catchName
+		c.emitOp(OpCatch)
+		c.addLocal(s.catchName.Lexeme)
+		c.markInitialized()
+
+		rethrowJump := -1
+		if s.finallyBody != nil {
+			rethrowJump = c.emitJump(OpTry)
+		} else {
+			fc.tries = fc.tries[:len(fc.tries)-1]
+		}
+		s.catchBody.Accept(c)
+		if s.finallyBody != nil {
+			c.token = *s.catchName
+			c.emitOp(OpEndTry)
+		}
+		c.endScope()
+
+		if s.finallyBody != nil {
+			doneJumps = append(doneJumps, c.emitJump(OpJump))
+			c.patchJump(rethrowJump)
+			fc.tries = fc.tries[:len(fc.tries)-1]
+			// the error variable is still below the error raised in the catch block
+			c.rethrowAfterFinally(s.finallyBody, 2)
+		}
+	}
+
+	for _, jump := range doneJumps {
+		c.patchJump(jump)
+	}
+	if s.finallyBody != nil {
+		s.finallyBody.Accept(c)
+	}
+	return nil, nil
+}
+
+// rethrowAfterFinally emits the handler that runs finally before raising the caught error again.
+// slots is the number of values the handler starts with: the error, and the error variable below it
+// when the catch block failed.
+func (c *Compiler) rethrowAfterFinally(finally Stmt, slots int) {
+	c.beginScope()
+	for range slots {
+		c.addLocal("")
+		c.markInitialized()
+	}
+	finally.Accept(c)
+	c.emitOp(OpRethrow)
+
+	// the VM drops the stack as the error unwinds, so there is nothing to pop
+	c.current.scopeDepth--
+	c.current.locals = c.current.locals[:len(c.current.locals)-slots]
+}
+
+// exitTries emits what a return, break or continue does on its way out of the try statements
+// tries[outer:], innermost first: remove the statement's handler and run its finally block. Unless
+// popLocals is false, as for a return, the locals of each statement are discarded before its finally
+// block; the number of locals still live is returned.
+func (c *Compiler) exitTries(outer int, popLocals bool) (live int) {
+	fc := c.current
+	locals, scopeDepth, tries, token := fc.locals, fc.scopeDepth, fc.tries, c.token
+	live = len(locals)
+
+	for i := len(tries) - 1; i >= outer; i-- {
+		try := tries[i]
+		if popLocals {
+			// as in exitLoopBody, whether a local is captured may only be known later on
+			for ; live > 0 && locals[live-1].depth > try.scopeDepth; live-- {
+				c.emitOp(OpCloseUpvalue)
+			}
+			fc.scopeDepth = try.scopeDepth
+		}
+		if try.handler {
+			c.emitOp(OpEndTry)
+		}
+
+		if try.finally != nil {
+			// the finally block sees the locals that are left, and only the try statements around its own
+			fc.locals, fc.tries = locals[:live:live], tries[:i]
+			try.finally.Accept(c)
+			for j := range live {
+				locals[j].isCaptured = fc.locals[j].isCaptured
+			}
+			c.token = token
+		}
+	}
+
+	fc.locals, fc.scopeDepth, fc.tries = locals, scopeDepth, tries
+	return live
+}
+
+// VisitImportStmt binds globals, as the Resolver only admits imports at the top level
+func (c *Compiler) VisitImportStmt(s *ImportStmt) (result interface{}, err error) {
+	c.token = s.path
+	c.emitOpShort(OpImport, c.makeConstant(s.path.Literal))
+	if s.alias != nil {
+		c.defineVariable(*s.alias)
+		return nil, nil
+	}
+
+	for _, name := range s.names {
+		c.token = name
+		c.emitOpShort(OpGetExport, c.makeConstant(name.Lexeme))
+		c.defineVariable(name)
+	}
+	c.emitOp(OpPop)
+	return nil, nil
+}
+
+func (c *Compiler) VisitExportStmt(s *ExportStmt) (result interface{}, err error) {
+	return s.declaration.Accept(c)
+}
+
+func (c *Compiler) VisitThrowStmt(s *ThrowStmt) (result interface{}, err error) {
+	s.value.Accept(c)
+	c.token = s.keyword
+	c.emitOp(OpThrow)
+	return nil, nil
+}
+
+func (c *Compiler) VisitBreakStmt(s *BreakStmt) (result interface{}, err error) {
+	c.token = s.keyword
+	loop := c.exitLoopBody(s.label)
+	loop.breakJumps = append(loop.breakJumps, c.emitJump(OpJump))
+	return nil, nil
+}
+
+func (c *Compiler) VisitContinueStmt(s *ContinueStmt) (result interface{}, err error) {
+	c.token = s.keyword
+	loop := c.exitLoopBody(s.label)
+	if loop.continueTarget == -1 {
+		loop.continueJumps = append(loop.continueJumps, c.emitJump(OpJump))
+	} else {
+		c.emitLoop(loop.continueTarget)
+	}
+	return nil, nil
+}
+
+// exitLoopBody finds the loop targeted by a break or continue statement and discards the locals
+// declared inside its body, leaving the try statements in between on the way. Unlike endScope the
+// locals stay known, as the code after the jump still uses them. Whether a local is captured may only
+// be known further down the body, e.g. when a nested loop runs the statement again, so every one of
+// them is closed.
+func (c *Compiler) exitLoopBody(label *Token) *loopCompiler {
+	loops := c.current.loops
+	target := len(loops) - 1
+	if label != nil {
+		for i := len(loops) - 1; i >= 0; i-- {
+			if loops[i].label == label.Lexeme {
+				target = i
+				break
+			}
+		}
+	}
+	loop := loops[target]
+
+	tries := c.current.tries
+	outer := len(tries)
+	for outer > 0 && tries[outer-1].loopDepth > target {
+		outer--
+	}
+	live := c.exitTries(outer, true)
+
+	locals := c.current.locals
+	for i := live - 1; i >= 0 && locals[i].depth > loop.scopeDepth; i-- {
+		c.emitOp(OpCloseUpvalue)
+	}
+	return loop
+}
+
+func (c *Compiler) VisitBinaryExpr(e *BinaryExpr) (result interface{}, err error) {
+	e.left.Accept(c)
+	e.right.Accept(c)
+
+	c.token = e.operator
+	c.emitBinaryOp(e.operator.Type)
+	return nil, nil
+}
+
+func (c *Compiler) emitBinaryOp(operator TokenType) {
+	switch operator {
+	case Plus:
+		c.emitOp(OpAdd)
+	case Minus:
+		c.emitOp(OpSubtract)
+	case Star:
+		c.emitOp(OpMultiply)
+	case Slash:
+		c.emitOp(OpDivide)
+	case Percent:
+		c.emitOp(OpModulo)
+	case TildeSlash:
+		c.emitOp(OpFloorDivide)
+	case StarStar:
+		c.emitOp(OpPower)
+	case EqualEqual:
+		c.emitOp(OpEqual)
+	case BangEqual:
+		c.emitOp(OpNotEqual)
+	case Greater:
+		c.emitOp(OpGreater)
+	case GreaterEqual:
+		c.emitOp(OpGreaterEqual)
+	case Less:
+		c.emitOp(OpLess)
+	case LessEqual:
+		c.emitOp(OpLessEqual)
+	default:
+		panic("Unsupported binary operator!")
+	}
+}
+
+// loadTarget pushes what storing to the target of a compound assignment or increment needs (the
+// object of a property, the object and index of a subscript) followed by the target's current
+// value. depth is the number of values below the current value, and store emits the instruction
+// assigning the value on top of the stack to the target.
+func (c *Compiler) loadTarget(target Expr) (depth int, store func()) {
+	switch target := target.(type) {
+	case *VariableExpr:
+		variable := c.resolveVariable(target.variableName)
+		variable.emit(variable.getOp)
+		return 0, func() { variable.emit(variable.setOp) }
+	case *GetExpr:
+		target.object.Accept(c)
+		name := c.makeConstant(target.name.Lexeme)
+		c.token = target.name
+		c.emitOp(OpCheckFields)
+		c.emitOpByte(OpDup, 0)
+		c.emitOpShort(OpGetProperty, name)
+		return 1, func() {
+			c.token = target.name
+			c.emitOpShort(OpSetProperty, name)
+		}
+	case *IndexExpr:
+		target.object.Accept(c)
+		target.index.Accept(c)
+		c.token = target.bracket
+		c.emitOpByte(OpDup, 1)
+		c.emitOpByte(OpDup, 1)
+		c.emitOp(OpGetIndex)
+		return 2, func() {
+			c.token = target.bracket
+			c.emitOp(OpSetIndex)
+		}
+	}
+	panic("Unsupported assignment target!")
+}
+
+func (c *Compiler) VisitCompoundExpr(e *CompoundExpr) (result interface{}, err error) {
+	_, store := c.loadTarget(e.target)
+	e.value.Accept(c)
+	c.token = e.operator
+	c.emitBinaryOp(compoundOperators[e.operator.Type])
+	store()
+	return nil, nil
+}
+
+func (c *Compiler) VisitIncrementExpr(e *IncrementExpr) (result interface{}, err error) {
+	op := OpIncrement
+	if e.operator.Type == MinusMinus {
+		op = OpDecrement
+	}
+
+	depth, store := c.loadTarget(e.target)
+	if e.prefix {
+		c.token = e.operator
+		c.emitOp(op)
+		store()
+		return nil, nil
+	}
+
+	// keep the old value under the object and index, and store an updated copy
+	if depth > 0 {
+		c.emitOpByte(OpRotate, depth)
+	}
+	c.emitOpByte(OpDup, depth)
+	c.token = e.operator
+	c.emitOp(op)
+	store()
+	c.emitOp(OpPop)
+	return nil, nil
+}
+
+func (c *Compiler) VisitConditionalExpr(e *ConditionalExpr) (result interface{}, err error) {
+	e.condition.Accept(c)
+	elseJump := c.emitJump(OpJumpIfFalse)
+	c.emitOp(OpPop)
+	e.thenBranch.Accept(c)
+	endJump := c.emitJump(OpJump)
+	c.patchJump(elseJump)
+	c.emitOp(OpPop)
+	e.elseBranch.Accept(c)
+	c.patchJump(endJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitUnaryExpr(e *UnaryExpr) (result interface{}, err error) {
+	e.right.Accept(c)
+
+	c.token = e.operator
+	switch e.operator.Type {
+	case Bang:
+		c.emitOp(OpNot)
+	case Minus:
+		c.emitOp(OpNegate)
+	default:
+		panic("Unsupported unary operator!")
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitLogicalExpr(e *LogicalExpr) (result interface{}, err error) {
+	e.left.Accept(c)
+
+	switch e.operator.Type {
+	case And:
+		endJump := c.emitJump(OpJumpIfFalse)
+		c.emitOp(OpPop)
+		e.right.Accept(c)
+		c.patchJump(endJump)
+	case Or:
+		elseJump := c.emitJump(OpJumpIfFalse)
+		endJump := c.emitJump(OpJump)
+		c.patchJump(elseJump)
+		c.emitOp(OpPop)
+		e.right.Accept(c)
+		c.patchJump(endJump)
+	default:
+		panic("Unsupported logical operator " + e.operator.Lexeme)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitGroupingExpr(e *GroupingExpr) (result interface{}, err error) {
+	return e.expr.Accept(c)
+}
+
+func (c *Compiler) VisitLiteralExpr(e *LiteralExpr) (result interface{}, err error) {
+	switch v := e.value.(type) {
+	case nil:
+		c.emitOp(OpNil)
+	case bool:
+		if v {
+			c.emitOp(OpTrue)
+		} else {
+			c.emitOp(OpFalse)
+		}
+	default:
+		c.emitConstant(v)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitVariableExpr(e *VariableExpr) (result interface{}, err error) {
+	c.namedVariable(e.variableName, nil)
+	return nil, nil
+}
+
+func (c *Compiler) VisitAssignExpr(e *AssignExpr) (result interface{}, err error) {
+	c.namedVariable(e.variableName, e.assignValue)
+	return nil, nil
+}
+
+func (c *Compiler) VisitCallExpr(e *CallExpr) (result interface{}, err error) {
+	e.callee.Accept(c)
+	for _, arg := range e.arguments {
+		arg.Accept(c)
+	}
+
+	c.token = e.closingParen
+	c.emitOpByte(OpCall, len(e.arguments))
+	return nil, nil
+}
+
+func (c *Compiler) VisitGetExpr(e *GetExpr) (result interface{}, err error) {
+	e.object.Accept(c)
+	c.token = e.name
+	c.emitOpShort(OpGetProperty, c.makeConstant(e.name.Lexeme))
+	return nil, nil
+}
+
+func (c *Compiler) VisitSetExpr(e *SetExpr) (result interface{}, err error) {
+	e.object.Accept(c)
+	// the tree-walker rejects non-instances before evaluating the value, so check before its side effects
+	c.token = e.name
+	c.emitOp(OpCheckFields)
+	e.value.Accept(c)
+	c.token = e.name
+	c.emitOpShort(OpSetProperty, c.makeConstant(e.name.Lexeme))
+	return nil, nil
+}
+
+func (c *Compiler) VisitLambdaExpr(e *LambdaExpr) (result interface{}, err error) {
+	c.compileFunction(anonymousFunction, e.keyword, e.parameters, e.body, plainFunction)
+	return nil, nil
+}
+
+func (c *Compiler) VisitListExpr(e *ListExpr) (result interface{}, err error) {
+	if uint64(len(e.elements)) > math.MaxUint32 {
+		c.error("Too many elements in list literal.")
+	}
+	for _, element := range e.elements {
+		element.Accept(c)
+	}
+	c.emitOpShort(OpBuildList, len(e.elements))
+	return nil, nil
+}
+
+func (c *Compiler) VisitInterpolationExpr(e *InterpolationExpr) (result interface{}, err error) {
+	if uint64(len(e.parts)) > math.MaxUint32 {
+		c.error("Too many parts in string interpolation.")
+	}
+	for _, part := range e.parts {
+		part.Accept(c)
+	}
+	c.emitOpShort(OpBuildString, len(e.parts))
+	return nil, nil
+}
+
+func (c *Compiler) VisitMapExpr(e *MapExpr) (result interface{}, err error) {
+	c.token = e.brace
+	if uint64(len(e.keys)) > math.MaxUint32 {
+		c.error("Too many entries in map literal.")
+	}
+	for i, key := range e.keys {
+		key.Accept(c)
+		e.values[i].Accept(c)
+	}
+	c.token = e.brace
+	c.emitOpShort(OpBuildMap, len(e.keys))
+	return nil, nil
+}
+
+func (c *Compiler) VisitIndexExpr(e *IndexExpr) (result interface{}, err error) {
+	e.object.Accept(c)
+	e.index.Accept(c)
+	c.token = e.bracket
+	c.emitOp(OpGetIndex)
+	return nil, nil
+}
+
+func (c *Compiler) VisitIndexSetExpr(e *IndexSetExpr) (result interface{}, err error) {
+	e.object.Accept(c)
+	e.index.Accept(c)
+	e.value.Accept(c)
+	c.token = e.bracket
+	c.emitOp(OpSetIndex)
+	return nil, nil
+}
+
+func (c *Compiler) VisitSliceExpr(e *SliceExpr) (result interface{}, err error) {
+	e.object.Accept(c)
+	// omitted bounds are passed as nil
+	for _, bound := range []Expr{e.start, e.end} {
+		if bound != nil {
+			bound.Accept(c)
+		} else {
+			c.emitOp(OpNil)
+		}
+	}
+	c.token = e.bracket
+	c.emitOp(OpSlice)
+	return nil, nil
+}
+
+func (c *Compiler) VisitThisExpr(e *ThisExpr) (result interface{}, err error) {
+	c.namedVariable(e.keyword, nil)
+	return nil, nil
+}
+
+func (c *Compiler) VisitSuperExpr(e *SuperExpr) (result interface{}, err error) {
+	c.namedVariable(Token{Type: This, Lexeme: "this", Line: e.keyword.Line}, nil)
+	c.namedVariable(e.keyword, nil)
+	c.token = e.method
+	c.emitOpShort(OpGetSuper, c.makeConstant(e.method.Lexeme))
+	return nil, nil
+}
+
+var _ ExprVisitor = (*Compiler)(nil)
+var _ StmtVisitor = (*Compiler)(nil)
@@ -0,0 +1,226 @@
+package lox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrorFormat selects how an Interpreter reports errors
+type ErrorFormat int
+
+const (
+	// ShortErrors prints one "[line N] Error ..." line per error, as in Crafting Interpreters
+	ShortErrors ErrorFormat = iota
+	// HumanErrors prints rustc-style diagnostics quoting the source line and underlining the offending span
+	HumanErrors
+	// JSONErrors prints one JSON object per diagnostic and line, for editors and other tools
+	JSONErrors
+)
+
+// Diagnostic is an error located in a source file
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	File     string `json:"file"`
+	// 1-based line and column (in characters) where Span starts; 0 when the error has no location
+	Line   int          `json:"line"`
+	Column int          `json:"column"`
+	Span   Span         `json:"span"`
+	Trace  []StackFrame `json:"trace,omitempty"`
+
+	// inSource is false for runtime errors raised in code that came from an earlier source,
+	// e.g. a function declared by a previous Eval
+	inSource bool
+	// source is the text of File when inSource is set
+	source string
+}
+
+// Diagnostics converts err, which may be an ErrorList, into one Diagnostic per error. Positions are
+// computed from the spans when the error was raised in source, and taken from the error otherwise.
+// Errors found in an imported module are located in the module's file instead.
+func Diagnostics(err error, file, source string) []Diagnostic {
+	var moduleErr *ModuleError
+	if errors.As(err, &moduleErr) {
+		err, file, source = moduleErr.Err, moduleErr.File, moduleErr.source
+	}
+
+	var errs []error
+	var list ErrorList
+	if errors.As(err, &list) {
+		errs = list
+	} else if err != nil {
+		errs = []error{err}
+	}
+
+	diagnostics := make([]Diagnostic, 0, len(errs))
+	for _, err := range errs {
+		diagnostic := Diagnostic{Severity: "error", Message: err.Error(), File: file}
+		source := source
+		switch e := err.(type) {
+		case *ScanError:
+			diagnostic.Message = e.Message
+			diagnostic.Line, diagnostic.Column, diagnostic.Span = e.Line, e.Column, e.Span
+			diagnostic.inSource = spanIn(e.Span, source)
+		case *ParseError:
+			diagnostic.Message = e.Message
+			diagnostic.Line, diagnostic.Column, diagnostic.Span = e.Token.Line+1, e.Token.Column, e.Token.Span
+			diagnostic.inSource = spanIn(e.Token.Span, source)
+		case *CompileError:
+			diagnostic.Message = e.Message
+			diagnostic.Line, diagnostic.Column, diagnostic.Span = e.Token.Line+1, e.Token.Column, e.Token.Span
+			diagnostic.inSource = spanIn(e.Token.Span, source)
+		case *RuntimeError:
+			diagnostic.Message = e.Message
+			diagnostic.Trace = e.Trace
+			if e.module != nil && e.module.name != "" {
+				diagnostic.File, source = e.module.name, e.module.source
+			}
+			if e.line != 0 {
+				diagnostic.Line, diagnostic.Column, diagnostic.Span = e.Token.Line+1, e.Token.Column, e.Token.Span
+				span := e.Token.Span
+				diagnostic.inSource = spanIn(span, source) && source[span.Start:span.End] == e.Token.Lexeme
+			}
+		}
+
+		if diagnostic.inSource && source != "" {
+			diagnostic.Line, diagnostic.Column = position(source, diagnostic.Span.Start)
+			diagnostic.source = source
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
+
+// WriteDiagnostics reports err in the given format; source may be empty when it isn't available,
+// in which case no source lines are quoted
+func WriteDiagnostics(w io.Writer, format ErrorFormat, err error, file, source string) {
+	switch format {
+	case HumanErrors:
+		for i, diagnostic := range Diagnostics(err, file, source) {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			diagnostic.Render(w, diagnostic.source)
+		}
+	case JSONErrors:
+		encoder := json.NewEncoder(w)
+		for _, diagnostic := range Diagnostics(err, file, source) {
+			encoder.Encode(diagnostic)
+		}
+	default:
+		fmt.Fprintln(w, err)
+	}
+}
+
+// Render writes the diagnostic rustc-style:
+//
+//	error: Operands must be numbers.
+//	 --> script.lox:2:11
+//	  |
+//	2 | print 1 - "one";
+//	  |         ^
+func (d Diagnostic) Render(w io.Writer, source string) {
+	fmt.Fprintf(w, "%s: %s\n", d.Severity, d.Message)
+	if d.Line == 0 {
+		return
+	}
+
+	file := d.File
+	if file == "" {
+		file = "<input>"
+	}
+
+	lineText, lineStart, ok := sourceLine(source, d.Span.Start)
+	if !ok || d.Span.End > len(source) {
+		fmt.Fprintf(w, " --> %s:%d:%d\n", file, d.Line, d.Column)
+		d.renderTrace(w, "")
+		return
+	}
+
+	gutter := strings.Repeat(" ", len(strconv.Itoa(d.Line)))
+	fmt.Fprintf(w, "%s--> %s:%d:%d\n", gutter, file, d.Line, d.Column)
+	fmt.Fprintf(w, "%s |\n", gutter)
+	fmt.Fprintf(w, "%d | %s\n", d.Line, lineText)
+	fmt.Fprintf(w, "%s | %s\n", gutter, underline(lineText, d.Span.Start-lineStart, d.Span.End-lineStart))
+	d.renderTrace(w, gutter)
+}
+
+// renderTrace lists the calls that were active when a runtime error was raised
+func (d Diagnostic) renderTrace(w io.Writer, gutter string) {
+	lines := traceLines(d.Trace, func(frame StackFrame) string {
+		return fmt.Sprintf("in %s at line %d", frame.describe(), frame.Line)
+	})
+
+	prefix := gutter + " = note: "
+	for i, line := range lines {
+		if i > 0 {
+			prefix = strings.Repeat(" ", len(prefix))
+		}
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+}
+
+// spanIn reports whether span is a range of source; the spans of a program decoded from JSON may be
+// anything
+func spanIn(span Span, source string) bool {
+	return 0 <= span.Start && span.Start <= span.End && span.End <= len(source)
+}
+
+// position returns the 1-based line and column of the byte offset in source
+func position(source string, offset int) (line, column int) {
+	if offset == len(source) && strings.HasSuffix(source, "\n") {
+		// the end of input is shown right after the last line, see sourceLine
+		return position(source, offset-1)
+	}
+
+	before := source[:offset]
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return strings.Count(before, "\n") + 1, utf8.RuneCountInString(before[lineStart:]) + 1
+}
+
+// sourceLine returns the line containing offset (without its line break) and the offset the line starts
+// at. An offset at the very end of the source, as for the end-of-file token, belongs to the last line.
+func sourceLine(source string, offset int) (text string, start int, ok bool) {
+	if offset > len(source) || source == "" {
+		return "", 0, false
+	}
+	if offset == len(source) && strings.HasSuffix(source, "\n") {
+		offset--
+	}
+
+	start = strings.LastIndexByte(source[:offset], '\n') + 1
+	end := strings.IndexByte(source[offset:], '\n')
+	if end == -1 {
+		end = len(source)
+	} else {
+		end += offset
+	}
+	return strings.TrimSuffix(source[start:end], "\r"), start, true
+}
+
+// underline marks the byte range [from, to) of line with a caret followed by tildes. Spans running past
+// the end of the line are cut there; tabs are kept so that the marks line up with the quoted line.
+func underline(line string, from, to int) string {
+	from = min(from, len(line))
+	to = min(max(to, from), len(line))
+
+	var sb strings.Builder
+	for _, r := range line[:from] {
+		if r == '\t' {
+			sb.WriteRune('\t')
+		} else {
+			sb.WriteRune(' ')
+		}
+	}
+
+	sb.WriteRune('^')
+	if width := utf8.RuneCountInString(line[from:to]); width > 1 {
+		sb.WriteString(strings.Repeat("~", width-1))
+	}
+	return sb.String()
+}
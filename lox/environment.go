@@ -0,0 +1,53 @@
+package lox
+
+type Environment struct {
+	Enclosing *Environment
+	Values    map[string]interface{}
+}
+
+func (e *Environment) Define(name string, value interface{}) {
+	e.Values[name] = value
+}
+
+func (e *Environment) Assign(name Token, value interface{}) error {
+	if _, ok := e.Values[name.Lexeme]; ok {
+		e.Values[name.Lexeme] = value
+		return nil
+	}
+
+	if e.Enclosing != nil {
+		return e.Enclosing.Assign(name, value)
+	}
+
+	return newRuntimeError(name, "Undefined variable '%s'.", name.Lexeme)
+}
+
+func (e *Environment) Get(name Token) (interface{}, error) {
+	if val, ok := e.Values[name.Lexeme]; ok {
+		return val, nil
+	}
+
+	if e.Enclosing != nil {
+		return e.Enclosing.Get(name)
+	}
+
+	return nil, newRuntimeError(name, "Undefined variable '%s'.", name.Lexeme)
+}
+
+func (e *Environment) ancestor(distance int) *Environment {
+	env := e
+	for i := 0; i < distance; i++ {
+		env = env.Enclosing
+	}
+	return env
+}
+
+// GetAt reads a variable from the environment exactly distance hops up the chain, as computed by the Resolver
+func (e *Environment) GetAt(distance int, name string) interface{} {
+	return e.ancestor(distance).Values[name]
+}
+
+// AssignAt updates a variable in the environment exactly distance hops up the chain, as computed by the Resolver
+func (e *Environment) AssignAt(distance int, name Token, value interface{}) {
+	e.ancestor(distance).Values[name.Lexeme] = value
+}
@@ -0,0 +1,238 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScanError is a lexical error reported by the Scanner, e.g. an unexpected character
+type ScanError struct {
+	// 1-based source line
+	Line int
+	// 1-based column where the offending lexeme starts
+	Column  int
+	Span    Span
+	Message string
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("[line %d] Error: %s", e.Line, e.Message)
+}
+
+// ParseError is a static error found by the Parser or the Resolver, located at Token
+type ParseError struct {
+	Token   Token
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	location := "'" + e.Token.Lexeme + "'"
+	if e.Token.Type == Eof {
+		location = "end"
+	}
+	return fmt.Sprintf("[line %d] Error at %s: %s", e.Token.Line+1, location, e.Message)
+}
+
+// RuntimeError is raised while executing a program, e.g. on mismatched operand types
+type RuntimeError struct {
+	// Token is where the error was raised
+	Token   Token
+	Message string
+	// Trace lists the calls that were active, innermost first and ending with the top-level script.
+	// It is empty when the error was raised outside of any function.
+	Trace []StackFrame
+	// Thrown is set for errors raised by a throw statement, whose operand is Value
+	Thrown bool
+	Value  interface{}
+	// line is the 1-based line reached in the frame the error is unwinding through, 0 until the
+	// error is located
+	line int
+	// module whose code raised the error, nil until the error is located in a function or an
+	// imported module
+	module *LoxModule
+}
+
+// StackFrame is a function call in the Trace of a RuntimeError
+type StackFrame struct {
+	// Function is empty for the top-level script
+	Function string `json:"function"`
+	// 1-based line being executed in the function
+	Line int `json:"line"`
+	// Module is the path of the imported module whose top-level code the frame runs, if any
+	Module string `json:"module,omitempty"`
+}
+
+// maxTraceFrames bounds how many frames Error prints, so that a stack overflow stays readable
+const maxTraceFrames = 20
+
+func (e *RuntimeError) Error() string {
+	if len(e.Trace) == 0 {
+		return fmt.Sprintf("%s\n[line %d]", e.Message, e.Token.Line+1)
+	}
+
+	lines := traceLines(e.Trace, func(frame StackFrame) string {
+		return fmt.Sprintf("[line %d] in %s", frame.Line, frame.describe())
+	})
+	return e.Message + "\n" + strings.Join(lines, "\n")
+}
+
+func (f StackFrame) describe() string {
+	if f.Function == "" && f.Module != "" {
+		return f.Module
+	}
+	if f.Function == "" {
+		return "script"
+	}
+	return f.Function + "()"
+}
+
+// traceLines formats every frame of trace, replacing the middle of traces deeper than maxTraceFrames
+// with a line telling how many calls were left out
+func traceLines(trace []StackFrame, format func(StackFrame) string) []string {
+	var lines []string
+	skipped := len(trace) - maxTraceFrames
+	for i, frame := range trace {
+		if skipped > 0 && i >= maxTraceFrames/2 && i < len(trace)-maxTraceFrames/2 {
+			if i == maxTraceFrames/2 {
+				lines = append(lines, fmt.Sprintf("... %d more calls", skipped))
+			}
+			continue
+		}
+		lines = append(lines, format(frame))
+	}
+	return lines
+}
+
+// runtimeErrorf creates an error that is not located yet, as raised by native functions; the
+// interpreters locate it at the call site
+func runtimeErrorf(format string, a ...any) *RuntimeError {
+	return &RuntimeError{Message: fmt.Sprintf(format, a...)}
+}
+
+func newRuntimeError(token Token, format string, a ...any) *RuntimeError {
+	return &RuntimeError{Token: token, Message: fmt.Sprintf(format, a...), line: token.Line + 1}
+}
+
+// thrownError raises value from a throw statement; its message is what an uncaught error prints
+func thrownError(value interface{}) *RuntimeError {
+	message := loxStringify(value)
+	if loxErr, ok := value.(*LoxError); ok {
+		message = loxErr.message
+	}
+	return &RuntimeError{Message: message, Thrown: true, Value: value}
+}
+
+// enterFrame records that the error escaped a call to function, which was executing the current line
+func (e *RuntimeError) enterFrame(function string) {
+	e.Trace = append(e.Trace, StackFrame{Function: function, Line: e.line})
+}
+
+// enterModule records that the error escaped the top-level code of an imported module
+func (e *RuntimeError) enterModule(module *LoxModule) {
+	e.Trace = append(e.Trace, StackFrame{Line: e.line, Module: module.name})
+}
+
+// locateError places err at token if it is a RuntimeError that has no location yet
+func locateError(err error, token Token) error {
+	if runtimeErr, ok := err.(*RuntimeError); ok && runtimeErr.line == 0 {
+		runtimeErr.Token = token
+		runtimeErr.line = token.Line + 1
+	}
+	return err
+}
+
+// LoxError is the value a catch clause receives for an error raised by the interpreter rather than
+// by a throw statement. Its read-only fields are the message, the line the error was raised on and
+// the calls it unwound through up to the catching function, innermost first.
+type LoxError struct {
+	message string
+	line    int
+	stack   *LoxList
+}
+
+func (e *LoxError) String() string {
+	return "Error: " + e.message
+}
+
+// field returns the value of the field name; ok is false if there is no such field
+func (e *LoxError) field(name string) (value interface{}, ok bool) {
+	switch name {
+	case "message":
+		return e.message, true
+	case "line":
+		return float64(e.line), true
+	case "stack":
+		return e.stack, true
+	}
+	return nil, false
+}
+
+// caughtValue is the value a catch clause binds for err: the operand of a throw statement, or a
+// LoxError. stack lists the frames from where err was raised to the catching one.
+func caughtValue(err *RuntimeError, stack []StackFrame) interface{} {
+	if err.Thrown {
+		return err.Value
+	}
+
+	frames := make([]interface{}, len(stack))
+	for i, frame := range stack {
+		frames[i] = fmt.Sprintf("[line %d] in %s", frame.Line, frame.describe())
+	}
+	return &LoxError{message: err.Message, line: err.Token.Line + 1, stack: &LoxList{elements: frames}}
+}
+
+// ModuleError is a static error found in a module loaded by an import statement
+type ModuleError struct {
+	// File is the path of the module
+	File string
+	Err  error
+	// quoted by diagnostics
+	source string
+}
+
+func (e *ModuleError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ModuleError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorList gathers every error reported by a single pass (scanning, parsing, resolving);
+// errors.As finds the individual typed errors inside it
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (l ErrorList) Unwrap() []error {
+	return l
+}
+
+// Err returns nil for an empty list, so callers can return it as a plain error
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// joinErrors flattens errs into a single ErrorList, skipping nil errors
+func joinErrors(errs ...error) error {
+	var list ErrorList
+	for _, err := range errs {
+		switch e := err.(type) {
+		case nil:
+		case ErrorList:
+			list = append(list, e...)
+		default:
+			list = append(list, err)
+		}
+	}
+	return list.Err()
+}
@@ -0,0 +1,611 @@
+package lox
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// formatIndent is the indentation of each nesting level in formatted source
+	formatIndent = "  "
+	// maxLineWidth is the width past which argument lists and list and map literals are broken up, one
+	// element per line
+	maxLineWidth = 100
+)
+
+// Format parses a program and prints it back in the canonical layout: two-space indentation, single
+// spaces around binary operators, opening braces on the line of their statement and at most one
+// blank line in a row. Comments are kept where they were. Literals are printed as they were
+// written, so a program formats to the same tokens and the same AST. Source with lexical or syntax
+// errors isn't formatted; the errors are returned instead.
+func Format(source string) (string, error) {
+	scanner := NewScanner(source)
+	tokens := scanner.ScanTokens()
+	parser := Parser{Tokens: tokens}
+	stmts, parseErr := parser.Parse()
+	if err := joinErrors(scanner.Errors.Err(), parseErr); err != nil {
+		return "", err
+	}
+
+	f := &formatter{source: source, atBlockStart: true}
+	for _, tok := range tokens {
+		f.comments = append(f.comments, tok.Comments...)
+		if tok.Type == DocComment {
+			f.comments = append(f.comments, Comment{Text: tok.Lexeme, Span: tok.Span, OwnLine: startsLine(source, tok.Span.Start)})
+		}
+	}
+
+	source = strings.TrimPrefix(source, byteOrderMark)
+	if strings.HasPrefix(source, "#!") {
+		shebang, _, _ := strings.Cut(source, "\n")
+		f.write(strings.TrimRight(shebang, "\r"))
+		f.endLine()
+	}
+	for _, stmt := range stmts {
+		f.statementLine(stmt)
+	}
+	f.commentsBefore(len(f.source))
+	return f.out.String(), nil
+}
+
+// startsLine reports whether only blanks come before offset on its line
+func startsLine(source string, offset int) bool {
+	line := source[:offset]
+	if i := strings.LastIndexByte(line, '\n'); i >= 0 {
+		line = line[i+1:]
+	}
+	return strings.Trim(line, " \t\r") == ""
+}
+
+// formatter prints the AST of a program as source code
+type formatter struct {
+	source string
+	// the comments of the source, in order, and the index of the first one not written yet
+	comments []Comment
+	next     int
+	out      strings.Builder
+	indent   int
+	// the length of the current line so far; 0 at the start of a line, before the indentation
+	column int
+	// the end of the last statement or comment written, to tell whether blank lines followed it
+	lastEnd int
+	// true until something is written in a new block, which never starts with a blank line
+	atBlockStart bool
+	// flat formatters measure how wide an expression is when printed on one line
+	flat bool
+}
+
+func (f *formatter) write(s string) {
+	if f.column == 0 && !f.flat {
+		f.out.WriteString(strings.Repeat(formatIndent, f.indent))
+		f.column = len(formatIndent) * f.indent
+	}
+	f.out.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		f.column = utf8.RuneCountInString(s[i+1:])
+	} else {
+		f.column += utf8.RuneCountInString(s)
+	}
+}
+
+// endLine finishes the current line unless nothing has been written on it
+func (f *formatter) endLine() {
+	if f.column > 0 {
+		f.out.WriteString("\n")
+		f.column = 0
+	}
+}
+
+// text returns the source code of span
+func (f *formatter) text(span Span) string {
+	return strings.ReplaceAll(f.source[span.Start:span.End], "\r\n", "\n")
+}
+
+// blankLine keeps a blank line before offset if the source has one there
+func (f *formatter) blankLine(offset int) {
+	if !f.atBlockStart && strings.Count(f.source[f.lastEnd:offset], "\n") > 1 {
+		f.out.WriteString("\n")
+	}
+	f.atBlockStart = false
+}
+
+// commentsBefore writes the comments before offset on lines of their own
+func (f *formatter) commentsBefore(offset int) {
+	for f.next < len(f.comments) && f.comments[f.next].Span.Start < offset {
+		comment := f.comments[f.next]
+		f.next++
+		f.blankLine(comment.Span.Start)
+		f.write(comment.Text)
+		f.endLine()
+		f.lastEnd = max(f.lastEnd, comment.Span.End)
+	}
+}
+
+// trailingComments writes the comments before end, that is inside the construct just written, and
+// the comments following it on the same line, then ends the line. They go at the end of the current
+// line, up to the first line comment, which ends it.
+func (f *formatter) trailingComments(end int) {
+	for f.next < len(f.comments) {
+		comment := f.comments[f.next]
+		if comment.Span.Start >= end && (comment.OwnLine || strings.TrimSpace(f.source[max(end, f.lastEnd):comment.Span.Start]) != "") {
+			break
+		}
+		f.next++
+		if f.column > 0 {
+			f.write(" ")
+		}
+		f.write(comment.Text)
+		if strings.HasPrefix(comment.Text, "//") {
+			f.endLine()
+		}
+		f.lastEnd = max(f.lastEnd, comment.Span.End)
+	}
+	f.endLine()
+}
+
+// statementLine writes stmt and the comments around it, starting on a new line
+func (f *formatter) statementLine(stmt Stmt) {
+	f.commentsBefore(stmt.Span().Start)
+	f.blankLine(stmt.Span().Start)
+	f.statement(stmt)
+	f.lastEnd = max(f.lastEnd, stmt.Span().End)
+	f.trailingComments(stmt.Span().End)
+}
+
+// block writes statements between braces; end is the offset of the closing brace
+func (f *formatter) block(stmts []Stmt, end int, write func(Stmt)) {
+	f.write("{")
+	if len(stmts) == 0 && (f.next == len(f.comments) || f.comments[f.next].Span.Start >= end) {
+		f.write("}")
+		return
+	}
+
+	// a comment after the opening brace stays on its line
+	first := end
+	if len(stmts) > 0 {
+		first = stmts[0].Span().Start
+	}
+	if f.next < len(f.comments) && !f.comments[f.next].OwnLine && f.comments[f.next].Span.Start < first {
+		comment := f.comments[f.next]
+		f.next++
+		f.write(" " + comment.Text)
+		f.lastEnd = max(f.lastEnd, comment.Span.End)
+	}
+	f.endLine()
+	f.indent++
+	f.atBlockStart = true
+	for _, stmt := range stmts {
+		f.commentsBefore(stmt.Span().Start)
+		f.blankLine(stmt.Span().Start)
+		write(stmt)
+		f.lastEnd = max(f.lastEnd, stmt.Span().End)
+		f.trailingComments(stmt.Span().End)
+	}
+	f.commentsBefore(end)
+	f.indent--
+	f.write("}")
+	f.lastEnd = max(f.lastEnd, end+1)
+}
+
+// body writes the body of a control flow statement: a block goes on the line of the statement,
+// anything else on an indented line of its own
+func (f *formatter) body(stmt Stmt) {
+	if block, ok := stmt.(*BlockStmt); ok {
+		f.write(" ")
+		f.statement(block)
+		return
+	}
+	f.endLine()
+	f.indent++
+	f.atBlockStart = true
+	f.statementLine(stmt)
+	f.indent--
+}
+
+func (f *formatter) statement(stmt Stmt) {
+	switch s := stmt.(type) {
+	case *ExpressionStmt:
+		f.expression(s.expression)
+		f.write(";")
+	case *PrintStmt:
+		f.write("print ")
+		f.expression(s.expression)
+		f.write(";")
+	case *VarStmt:
+		f.varClause(s)
+		f.write(";")
+	case *FunctionStmt:
+		f.write("fun ")
+		f.function(s)
+	case *ReturnStmt:
+		f.write("return")
+		if s.value != nil {
+			f.write(" ")
+			f.expression(s.value)
+		}
+		f.write(";")
+	case *BlockStmt:
+		f.block(s.statements, s.span.End-1, f.statement)
+	case *IfStmt:
+		f.write("if (")
+		f.expression(s.condition)
+		f.write(")")
+		f.body(s.thenBranch)
+		if s.elseBranch == nil {
+			break
+		}
+		if _, ok := s.thenBranch.(*BlockStmt); ok {
+			f.write(" ")
+		}
+		f.write("else")
+		if elseIf, ok := s.elseBranch.(*IfStmt); ok {
+			f.write(" ")
+			f.statement(elseIf)
+		} else {
+			f.body(s.elseBranch)
+		}
+	case *WhileStmt:
+		f.label(s.label)
+		f.write("while (")
+		f.expression(s.condition)
+		f.write(")")
+		f.body(s.loopBody)
+	case *ForStmt:
+		f.label(s.label)
+		f.write("for (")
+		switch init := s.init.(type) {
+		case *VarStmt:
+			f.varClause(init)
+		case *ExpressionStmt:
+			f.expression(init.expression)
+		}
+		f.write(";")
+		if s.condition != nil {
+			f.write(" ")
+			f.expression(s.condition)
+		}
+		f.write(";")
+		if s.iteration != nil {
+			f.write(" ")
+			f.expression(s.iteration)
+		}
+		f.write(")")
+		f.body(s.loopBody)
+	case *BreakStmt:
+		f.loopControl("break", s.label)
+	case *ContinueStmt:
+		f.loopControl("continue", s.label)
+	case *ThrowStmt:
+		f.write("throw ")
+		f.expression(s.value)
+		f.write(";")
+	case *TryStmt:
+		f.write("try ")
+		f.statement(s.body)
+		if s.catchBody != nil {
+			f.write(" catch (" + s.catchName.Lexeme + ") ")
+			f.statement(s.catchBody)
+		}
+		if s.finallyBody != nil {
+			f.write(" finally ")
+			f.statement(s.finallyBody)
+		}
+	case *ImportStmt:
+		if s.alias != nil {
+			f.write("import " + s.path.Lexeme + " as " + s.alias.Lexeme + ";")
+			break
+		}
+		names := make([]string, len(s.names))
+		for i, name := range s.names {
+			names[i] = name.Lexeme
+		}
+		f.write("from " + s.path.Lexeme + " import " + strings.Join(names, ", ") + ";")
+	case *ExportStmt:
+		f.write("export ")
+		f.statement(s.declaration)
+	case *ClassStmt:
+		f.write("class " + s.name.Lexeme + " ")
+		if s.superclass != nil {
+			f.write("< " + s.superclass.variableName.Lexeme + " ")
+		}
+		methods := make([]Stmt, len(s.methods))
+		for i, method := range s.methods {
+			methods[i] = method
+		}
+		f.block(methods, s.span.End-1, func(method Stmt) { f.function(method.(*FunctionStmt)) })
+	}
+}
+
+// function writes a function declaration or method after the "fun" keyword
+func (f *formatter) function(fn *FunctionStmt) {
+	f.write(fn.name.Lexeme)
+	f.parameters(fn.parameters)
+	f.write(" ")
+	f.block(fn.body, fn.span.End-1, f.statement)
+}
+
+func (f *formatter) parameters(params []Token) {
+	names := make([]string, len(params))
+	for i, param := range params {
+		names[i] = param.Lexeme
+	}
+	f.write("(" + strings.Join(names, ", ") + ")")
+}
+
+// varClause writes a variable declaration without its semicolon, as in a for loop's initializer
+func (f *formatter) varClause(s *VarStmt) {
+	f.write("var " + s.varName.Lexeme)
+	if s.initializerExpression != nil {
+		f.write(" = ")
+		f.expression(s.initializerExpression)
+	}
+}
+
+func (f *formatter) label(label *Token) {
+	if label != nil {
+		f.write(label.Lexeme + ": ")
+	}
+}
+
+func (f *formatter) loopControl(keyword string, label *Token) {
+	f.write(keyword)
+	if label != nil {
+		f.write(" " + label.Lexeme)
+	}
+	f.write(";")
+}
+
+func (f *formatter) expression(expr Expr) {
+	switch e := expr.(type) {
+	case *LiteralExpr:
+		f.write(f.text(e.span))
+	case *InterpolationExpr:
+		f.write(f.text(e.span))
+	case *GroupingExpr:
+		f.write("(")
+		f.expression(e.expr)
+		f.write(")")
+	case *UnaryExpr:
+		f.write(e.operator.Lexeme)
+		// "- -x" must not run together into a decrement
+		if e.operator.Type == Minus && startsWithMinus(e.right) {
+			f.write(" ")
+		}
+		f.expression(e.right)
+	case *BinaryExpr:
+		f.binary(e.left, e.operator, e.right)
+	case *LogicalExpr:
+		f.binary(e.left, e.operator, e.right)
+	case *VariableExpr:
+		f.write(e.variableName.Lexeme)
+	case *AssignExpr:
+		f.write(e.variableName.Lexeme + " = ")
+		f.expression(e.assignValue)
+	case *CompoundExpr:
+		f.binary(e.target, e.operator, e.value)
+	case *IncrementExpr:
+		if e.prefix {
+			f.write(e.operator.Lexeme)
+			f.expression(e.target)
+		} else {
+			f.expression(e.target)
+			f.write(e.operator.Lexeme)
+		}
+	case *ConditionalExpr:
+		f.expression(e.condition)
+		f.write(" ? ")
+		f.expression(e.thenBranch)
+		f.write(" : ")
+		f.expression(e.elseBranch)
+	case *CallExpr:
+		f.expression(e.callee)
+		f.list("(", ")", listElements(e.arguments), e.closingParen.Span.Start, func(f *formatter, element []Expr) {
+			f.expression(element[0])
+		})
+	case *GetExpr:
+		f.expression(e.object)
+		f.write("." + e.name.Lexeme)
+	case *SetExpr:
+		f.expression(e.object)
+		f.write("." + e.name.Lexeme + " = ")
+		f.expression(e.value)
+	case *ThisExpr:
+		f.write("this")
+	case *SuperExpr:
+		f.write("super." + e.method.Lexeme)
+	case *LambdaExpr:
+		if e.keyword.Type == Arrow {
+			f.parameters(e.parameters)
+			f.write(" => ")
+			f.expression(e.body[0].(*ReturnStmt).value)
+			break
+		}
+		f.write("fun ")
+		f.parameters(e.parameters)
+		f.write(" ")
+		f.block(e.body, e.span.End-1, f.statement)
+	case *ListExpr:
+		f.list("[", "]", listElements(e.elements), e.span.End-1, func(f *formatter, element []Expr) {
+			f.expression(element[0])
+		})
+	case *MapExpr:
+		entries := make([][]Expr, len(e.keys))
+		for i := range entries {
+			entries[i] = []Expr{e.keys[i], e.values[i]}
+		}
+		f.list("{", "}", entries, e.span.End-1, func(f *formatter, entry []Expr) {
+			f.expression(entry[0])
+			f.write(": ")
+			f.expression(entry[1])
+		})
+	case *IndexExpr:
+		f.expression(e.object)
+		f.write("[")
+		f.expression(e.index)
+		f.write("]")
+	case *IndexSetExpr:
+		f.expression(e.object)
+		f.write("[")
+		f.expression(e.index)
+		f.write("] = ")
+		f.expression(e.value)
+	case *SliceExpr:
+		f.expression(e.object)
+		f.write("[")
+		if e.start != nil {
+			f.expression(e.start)
+		}
+		f.write(":")
+		if e.end != nil {
+			f.expression(e.end)
+		}
+		f.write("]")
+	}
+}
+
+func startsWithMinus(expr Expr) bool {
+	switch e := expr.(type) {
+	case *UnaryExpr:
+		return e.operator.Type == Minus
+	case *IncrementExpr:
+		return e.prefix && e.operator.Type == MinusMinus
+	}
+	return false
+}
+
+func (f *formatter) binary(left Expr, operator Token, right Expr) {
+	f.expression(left)
+	f.write(" " + operator.Lexeme + " ")
+	f.expression(right)
+}
+
+// listElements makes each expression an element of its own for list
+func listElements(exprs []Expr) [][]Expr {
+	elements := make([][]Expr, len(exprs))
+	for i, expr := range exprs {
+		elements[i] = []Expr{expr}
+	}
+	return elements
+}
+
+// list writes comma separated elements, each made of one or more expressions, between brackets;
+// end is the offset of the closing bracket. When they don't fit on the current line, or a line
+// comment among them has to end a line, each element goes on a line of its own. Comments between
+// the elements are written where they were.
+func (f *formatter) list(open, close string, elements [][]Expr, end int, write func(f *formatter, element []Expr)) {
+	flat := &formatter{source: f.source, flat: true}
+	flat.write(open)
+	for i, element := range elements {
+		if i > 0 {
+			flat.write(", ")
+		}
+		write(flat, element)
+	}
+	flat.write(close)
+	line, _, _ := strings.Cut(flat.out.String(), "\n")
+
+	starts := make([]int, len(elements)+1)
+	for i, element := range elements {
+		starts[i] = element[0].Span().Start
+	}
+	starts[len(elements)] = end
+
+	if f.flat || (!f.lineCommentIn(elements, end) && f.column+utf8.RuneCountInString(line) <= maxLineWidth) {
+		f.write(open)
+		for i, element := range elements {
+			if i > 0 {
+				f.write(", ")
+			}
+			f.inlineComments(starts[i], "", " ")
+			write(f, element)
+		}
+		if len(elements) > 0 {
+			f.inlineComments(end, " ", "")
+		} else {
+			f.inlineComments(end, "", "")
+		}
+		f.write(close)
+		return
+	}
+
+	f.write(open)
+	f.indent++
+	for i, element := range elements {
+		f.listComments(starts[i])
+		write(f, element)
+		if i < len(elements)-1 {
+			f.write(",")
+		}
+	}
+	f.listComments(end)
+	f.indent--
+	f.write(close)
+}
+
+// lineCommentIn reports whether a line comment comes before end that isn't inside an argument list,
+// list or map literal or function body nested in elements, which would place it themselves
+func (f *formatter) lineCommentIn(elements [][]Expr, end int) bool {
+	var nested []Span
+	for _, element := range elements {
+		for _, expr := range element {
+			Walk(expr, func(node Node) bool {
+				switch n := node.(type) {
+				case *CallExpr, *ListExpr, *MapExpr:
+					nested = append(nested, n.Span())
+					return false
+				case *LambdaExpr:
+					if n.keyword.Type != Arrow {
+						nested = append(nested, n.Span())
+						return false
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	for _, comment := range f.comments[f.next:] {
+		if comment.Span.Start >= end {
+			break
+		}
+		inNested := slices.ContainsFunc(nested, func(span Span) bool {
+			return span.Start <= comment.Span.Start && comment.Span.End <= span.End
+		})
+		if !inNested && strings.HasPrefix(comment.Text, "//") {
+			return true
+		}
+	}
+	return false
+}
+
+// inlineComments writes the comments before offset on the current line, each between before and
+// after. A line comment, which can only be left inside nested elements, ends the line.
+func (f *formatter) inlineComments(offset int, before, after string) {
+	for f.next < len(f.comments) && f.comments[f.next].Span.Start < offset {
+		comment := f.comments[f.next]
+		f.next++
+		if strings.HasPrefix(comment.Text, "//") {
+			f.write(before + comment.Text)
+			f.endLine()
+			continue
+		}
+		f.write(before + comment.Text + after)
+	}
+}
+
+// listComments ends the line of the last element or opening bracket of a list broken up into lines,
+// the comments that followed it on its line at its end, and writes the other comments before offset
+// on lines of their own
+func (f *formatter) listComments(offset int) {
+	for f.next < len(f.comments) && f.comments[f.next].Span.Start < offset && !f.comments[f.next].OwnLine {
+		f.write(" " + f.comments[f.next].Text)
+		f.next++
+	}
+	f.endLine()
+	for f.next < len(f.comments) && f.comments[f.next].Span.Start < offset {
+		f.write(f.comments[f.next].Text)
+		f.endLine()
+		f.next++
+	}
+}
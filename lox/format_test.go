@@ -0,0 +1,144 @@
+package lox
+
+import (
+	"testing"
+)
+
+// formatCorpus is source in all kinds of layouts, with comments in every place they can go
+var formatCorpus = []struct {
+	name   string
+	source string
+}{
+	{"expressions", "print 1+2*3;print -(-1) ;print - -1;var x=1;x+=2;x++;print x ;"},
+	{"blocks", "if(true){print 1;}else if (false) print 2; else{print 3;}\n\n\n{var a=1;{print a;}}"},
+	{"loops", "outer:for(var i=0;i<3;i=i+1){while(true){break outer;}}\nfor(;;)break;"},
+	{"functions", "fun add(a,b){return a+b;}\nvar f=fun(x){return x;};var g=(x)=>x*2;print add(f(1),g(2));"},
+	{"classes", "class A{init(x){this.x=x;}\nget(){return this.x;}}class B<A{get(){return super.get()+1;}}\nprint B(1).get();"},
+	{"collections", "var l=[1,2,3];var m={\"a\":1,\"b\":[l[0],l[1:]]};print m[\"b\"];"},
+	{"try", "try{throw \"x\";}catch(e){print e;}finally{print \"done\";}"},
+	{"comments", `// leading
+var a = 1; // trailing
+/* block */ print a;
+
+{ // after the brace
+  print a; /* inside */
+  // before the brace
+}
+// at the end
+`},
+	{"comments in lists", `fun f(a, b) { return a + b; }
+print f(1, /* mid */ 2);
+var l = [
+  1, // one
+  // two
+  2
+];
+var m = {"a": 1, /* b */ "b": 2};
+print f(/* x */ 1, 2 /* y */);
+print [/* empty */];
+print f(1 + // odd
+  2, 3);
+`},
+	{"long lines", `print [` + "\"aaaaaaaaaaaaaaaaaaaa\", \"bbbbbbbbbbbbbbbbbbbb\", \"cccccccccccccccccccc\", \"dddddddddddddddddddd\", \"eeeeeeeeeeeeeeeeeeee\"" + `];`},
+	{"literals", `print 0xFF + 1_000 + 0b101; print "a\tb${1 + 2}";`},
+}
+
+func TestFormatIsIdempotent(t *testing.T) {
+	for _, test := range formatCorpus {
+		t.Run(test.name, func(t *testing.T) {
+			once, err := Format(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			twice, err := Format(once)
+			if err != nil {
+				t.Fatalf("formatted source doesn't parse: %v\n%s", err, once)
+			}
+			if twice != once {
+				t.Errorf("formatting again changed\n%s\ninto\n%s", once, twice)
+			}
+		})
+	}
+}
+
+func TestFormatKeepsMeaning(t *testing.T) {
+	for _, test := range formatCorpus {
+		t.Run(test.name, func(t *testing.T) {
+			formatted, err := Format(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := Parse(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(formatted)
+			if err != nil {
+				t.Fatalf("formatted source doesn't parse: %v\n%s", err, formatted)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("formatted source has %d statements instead of %d:\n%s", len(got), len(want), formatted)
+			}
+			for i := range want {
+				if !EqualNodes(got[i], want[i]) {
+					t.Errorf("statement %d parses differently after formatting:\n%s", i+1, formatted)
+				}
+			}
+
+			wantOut, _, wantStatus := run(t, TreeWalker, test.source)
+			gotOut, _, gotStatus := run(t, TreeWalker, formatted)
+			if gotOut != wantOut || gotStatus != wantStatus {
+				t.Errorf("formatted source prints %q and exits with %d instead of %q and %d", gotOut, gotStatus, wantOut, wantStatus)
+			}
+		})
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"block comment between arguments", "f(1, /* mid */ 2);", "f(1, /* mid */ 2);\n"},
+		{"block comment after the last argument", "f(1,2/* end */);", "f(1, 2 /* end */);\n"},
+		{"comments in an empty list", "print [ /* none */ ];", "print [/* none */];\n"},
+		{
+			"line comments between elements",
+			"var l = [1, // one\n// two\n2];",
+			"var l = [\n  1, // one\n  // two\n  2\n];\n",
+		},
+		{
+			"line comment after an opening bracket",
+			"f( // why\n1);",
+			"f( // why\n  1\n);\n",
+		},
+		{
+			"comments in a function argument",
+			"f(fun () {\n// body\nreturn 1;\n}, 2);",
+			"f(fun () {\n  // body\n  return 1;\n}, 2);\n",
+		},
+		{
+			"comment inside an expression",
+			"print 1 /* one */ + 2;\nprint 3;",
+			"print 1 + 2; /* one */\nprint 3;\n",
+		},
+		{
+			"blank lines after a statement with inner comments",
+			"print 1 /* a */ + 2; // b\nprint 3;",
+			"print 1 + 2; /* a */ // b\nprint 3;\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Format(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("Format(%q) =\n%s\nwant\n%s", test.source, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package lox
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// collection is implemented by the values that support subscripts and built-in methods: lists and maps
+type collection interface {
+	Get(index interface{}) (interface{}, error)
+	Set(index, value interface{}) error
+	method(name string) (method LoxCallable, ok bool)
+}
+
+var (
+	_ collection = (*LoxList)(nil)
+	_ collection = (*LoxMap)(nil)
+	_ collection = loxString("")
+)
+
+// asCollection returns value as a collection; strings are wrapped so that they can be indexed too
+func asCollection(value interface{}) (collection, bool) {
+	if s, ok := value.(string); ok {
+		return loxString(s), true
+	}
+	c, ok := value.(collection)
+	return c, ok
+}
+
+// LoxList is the value of a list literal. Lists are mutable and shared by reference, so they
+// compare equal only to themselves.
+type LoxList struct {
+	elements []interface{}
+}
+
+func (l *LoxList) String() string {
+	return formatElement(l, nil)
+}
+
+// Len is the number of elements in the list
+func (l *LoxList) Len() int {
+	return len(l.elements)
+}
+
+// Get returns the element at index, counting from the end for negative indices
+func (l *LoxList) Get(index interface{}) (interface{}, error) {
+	i, err := elementIndex(index, len(l.elements), "list", false)
+	if err != nil {
+		return nil, err
+	}
+	return l.elements[i], nil
+}
+
+// Set replaces the element at index, counting from the end for negative indices
+func (l *LoxList) Set(index, value interface{}) error {
+	i, err := elementIndex(index, len(l.elements), "list", false)
+	if err != nil {
+		return err
+	}
+	l.elements[i] = value
+	return nil
+}
+
+// Slice copies the elements from start up to end into a new list. Either bound may be nil to slice
+// from the beginning or to the end; negative bounds count from the end and bounds out of range are
+// clamped to the list.
+func (l *LoxList) Slice(start, end interface{}) (*LoxList, error) {
+	from, to, err := sliceRange(start, end, len(l.elements))
+	if err != nil {
+		return nil, err
+	}
+	return &LoxList{elements: slices.Clone(l.elements[from:to])}, nil
+}
+
+// sliceValue slices a list or a string
+func sliceValue(object, start, end interface{}) (interface{}, error) {
+	switch object := object.(type) {
+	case *LoxList:
+		return object.Slice(start, end)
+	case string:
+		return loxString(object).Slice(start, end)
+	}
+	return nil, runtimeErrorf("Only lists and strings can be sliced.")
+}
+
+// elementIndex converts a Lox number into an index of a list or string of length elements, counting
+// from the end for negative numbers; allowEnd admits the index right after the last element, where
+// insert appends
+func elementIndex(index interface{}, length int, kind string, allowEnd bool) (int, error) {
+	number, ok := index.(float64)
+	if !ok || number != math.Trunc(number) {
+		return 0, runtimeErrorf("%s index must be an integer.", strings.ToUpper(kind[:1])+kind[1:])
+	}
+
+	i := number
+	if i < 0 {
+		i += float64(length)
+	}
+	last := float64(length - 1)
+	if allowEnd {
+		last++
+	}
+	if i < 0 || i > last {
+		return 0, runtimeErrorf("Index %s is out of bounds for a %s of length %d.", loxStringify(number), kind, length)
+	}
+	return int(i), nil
+}
+
+// sliceRange converts the bounds of a slice into a range of indices of a list or string of length
+// elements. Either bound may be nil to slice from the beginning or to the end; negative bounds
+// count from the end and bounds out of range are clamped.
+func sliceRange(start, end interface{}, length int) (from, to int, err error) {
+	if from, err = sliceBound(start, length, 0); err != nil {
+		return 0, 0, err
+	}
+	if to, err = sliceBound(end, length, length); err != nil {
+		return 0, 0, err
+	}
+	return from, max(from, to), nil
+}
+
+func sliceBound(bound interface{}, length, omitted int) (int, error) {
+	if bound == nil {
+		return omitted, nil
+	}
+	number, ok := bound.(float64)
+	if !ok || number != math.Trunc(number) {
+		return 0, runtimeErrorf("Slice bounds must be integers.")
+	}
+
+	if number < 0 {
+		number += float64(length)
+	}
+	return int(min(max(number, 0), float64(length))), nil
+}
+
+func (l *LoxList) format(enclosing []interface{}) string {
+	elements := make([]string, len(l.elements))
+	for i, element := range l.elements {
+		elements[i] = formatElement(element, enclosing)
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// method returns the built-in method name bound to the list; ok is false if there is no such method
+func (l *LoxList) method(name string) (method LoxCallable, ok bool) {
+	var fn interface{}
+	switch name {
+	case "len":
+		fn = func() int { return len(l.elements) }
+	case "push":
+		fn = func(value interface{}) { l.elements = append(l.elements, value) }
+	case "pop":
+		fn = func() (interface{}, error) {
+			if len(l.elements) == 0 {
+				return nil, runtimeErrorf("Can't pop from an empty list.")
+			}
+			last := l.elements[len(l.elements)-1]
+			l.elements = l.elements[:len(l.elements)-1]
+			return last, nil
+		}
+	case "insert":
+		fn = func(index float64, value interface{}) error {
+			i, err := elementIndex(index, len(l.elements), "list", true)
+			if err != nil {
+				return err
+			}
+			l.elements = slices.Insert(l.elements, i, value)
+			return nil
+		}
+	case "remove":
+		fn = func(index float64) (interface{}, error) {
+			i, err := elementIndex(index, len(l.elements), "list", false)
+			if err != nil {
+				return nil, err
+			}
+			removed := l.elements[i]
+			l.elements = slices.Delete(l.elements, i, i+1)
+			return removed, nil
+		}
+	case "contains":
+		fn = func(value interface{}) bool { return slices.Contains(l.elements, value) }
+	case "map":
+		fn = func(transform func(interface{}) (interface{}, error)) (*LoxList, error) {
+			mapped := make([]interface{}, len(l.elements))
+			for i, element := range l.elements {
+				result, err := transform(element)
+				if err != nil {
+					return nil, err
+				}
+				mapped[i] = result
+			}
+			return &LoxList{elements: mapped}, nil
+		}
+	case "filter":
+		fn = func(keep func(interface{}) (interface{}, error)) (*LoxList, error) {
+			var kept []interface{}
+			for _, element := range l.elements {
+				result, err := keep(element)
+				if err != nil {
+					return nil, err
+				}
+				if isTruthy(result) {
+					kept = append(kept, element)
+				}
+			}
+			return &LoxList{elements: kept}, nil
+		}
+	case "reduce":
+		fn = func(combine func(interface{}, interface{}) (interface{}, error), initial interface{}) (interface{}, error) {
+			accumulator := initial
+			for _, element := range l.elements {
+				var err error
+				if accumulator, err = combine(accumulator, element); err != nil {
+					return nil, err
+				}
+			}
+			return accumulator, nil
+		}
+	case "sort":
+		fn = l.sort
+	default:
+		return nil, false
+	}
+	return &nativeFunction{name: name, fn: reflect.ValueOf(fn)}, true
+}
+
+// sort orders the list in place, stably. Without a comparison function the elements must be all
+// numbers or all strings; compare(a, b) returns a negative number when a goes before b.
+func (l *LoxList) sort(compare ...func(a, b interface{}) (float64, error)) error {
+	if len(compare) > 1 {
+		return runtimeErrorf("Expected at most 1 argument but got %d.", len(compare))
+	}
+
+	// the list is only updated once every comparison has succeeded
+	sorted := slices.Clone(l.elements)
+	var err error
+	if len(compare) == 1 {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if err != nil {
+				return false
+			}
+			var order float64
+			order, err = compare[0](sorted[i], sorted[j])
+			return order < 0
+		})
+	} else {
+		err = sortValues(sorted)
+	}
+	if err != nil {
+		return err
+	}
+
+	l.elements = sorted
+	return nil
+}
+
+func sortValues(values []interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	switch values[0].(type) {
+	case float64:
+		for _, value := range values {
+			if _, ok := value.(float64); !ok {
+				return runtimeErrorf("Can only sort lists of numbers or lists of strings.")
+			}
+		}
+		sort.SliceStable(values, func(i, j int) bool { return values[i].(float64) < values[j].(float64) })
+	case string:
+		for _, value := range values {
+			if _, ok := value.(string); !ok {
+				return runtimeErrorf("Can only sort lists of numbers or lists of strings.")
+			}
+		}
+		sort.SliceStable(values, func(i, j int) bool { return values[i].(string) < values[j].(string) })
+	default:
+		return runtimeErrorf("Can only sort lists of numbers or lists of strings.")
+	}
+	return nil
+}
+
+// formatElement prints a value as it appears inside a collection: strings are quoted, and a
+// collection nested in itself is shown as [...] or {...}
+func formatElement(value interface{}, enclosing []interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return quoteString(value)
+	case *LoxList:
+		if slices.Contains(enclosing, interface{}(value)) {
+			return "[...]"
+		}
+		return value.format(append(enclosing, value))
+	case *LoxMap:
+		if slices.Contains(enclosing, interface{}(value)) {
+			return "{...}"
+		}
+		return value.format(append(enclosing, value))
+	}
+	return loxStringify(value)
+}
+
+// stringEscapes spells out the characters that can't appear as themselves in a string literal
+var stringEscapes = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`, "\x00", `\0`, "${", `\${`)
+
+// quoteString writes s as a string literal that reads back as s
+func quoteString(s string) string {
+	return `"` + stringEscapes.Replace(s) + `"`
+}
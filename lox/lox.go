@@ -0,0 +1,322 @@
+// Package lox implements the Lox language from Crafting Interpreters: a scanner, a recursive-descent
+// parser, a static resolver and two execution engines, a tree-walk interpreter and a bytecode VM.
+//
+// Embedders create an Interpreter with New and feed it source code:
+//
+//	in := lox.New(lox.WithStdout(&buf))
+//	if _, err := in.Eval(ctx, `print "hello";`); err != nil {
+//		// err is (or wraps) a *ScanError, *ParseError, *CompileError or *RuntimeError
+//	}
+package lox
+
+import (
+	"context"
+	"io"
+	"maps"
+	"os"
+)
+
+// The AST types are generated from grammar.json; "go run ./tool -check ../grammar.json" fails when
+// ast.generated.go is out of date.
+//go:generate go run ./tool -o ast.generated.go ../grammar.json
+
+// Backend selects the engine an Interpreter executes programs with
+type Backend int
+
+const (
+	// TreeWalker evaluates the AST directly (AstInterpreter)
+	TreeWalker Backend = iota
+	// Bytecode compiles the AST into a Chunk and runs it on the stack VM
+	Bytecode
+)
+
+type Option func(*Interpreter)
+
+// WithStdout sets where "print" statements write to (os.Stdout by default)
+func WithStdout(w io.Writer) Option {
+	return func(in *Interpreter) { in.stdout = w }
+}
+
+// WithStderr sets where diagnostics are reported (os.Stderr by default)
+func WithStderr(w io.Writer) Option {
+	return func(in *Interpreter) { in.stderr = w }
+}
+
+func WithBackend(backend Backend) Option {
+	return func(in *Interpreter) { in.backend = backend }
+}
+
+// WithSourceName sets the file name diagnostics give for source passed to Eval and EvalExpr ("<input>" by default)
+func WithSourceName(name string) Option {
+	return func(in *Interpreter) { in.sourceName = name }
+}
+
+// WithErrorFormat sets how errors are reported to stderr (ShortErrors by default)
+func WithErrorFormat(format ErrorFormat) Option {
+	return func(in *Interpreter) { in.errorFormat = format }
+}
+
+// WithDisassembly makes the Bytecode backend write a listing of every compiled program to w
+func WithDisassembly(w io.Writer) Option {
+	return func(in *Interpreter) { in.disassembly = w }
+}
+
+// WithModulePath adds directories where import statements look for modules that aren't found
+// relative to the importing file
+func WithModulePath(dirs ...string) Option {
+	return func(in *Interpreter) { in.modules.searchPath = append(in.modules.searchPath, dirs...) }
+}
+
+// Interpreter is one Lox session: globals persist across calls to Eval, Exec and RunFile.
+// Interpreters share no state, so any number of them can run concurrently, but a single
+// Interpreter must not be used from several goroutines at once.
+type Interpreter struct {
+	stdout      io.Writer
+	stderr      io.Writer
+	backend     Backend
+	disassembly io.Writer
+	errorFormat ErrorFormat
+	sourceName  string
+	ast         *AstInterpreter
+	vm          *VM
+	modules     *moduleLoader
+	// functions added with RegisterFunc, which imported modules see as well
+	natives map[string]interface{}
+	// name and contents of the source being run, quoted by diagnostics
+	file   string
+	source string
+}
+
+func New(opts ...Option) *Interpreter {
+	in := &Interpreter{
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
+		sourceName: "<input>",
+		modules:    &moduleLoader{modules: make(map[string]*LoxModule)},
+		natives:    make(map[string]interface{}),
+	}
+	in.modules.run = in.runModule
+	for _, opt := range opts {
+		opt(in)
+	}
+
+	if in.backend == Bytecode {
+		in.vm = NewVM(in.stdout)
+		in.vm.modules = in.modules
+	} else {
+		in.ast = NewAstInterpreter(in.stdout)
+		in.ast.modules = in.modules
+	}
+	return in
+}
+
+// Eval runs a program. The result is the value of its last statement when that is an expression
+// statement, and nil otherwise. Errors are reported to the configured stderr and returned.
+func (in *Interpreter) Eval(ctx context.Context, source string) (interface{}, error) {
+	return in.eval(ctx, in.sourceName, source)
+}
+
+func (in *Interpreter) eval(ctx context.Context, file, source string) (interface{}, error) {
+	defer in.setSource(file, source)()
+
+	stmts, err := Parse(source)
+	if err != nil {
+		return nil, in.report(err)
+	}
+	return in.Exec(ctx, stmts)
+}
+
+// EvalExpr evaluates a single expression and returns its value
+func (in *Interpreter) EvalExpr(ctx context.Context, source string) (interface{}, error) {
+	defer in.setSource(in.sourceName, source)()
+
+	expr, err := ParseExpr(source)
+	if err != nil {
+		return nil, in.report(err)
+	}
+
+	if in.backend == Bytecode {
+		script, err := NewCompiler().CompileExpr(expr)
+		if err != nil {
+			return nil, in.report(err)
+		}
+		return in.runScript(ctx, script)
+	}
+
+	result, err := in.ast.InterpretExpr(ctx, expr)
+	if err != nil {
+		return nil, in.report(err)
+	}
+	return result, nil
+}
+
+// Exec resolves and executes already parsed statements, see Eval. Import statements look modules
+// up relative to the directory of the source name.
+func (in *Interpreter) Exec(ctx context.Context, stmts []Stmt) (interface{}, error) {
+	// statements that weren't parsed by Eval, e.g. read by UnmarshalProgram, have no source to quote
+	if in.file == "" {
+		defer in.setSource(in.sourceName, "")()
+	}
+	defer in.modules.enter(in.file)()
+
+	resolver := NewResolver(in.ast)
+	resolver.Resolve(stmts)
+	if err := resolver.Errors.Err(); err != nil {
+		return nil, in.report(err)
+	}
+
+	if in.backend == Bytecode {
+		script, err := NewCompiler().Compile(stmts)
+		if err != nil {
+			return nil, in.report(err)
+		}
+		return in.runScript(ctx, script)
+	}
+
+	result, err := in.ast.Interpret(ctx, stmts)
+	if err != nil {
+		return nil, in.report(err)
+	}
+	return result, nil
+}
+
+func (in *Interpreter) runScript(ctx context.Context, script *vmFunction) (interface{}, error) {
+	if in.disassembly != nil {
+		DisassembleAll(in.disassembly, script)
+	}
+
+	result, err := in.vm.Interpret(ctx, script)
+	if err != nil {
+		return nil, in.report(err)
+	}
+	return result, nil
+}
+
+// runModule checks and executes an imported module on the Interpreter's backend. Static errors are
+// returned as a ModuleError, so that they are reported against the module's source.
+func (in *Interpreter) runModule(module *LoxModule) error {
+	stmts, err := Parse(module.source)
+	if err == nil {
+		resolver := NewResolver(in.ast)
+		resolver.Resolve(stmts)
+		err = resolver.Errors.Err()
+	}
+	if err != nil {
+		return &ModuleError{File: module.name, Err: err, source: module.source}
+	}
+
+	globals := builtinGlobals()
+	maps.Copy(globals, in.natives)
+	module.globals = &Environment{Values: globals}
+	module.exports = exportedNames(stmts)
+
+	if in.backend == Bytecode {
+		script, err := NewCompiler().Compile(stmts)
+		if err != nil {
+			return &ModuleError{File: module.name, Err: err, source: module.source}
+		}
+		if in.disassembly != nil {
+			DisassembleAll(in.disassembly, script)
+		}
+		return in.vm.runModule(script, module)
+	}
+	return in.ast.runModule(stmts, module)
+}
+
+// RunFile reads and runs the program stored at path
+func (in *Interpreter) RunFile(ctx context.Context, path string) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = in.eval(ctx, path, string(source))
+	return err
+}
+
+// setSource makes diagnostics quote source until the returned function restores the previous one
+func (in *Interpreter) setSource(file, source string) (restore func()) {
+	previousFile, previousSource := in.file, in.source
+	in.file, in.source = file, source
+	return func() { in.file, in.source = previousFile, previousSource }
+}
+
+func (in *Interpreter) report(err error) error {
+	WriteDiagnostics(in.stderr, in.errorFormat, err, in.file, in.source)
+	return err
+}
+
+func (in *Interpreter) globals() map[string]interface{} {
+	if in.backend == Bytecode {
+		return in.vm.globals
+	}
+	return in.ast.Globals.Values
+}
+
+// SetGlobal defines or overwrites a global variable. The value must be a Lox value: nil, a bool,
+// a float64, a string, or a value previously obtained from this Interpreter.
+func (in *Interpreter) SetGlobal(name string, value interface{}) {
+	in.globals()[name] = value
+}
+
+// RegisterFunc defines the global name as a native function calling fn, which must be a Go function.
+// Parameters and results may be booleans, strings, numbers of any Go numeric type, interface{} (any
+// Lox value), LoxCallable, or functions built from these types: a Lox function passed for a func
+// parameter is called back through it. Variadic functions accept any number of trailing arguments.
+// Arguments that don't fit the parameter types, as well as a non-nil trailing error result, raise
+// a runtime error in the calling program.
+//
+//	in.RegisterFunc("repeat", func(s string, n int) string { return strings.Repeat(s, n) })
+func (in *Interpreter) RegisterFunc(name string, fn interface{}) error {
+	native, err := newNativeFunction(name, fn)
+	if err != nil {
+		return err
+	}
+	in.SetGlobal(name, native)
+	in.natives[name] = native
+	return nil
+}
+
+// Global returns the value of a global variable and whether it is defined
+func (in *Interpreter) Global(name string) (interface{}, bool) {
+	value, ok := in.globals()[name]
+	return value, ok
+}
+
+// Globals returns a snapshot of all global variables
+func (in *Interpreter) Globals() map[string]interface{} {
+	snapshot := make(map[string]interface{}, len(in.globals()))
+	for name, value := range in.globals() {
+		snapshot[name] = value
+	}
+	return snapshot
+}
+
+// Scan splits source into tokens. All tokens are returned even when lexical errors were found.
+func Scan(source string) ([]Token, error) {
+	scanner := NewScanner(source)
+	tokens := scanner.ScanTokens()
+	return tokens, scanner.Errors.Err()
+}
+
+// Parse scans and parses a whole program and returns every lexical and syntax error in it, together
+// with the statements that parsed cleanly.
+func Parse(source string) ([]Stmt, error) {
+	tokens, scanErr := Scan(source)
+	parser := Parser{Tokens: tokens}
+	stmts, parseErr := parser.Parse()
+	return stmts, joinErrors(scanErr, parseErr)
+}
+
+// ParseExpr scans and parses the first expression in source, see Parse
+func ParseExpr(source string) (Expr, error) {
+	tokens, scanErr := Scan(source)
+	parser := Parser{Tokens: tokens}
+	expr, parseErr := parser.ParseExpr()
+	return expr, joinErrors(scanErr, parseErr)
+}
+
+// Stringify formats a Lox value the way "print" does
+func Stringify(value interface{}) string {
+	return loxStringify(value)
+}
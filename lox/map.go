@@ -0,0 +1,130 @@
+package lox
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// LoxMap is the value of a map literal. Keys are strings, numbers, booleans or nil and compare
+// like Lox ==; entries are kept in insertion order. Maps are shared by reference like lists.
+type LoxMap struct {
+	entries []mapEntry
+	// position of each key in entries
+	indices map[interface{}]int
+}
+
+type mapEntry struct {
+	key, value interface{}
+}
+
+func newLoxMap() *LoxMap {
+	return &LoxMap{indices: make(map[interface{}]int)}
+}
+
+func (m *LoxMap) String() string {
+	return formatElement(m, nil)
+}
+
+// Len is the number of entries in the map
+func (m *LoxMap) Len() int {
+	return len(m.entries)
+}
+
+// Get returns the value stored under key; a missing key is an error
+func (m *LoxMap) Get(key interface{}) (interface{}, error) {
+	if err := checkMapKey(key); err != nil {
+		return nil, err
+	}
+	i, ok := m.indices[key]
+	if !ok {
+		return nil, runtimeErrorf("Undefined key %s.", formatElement(key, nil))
+	}
+	return m.entries[i].value, nil
+}
+
+// Set stores value under key. A new key goes after all others, an existing one keeps its place.
+func (m *LoxMap) Set(key, value interface{}) error {
+	if err := checkMapKey(key); err != nil {
+		return err
+	}
+	if i, ok := m.indices[key]; ok {
+		m.entries[i].value = value
+		return nil
+	}
+	m.indices[key] = len(m.entries)
+	m.entries = append(m.entries, mapEntry{key: key, value: value})
+	return nil
+}
+
+// Delete removes key from the map and reports whether it was present
+func (m *LoxMap) Delete(key interface{}) (bool, error) {
+	if err := checkMapKey(key); err != nil {
+		return false, err
+	}
+	i, ok := m.indices[key]
+	if !ok {
+		return false, nil
+	}
+
+	delete(m.indices, key)
+	m.entries = slices.Delete(m.entries, i, i+1)
+	for j := i; j < len(m.entries); j++ {
+		m.indices[m.entries[j].key] = j
+	}
+	return true, nil
+}
+
+func checkMapKey(key interface{}) error {
+	switch key.(type) {
+	case nil, bool, float64, string:
+		return nil
+	}
+	return runtimeErrorf("Map keys must be strings, numbers, booleans or nil.")
+}
+
+// method returns the built-in method name bound to the map; ok is false if there is no such method
+func (m *LoxMap) method(name string) (method LoxCallable, ok bool) {
+	var fn interface{}
+	switch name {
+	case "len":
+		fn = func() int { return len(m.entries) }
+	case "keys":
+		fn = func() *LoxList {
+			keys := make([]interface{}, len(m.entries))
+			for i, entry := range m.entries {
+				keys[i] = entry.key
+			}
+			return &LoxList{elements: keys}
+		}
+	case "values":
+		fn = func() *LoxList {
+			values := make([]interface{}, len(m.entries))
+			for i, entry := range m.entries {
+				values[i] = entry.value
+			}
+			return &LoxList{elements: values}
+		}
+	case "has":
+		fn = func(key interface{}) (bool, error) {
+			if err := checkMapKey(key); err != nil {
+				return false, err
+			}
+			_, ok := m.indices[key]
+			return ok, nil
+		}
+	case "delete":
+		fn = m.Delete
+	default:
+		return nil, false
+	}
+	return &nativeFunction{name: name, fn: reflect.ValueOf(fn)}, true
+}
+
+func (m *LoxMap) format(enclosing []interface{}) string {
+	entries := make([]string, len(m.entries))
+	for i, entry := range m.entries {
+		entries[i] = formatElement(entry.key, enclosing) + ": " + formatElement(entry.value, enclosing)
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
@@ -0,0 +1,141 @@
+package lox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoxModule is a source file loaded by an import statement. A module runs once, in globals of its
+// own; "import ... as m" binds the module itself, whose exported names read like properties.
+// The program an Interpreter runs is a module as well, with an empty name.
+type LoxModule struct {
+	// path the module was found at, as diagnostics show it
+	name    string
+	source  string
+	globals *Environment
+	// names declared with export
+	exports map[string]bool
+}
+
+func (m *LoxModule) String() string {
+	return "<module " + m.name + ">"
+}
+
+// export returns the current value of an exported name, so that a module sees the updates its
+// functions make to their globals
+func (m *LoxModule) export(name string) (interface{}, error) {
+	if !m.exports[name] {
+		return nil, runtimeErrorf("Module '%s' doesn't export '%s'.", m.name, name)
+	}
+	return m.globals.Values[name], nil
+}
+
+// exportedNames lists the top-level declarations marked with export
+func exportedNames(stmts []Stmt) map[string]bool {
+	exports := make(map[string]bool)
+	for _, stmt := range stmts {
+		export, ok := stmt.(*ExportStmt)
+		if !ok {
+			continue
+		}
+		switch declaration := export.declaration.(type) {
+		case *VarStmt:
+			exports[declaration.varName.Lexeme] = true
+		case *FunctionStmt:
+			exports[declaration.name.Lexeme] = true
+		case *ClassStmt:
+			exports[declaration.name.Lexeme] = true
+		}
+	}
+	return exports
+}
+
+// builtinGlobals are the globals every module starts with
+func builtinGlobals() map[string]interface{} {
+	return map[string]interface{}{"clock": ClockFunc{}}
+}
+
+// moduleLoader finds, runs and caches the modules imported by the programs of one Interpreter
+type moduleLoader struct {
+	// directories searched for module paths not found next to the importing file
+	searchPath []string
+	// modules that finished running, by absolute path
+	modules map[string]*LoxModule
+	// files whose top-level code is running, the innermost importer last
+	running []moduleFile
+	// run executes a module on the Interpreter's backend
+	run func(module *LoxModule) error
+}
+
+type moduleFile struct {
+	path string
+	name string
+}
+
+// enter records that the top-level code of the file name is running, until leave is called
+func (l *moduleLoader) enter(name string) (leave func()) {
+	path, _ := filepath.Abs(name)
+	l.running = append(l.running, moduleFile{path: path, name: name})
+	return func() { l.running = l.running[:len(l.running)-1] }
+}
+
+// load returns the module at path, running it first unless it has been imported before. Relative
+// paths are looked up next to the importing file, then in every directory of the search path.
+func (l *moduleLoader) load(path string) (*LoxModule, error) {
+	name, err := l.find(path)
+	if err != nil {
+		return nil, err
+	}
+	absolute, err := filepath.Abs(name)
+	if err != nil {
+		return nil, runtimeErrorf("Can't find module '%s'.", path)
+	}
+	if module, ok := l.modules[absolute]; ok {
+		return module, nil
+	}
+
+	for i, file := range l.running {
+		if file.path == absolute {
+			var cycle []string
+			for _, importer := range l.running[i:] {
+				cycle = append(cycle, importer.name)
+			}
+			cycle = append(cycle, name)
+			return nil, runtimeErrorf("Import cycle: %s.", strings.Join(cycle, " -> "))
+		}
+	}
+
+	source, err := os.ReadFile(name)
+	if err != nil {
+		return nil, runtimeErrorf("Can't read module '%s': %s.", name, err)
+	}
+	module := &LoxModule{name: name, source: string(source)}
+
+	leave := l.enter(name)
+	err = l.run(module)
+	leave()
+	if err != nil {
+		return nil, err
+	}
+	l.modules[absolute] = module
+	return module, nil
+}
+
+func (l *moduleLoader) find(path string) (string, error) {
+	candidates := []string{path}
+	if !filepath.IsAbs(path) {
+		importer := l.running[len(l.running)-1]
+		candidates = []string{filepath.Join(filepath.Dir(importer.name), path)}
+		for _, dir := range l.searchPath {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", runtimeErrorf("Can't find module '%s'.", path)
+}
@@ -0,0 +1,323 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	callableType = reflect.TypeOf((*LoxCallable)(nil)).Elem()
+)
+
+// nativeFunction is a Go function exposed to Lox programs, see Interpreter.RegisterFunc.
+// Arguments and results are converted between Lox and Go values through reflection.
+type nativeFunction struct {
+	name string
+	fn   reflect.Value
+}
+
+// newNativeFunction checks up front that every parameter and result of fn has a Lox counterpart
+func newNativeFunction(name string, fn interface{}) (*nativeFunction, error) {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return nil, fmt.Errorf("native function %s: expected a Go function, got %T", name, fn)
+	}
+	if err := checkNativeType(value.Type()); err != nil {
+		return nil, fmt.Errorf("native function %s: %w", name, err)
+	}
+	return &nativeFunction{name: name, fn: value}, nil
+}
+
+// Arity is -1 for variadic functions, which check their minimum number of arguments in Call
+func (nf *nativeFunction) Arity() int {
+	if nf.fn.Type().IsVariadic() {
+		return -1
+	}
+	return nf.fn.Type().NumIn()
+}
+
+func (nf *nativeFunction) Call(itp *AstInterpreter, arguments []interface{}) (result interface{}, err error) {
+	fnType := nf.fn.Type()
+	fixed := fnType.NumIn()
+	if fnType.IsVariadic() {
+		fixed--
+		if len(arguments) < fixed {
+			return nil, runtimeErrorf("Expected at least %d arguments but got %d.", fixed, len(arguments))
+		}
+	}
+
+	in := make([]reflect.Value, len(arguments))
+	for i, argument := range arguments {
+		var paramType reflect.Type
+		if i < fixed {
+			paramType = fnType.In(i)
+		} else {
+			paramType = fnType.In(fixed).Elem()
+		}
+
+		value, ok := fromLox(itp, argument, paramType)
+		if !ok {
+			return nil, runtimeErrorf("Argument %d to '%s' must be %s.", i+1, nf.name, describeType(paramType, argument))
+		}
+		in[i] = value
+	}
+
+	// a failing Lox callback unwinds through Go code that has no error result to return it with
+	defer func() {
+		if r := recover(); r != nil {
+			failure, ok := r.(callbackFailure)
+			if !ok {
+				panic(r)
+			}
+			result, err = nil, failure.err
+		}
+	}()
+
+	return nativeResults(nf.fn.Call(in))
+}
+
+func (nf *nativeFunction) String() string {
+	return "<native fn>"
+}
+
+// callbackFailure carries the error of a Lox callback back to nativeFunction.Call
+type callbackFailure struct {
+	err error
+}
+
+// checkNativeType reports whether values of type t can be exchanged with Lox
+func checkNativeType(t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return nil
+	case reflect.Interface:
+		if t.NumMethod() == 0 || t == callableType {
+			return nil
+		}
+	case reflect.Func:
+		for i := 0; i < t.NumIn(); i++ {
+			param := t.In(i)
+			if t.IsVariadic() && i == t.NumIn()-1 {
+				param = param.Elem()
+			}
+			if err := checkNativeType(param); err != nil {
+				return err
+			}
+		}
+
+		results := t.NumOut()
+		if results > 0 && t.Out(results-1) == errorType {
+			results--
+		}
+		if results > 1 {
+			return fmt.Errorf("%s returns more than one value besides an error", t)
+		}
+		if results == 1 {
+			return checkNativeType(t.Out(0))
+		}
+		return nil
+	}
+	return fmt.Errorf("type %s has no Lox equivalent", t)
+}
+
+// fromLox converts a Lox value to a Go value of type t; ok is false when value doesn't fit t.
+// Numbers only convert to integer types when they have no fractional part and are in range.
+func fromLox(itp *AstInterpreter, value interface{}, t reflect.Type) (converted reflect.Value, ok bool) {
+	switch t.Kind() {
+	case reflect.Bool:
+		b, ok := value.(bool)
+		return reflect.ValueOf(b).Convert(t), ok
+	case reflect.String:
+		s, ok := value.(string)
+		return reflect.ValueOf(s).Convert(t), ok
+	case reflect.Float32, reflect.Float64:
+		n, ok := value.(float64)
+		return reflect.ValueOf(n).Convert(t), ok
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(float64)
+		converted = reflect.New(t).Elem()
+		if !ok || n != math.Trunc(n) || math.Abs(n) >= 1<<63 || converted.OverflowInt(int64(n)) {
+			return converted, false
+		}
+		converted.SetInt(int64(n))
+		return converted, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := value.(float64)
+		converted = reflect.New(t).Elem()
+		if !ok || n != math.Trunc(n) || n < 0 || n >= 1<<64 || converted.OverflowUint(uint64(n)) {
+			return converted, false
+		}
+		converted.SetUint(uint64(n))
+		return converted, true
+	case reflect.Interface:
+		if value == nil {
+			return reflect.Zero(t), true
+		}
+		if !reflect.TypeOf(value).Implements(t) {
+			return reflect.Zero(t), false
+		}
+		return reflect.ValueOf(value).Convert(t), true
+	case reflect.Func:
+		if value == nil {
+			return reflect.Zero(t), true
+		}
+		callable, ok := value.(LoxCallable)
+		if !ok {
+			return reflect.Zero(t), false
+		}
+		return makeCallback(itp, callable, t), true
+	}
+	return reflect.Zero(t), false
+}
+
+// toLox converts a Go value back to a Lox value. Values that already are Lox values, e.g. an
+// instance passed through an interface{} parameter, are returned unchanged.
+func toLox(value reflect.Value) (interface{}, error) {
+	switch value.Kind() {
+	case reflect.Bool:
+		return value.Bool(), nil
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	case reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return toLox(value.Elem())
+	case reflect.Func:
+		if value.IsNil() {
+			return nil, nil
+		}
+		if err := checkNativeType(value.Type()); err != nil {
+			return nil, err
+		}
+		return &nativeFunction{name: "native fn", fn: value}, nil
+	}
+
+	switch v := value.Interface().(type) {
+	case LoxCallable, *LoxInstance, *vmInstance, *LoxList, *LoxMap, *LoxError:
+		return v, nil
+	}
+	return nil, fmt.Errorf("Go value of type %s has no Lox equivalent", value.Type())
+}
+
+// nativeResults converts the results of a Go function, turning a non-nil trailing error into a runtime error
+func nativeResults(out []reflect.Value) (interface{}, error) {
+	if n := len(out); n > 0 && out[n-1].Type() == errorType {
+		if !out[n-1].IsNil() {
+			err := out[n-1].Interface().(error)
+			if runtimeErr, ok := err.(*RuntimeError); ok {
+				return nil, runtimeErr
+			}
+			return nil, runtimeErrorf("%s", err)
+		}
+		out = out[:n-1]
+	}
+
+	if len(out) == 0 {
+		return nil, nil
+	}
+	result, err := toLox(out[0])
+	if err != nil {
+		return nil, runtimeErrorf("%s", err)
+	}
+	return result, nil
+}
+
+// makeCallback wraps a Lox callable into a Go function of type t. The function must be called
+// synchronously, while the native function it was passed to is running.
+func makeCallback(itp *AstInterpreter, callable LoxCallable, t reflect.Type) reflect.Value {
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		if t.IsVariadic() {
+			rest := in[len(in)-1]
+			in = in[:len(in)-1]
+			for i := 0; i < rest.Len(); i++ {
+				in = append(in, rest.Index(i))
+			}
+		}
+
+		arguments := make([]interface{}, len(in))
+		for i, arg := range in {
+			argument, err := toLox(arg)
+			if err != nil {
+				return callbackResults(itp, t, nil, runtimeErrorf("%s", err))
+			}
+			arguments[i] = argument
+		}
+
+		if arity := callable.Arity(); arity >= 0 && arity != len(arguments) {
+			return callbackResults(itp, t, nil, runtimeErrorf("Expected %d arguments but got %d.", arity, len(arguments)))
+		}
+		result, err := callable.Call(itp, arguments)
+		return callbackResults(itp, t, result, err)
+	})
+}
+
+// callbackResults builds the results of a callback of type t: the error is returned when t has an
+// error result, and raised as a callbackFailure otherwise
+func callbackResults(itp *AstInterpreter, t reflect.Type, result interface{}, err error) []reflect.Value {
+	out := make([]reflect.Value, t.NumOut())
+	for i := range out {
+		out[i] = reflect.Zero(t.Out(i))
+	}
+	hasError := len(out) > 0 && t.Out(len(out)-1) == errorType
+
+	if err == nil && (len(out) == 2 || (len(out) == 1 && !hasError)) {
+		value, ok := fromLox(itp, result, t.Out(0))
+		if ok {
+			out[0] = value
+		} else {
+			err = runtimeErrorf("Callback must return %s.", describeType(t.Out(0), result))
+		}
+	}
+
+	if err != nil {
+		if !hasError {
+			panic(callbackFailure{err})
+		}
+		out[len(out)-1] = reflect.ValueOf(&err).Elem()
+	}
+	return out
+}
+
+// describeType names the Lox values accepted for t, for the error about value not converting to
+// it; a whole number out of the range of an integer type gets the range
+func describeType(t reflect.Type, value interface{}) string {
+	n, isNumber := value.(float64)
+	whole := isNumber && n == math.Trunc(n)
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if whole {
+			bits := t.Bits()
+			return fmt.Sprintf("an integer between %d and %d", int64(-1)<<(bits-1), int64(1)<<(bits-1)-1)
+		}
+		return "an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if whole {
+			return fmt.Sprintf("an integer between 0 and %d", uint64(math.MaxUint64)>>(64-t.Bits()))
+		}
+		return "an integer"
+	case reflect.Func:
+		return "a function"
+	}
+	if t == callableType {
+		return "a function"
+	}
+	return "a value"
+}
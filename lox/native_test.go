@@ -0,0 +1,102 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testNatives are registered for TestNativeFunctions
+var testNatives = map[string]interface{}{
+	"u8":   func(n uint8) uint8 { return n },
+	"i16":  func(n int16) int16 { return n },
+	"u64":  func(n uint64) uint64 { return n },
+	"half": func(n float32) float32 { return n / 2 },
+	"sum": func(xs ...int) int {
+		total := 0
+		for _, x := range xs {
+			total += x
+		}
+		return total
+	},
+	"join": func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+	"check": func(n int) (int, error) {
+		if n < 0 {
+			return 0, errors.New("negative")
+		}
+		return n, nil
+	},
+	"twice": func(f func(int) int, n int) int { return f(f(n)) },
+	"each": func(n int, f func(int) error) error {
+		for i := 0; i < n; i++ {
+			if err := f(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	"same": func(v interface{}) interface{} { return v },
+}
+
+func TestNativeFunctions(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		// the message of the runtime error, if any
+		err string
+	}{
+		{"integers", `print u8(255); print i16(-32768); print u64(0);`, "255\n-32768\n0\n", ""},
+		{"floats", `print half(3);`, "1.5\n", ""},
+		{"fraction", `u8(1.5);`, "", "Argument 1 to 'u8' must be an integer."},
+		{"above the range", `u8(256);`, "", "Argument 1 to 'u8' must be an integer between 0 and 255."},
+		{"below the range", `u8(-1);`, "", "Argument 1 to 'u8' must be an integer between 0 and 255."},
+		{"signed range", `i16(40000);`, "", "Argument 1 to 'i16' must be an integer between -32768 and 32767."},
+		{"unsigned 64 bits", `u64(-1);`, "", "Argument 1 to 'u64' must be an integer between 0 and 18446744073709551615."},
+		{"not a number", `u8("1");`, "", "Argument 1 to 'u8' must be an integer."},
+		{"variadic", `print sum(); print sum(1, 2, 3); print join("-", "a", "b");`, "0\n6\na-b\n", ""},
+		{"variadic argument", `sum(1, 2.5);`, "", "Argument 2 to 'sum' must be an integer."},
+		{"too few arguments", `join();`, "", "Expected at least 1 arguments but got 0."},
+		{"error result", `print check(1); check(-1);`, "1\n", "negative"},
+		{"caught error result", `try { check(-1); } catch (e) { print e; }`, "Error: negative\n", ""},
+		{"callback", `print twice((n) => n * 3, 2); fun show(i) { print i; } each(2, show);`, "18\n0\n1\n", ""},
+		{"callback with a bound method", `class C { init() { this.k = 10; } add(n) { return n + this.k; } } print twice(C().add, 1);`, "21\n", ""},
+		{"callback result", `twice((n) => "x", 1);`, "", "Callback must return an integer."},
+		{"callback result out of range", `twice((n) => -1 - 2 ** 63 * 2, 1);`, "", "Callback must return an integer between -9223372036854775808 and 9223372036854775807."},
+		{"error in a callback", `each(3, fun (i) { if (i == 1) throw "stop"; print i; });`, "0\n", "stop"},
+		{"any value", `var l = [1]; print same(l) == l; print same(nil);`, "true\nnil\n", ""},
+	}
+	for _, test := range tests {
+		for _, backend := range []struct {
+			name    string
+			backend Backend
+		}{{"tree-walker", TreeWalker}, {"vm", Bytecode}} {
+			t.Run(test.name+"/"+backend.name, func(t *testing.T) {
+				var out bytes.Buffer
+				interpreter := New(WithBackend(backend.backend), WithStdout(&out), WithStderr(io.Discard))
+				for name, fn := range testNatives {
+					if err := interpreter.RegisterFunc(name, fn); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				_, err := interpreter.Eval(context.Background(), test.source)
+				if out.String() != test.want {
+					t.Errorf("printed %q, want %q", out.String(), test.want)
+				}
+				var runtimeErr *RuntimeError
+				switch {
+				case test.err == "" && err != nil:
+					t.Errorf("failed with %v", err)
+				case test.err != "" && !errors.As(err, &runtimeErr):
+					t.Errorf("failed with %v, want a runtime error", err)
+				case test.err != "" && runtimeErr.Message != test.err && Stringify(runtimeErr.Value) != test.err:
+					t.Errorf("failed with %q, want %q", runtimeErr.Message, test.err)
+				}
+			})
+		}
+	}
+}
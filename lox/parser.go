@@ -0,0 +1,1368 @@
+package lox
+
+import (
+	"fmt"
+	"slices"
+)
+
+type Parser struct {
+	Tokens  []Token
+	Current int
+	// every syntax error found so far; parsing resumes at the next statement after each one
+	reported ErrorList
+	// labels of the loops enclosing the current statement, innermost last; "" for unlabeled loops
+	loops []string
+}
+
+// Parse parses the whole program and reports all syntax errors in it. The statements that parsed
+// cleanly are returned alongside the errors.
+func (p *Parser) Parse() ([]Stmt, error) {
+	p.skipDocComments()
+	var statements []Stmt
+	for p.Tokens[p.Current].Type != Eof {
+		if nextStmt, ok := p.declaration(); ok {
+			statements = append(statements, nextStmt)
+		}
+	}
+	return statements, p.reported.Err()
+}
+
+// declaration records a syntax error and skips to the next statement when the declaration is
+// malformed, in which case ok is false
+func (p *Parser) declaration() (nextStmt Stmt, ok bool) {
+	start := p.Current
+	var err error
+	if p.match(Class) {
+		nextStmt, err = p.classDeclaration()
+	} else if p.Tokens[p.Current].Type == Function && p.Tokens[p.Current+1].Type == Identifier {
+		// a "fun" not followed by a name starts a function expression
+		p.Current++
+		nextStmt, err = p.funcDeclaration("function")
+	} else if p.match(Var) {
+		nextStmt, err = p.varDeclaration()
+	} else if p.match(Export) {
+		nextStmt, err = p.exportDeclaration()
+	} else if p.match(Import) {
+		nextStmt, err = p.importDeclaration()
+	} else if p.Tokens[p.Current].Lexeme == "from" && p.Tokens[p.Current+1].Type == String {
+		// "from" is only a keyword in front of a module path
+		p.Current++
+		nextStmt, err = p.fromImportDeclaration()
+	} else {
+		nextStmt, err = p.statement()
+	}
+
+	if err != nil {
+		p.report(err)
+		if p.Current == start {
+			// the offending token can't start any statement, e.g. a stray '}'; parsing resumes right
+			// after it, as a brace following it opens a block rather than closing the discarded tokens
+			p.Current++
+			return nil, false
+		}
+		p.synchronize()
+		return nil, false
+	}
+	return nextStmt, true
+}
+
+// report records a syntax error, unless it repeats the previous one
+func (p *Parser) report(err error) {
+	if !p.repeats(err) {
+		p.reported = append(p.reported, err)
+	}
+}
+
+// classDecl -> "class" IDENTIFIER ( "<" IDENTIFIER )? "{" function* "}"
+func (p *Parser) classDeclaration() (Stmt, error) {
+	start := p.previous()
+	if !p.match(Identifier) {
+		return nil, p.getError("Expect class name.")
+	}
+	className := p.previous()
+
+	var superclass *VariableExpr
+	if p.match(Less) {
+		if !p.match(Identifier) {
+			return nil, p.getError("Expect superclass name.")
+		}
+		superclass = &VariableExpr{variableName: p.previous(), span: p.previous().Span}
+	}
+
+	if !p.match(LeftBrace) {
+		return nil, p.getError("Expect '{' before class body.")
+	}
+
+	var methods []*FunctionStmt
+	for p.Tokens[p.Current].Type != RightBrace && p.Tokens[p.Current].Type != Eof {
+		start := p.Current
+		method, err := p.funcDeclaration("method")
+		if err != nil {
+			// a malformed method is left out, and parsing resumes at the next one
+			p.report(err)
+			if p.Current == start {
+				p.Current++
+			}
+			p.synchronizeMethod()
+			continue
+		}
+		methods = append(methods, method.(*FunctionStmt))
+	}
+
+	if !p.match(RightBrace) {
+		return nil, p.getError("Expect '}' after class body.")
+	}
+
+	return &ClassStmt{
+		name:       className,
+		superclass: superclass,
+		methods:    methods,
+		span:       p.spanFrom(start),
+	}, nil
+}
+
+// funDecl -> "fun" function
+// function -> IDENTIFIER "(" parameters? ")" block
+func (p *Parser) funcDeclaration(kind string) (Stmt, error) {
+	// methods have no "fun" keyword
+	start := p.Tokens[p.Current]
+	if kind == "function" {
+		start = p.previous()
+	}
+
+	if !p.match(Identifier) {
+		return nil, p.getError("Expect %s name.", kind)
+	}
+	funcName := p.previous()
+
+	if !p.match(LeftParen) {
+		return nil, p.getError("Expect '(' after %s name.", kind)
+	}
+
+	params, funcBody, err := p.functionRest(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FunctionStmt{
+		name:       funcName,
+		parameters: params,
+		body:       funcBody,
+		span:       p.spanFrom(start),
+	}, nil
+}
+
+// functionRest parses the parameters and the body of a function, after the opening parenthesis
+func (p *Parser) functionRest(kind string) (params []Token, body []Stmt, err error) {
+	params, err = p.parameters()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !p.match(LeftBrace) {
+		return nil, nil, p.getError("Expect '{' before %s body.", kind)
+	}
+
+	// break and continue can't reach the loops around a function
+	enclosingLoops := p.loops
+	p.loops = nil
+	body, err = p.block()
+	p.loops = enclosingLoops
+	if err != nil {
+		return nil, nil, err
+	}
+	return params, body, nil
+}
+
+// parameters -> IDENTIFIER ( "," IDENTIFIER )* ")"
+func (p *Parser) parameters() ([]Token, error) {
+	var params []Token
+	if p.Tokens[p.Current].Type != RightParen {
+		if !p.match(Identifier) {
+			return nil, p.getError("Expect parameter name.")
+		}
+
+		params = []Token{p.previous()}
+		for p.match(Comma) {
+			if !p.match(Identifier) {
+				return nil, p.getError("Expect parameter name.")
+			}
+			params = append(params, p.previous())
+
+			if len(params) > 255 {
+				p.reported = append(p.reported, p.getError("Can't have more than 255 parameters."))
+			}
+		}
+	}
+
+	if !p.match(RightParen) {
+		return nil, p.getError("Expect ')' after parameters.")
+	}
+	return params, nil
+}
+
+func (p *Parser) varDeclaration() (Stmt, error) {
+	start := p.previous()
+	if !p.match(Identifier) {
+		return nil, p.getError("Expect variable name.")
+	}
+	variableName := p.previous()
+	var initializer Expr
+	// optional initializer expr after '=' sign
+	if p.match(Equal) {
+		var err error
+		initializer, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !p.match(Semicolon) {
+		return nil, p.getError("Expect ';' after variable declaration.")
+	}
+
+	return &VarStmt{varName: variableName, initializerExpression: initializer, span: p.spanFrom(start)}, nil
+}
+
+// exportDecl -> "export" (classDecl | funDecl | varDecl)
+func (p *Parser) exportDeclaration() (Stmt, error) {
+	keyword := p.previous()
+	var declaration Stmt
+	var err error
+	if p.match(Class) {
+		declaration, err = p.classDeclaration()
+	} else if p.Tokens[p.Current].Type == Function && p.Tokens[p.Current+1].Type == Identifier {
+		p.Current++
+		declaration, err = p.funcDeclaration("function")
+	} else if p.match(Var) {
+		declaration, err = p.varDeclaration()
+	} else {
+		return nil, p.getError("Expect class, function or variable declaration after 'export'.")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ExportStmt{keyword: keyword, declaration: declaration, span: p.spanFrom(keyword)}, nil
+}
+
+// importDecl -> "import" STRING "as" IDENTIFIER ";"
+func (p *Parser) importDeclaration() (Stmt, error) {
+	keyword := p.previous()
+	if !p.match(String) {
+		return nil, p.getError("Expect module path after 'import'.")
+	}
+	path := p.previous()
+	if p.Tokens[p.Current].Type != Identifier || p.Tokens[p.Current].Lexeme != "as" {
+		return nil, p.getError("Expect 'as' after module path.")
+	}
+	p.Current++
+	if !p.match(Identifier) {
+		return nil, p.getError("Expect module name after 'as'.")
+	}
+	alias := p.previous()
+	if !p.match(Semicolon) {
+		return nil, p.getError("Expect ';' after import.")
+	}
+	return &ImportStmt{keyword: keyword, path: path, alias: &alias, span: p.spanFrom(keyword)}, nil
+}
+
+// fromImportDecl -> "from" STRING "import" IDENTIFIER ("," IDENTIFIER)* ";"
+func (p *Parser) fromImportDeclaration() (Stmt, error) {
+	keyword := p.previous()
+	p.Current++ // the path, checked by declaration
+	path := p.previous()
+	if !p.match(Import) {
+		return nil, p.getError("Expect 'import' after module path.")
+	}
+
+	var names []Token
+	for {
+		if !p.match(Identifier) {
+			return nil, p.getError("Expect name to import.")
+		}
+		names = append(names, p.previous())
+		if !p.match(Comma) {
+			break
+		}
+	}
+	if !p.match(Semicolon) {
+		return nil, p.getError("Expect ';' after import.")
+	}
+	return &ImportStmt{keyword: keyword, path: path, names: names, span: p.spanFrom(keyword)}, nil
+}
+
+func (p *Parser) statement() (Stmt, error) {
+	if p.match(Print) {
+		return p.printStatement()
+	}
+	if p.match(LeftBrace) {
+		start := p.previous()
+		stmts, err := p.block()
+		return &BlockStmt{statements: stmts, span: p.spanFrom(start)}, err
+	}
+	if p.match(If) {
+		return p.ifStatement()
+	}
+	if p.match(While) {
+		return p.whileStatement(nil)
+	}
+	if p.match(For) {
+		return p.forStatement(nil)
+	}
+	if p.match(Return) {
+		return p.returnStatement()
+	}
+	if p.match(Break, Continue) {
+		return p.loopControlStatement()
+	}
+	if p.match(Try) {
+		return p.tryStatement()
+	}
+	if p.match(Throw) {
+		return p.throwStatement()
+	}
+	if p.Tokens[p.Current].Type == Identifier && p.Tokens[p.Current+1].Type == Colon {
+		return p.labeledStatement()
+	}
+
+	return p.expressionStatement()
+}
+
+func (p *Parser) block() ([]Stmt, error) {
+	var stmts []Stmt
+
+	for (p.Current < len(p.Tokens)) && (p.Tokens[p.Current].Type != Eof) && (p.Tokens[p.Current].Type) != RightBrace {
+		if stmt, ok := p.declaration(); ok {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	if !p.match(RightBrace) {
+		return nil, p.getError("Expect '}' after block.")
+	}
+	return stmts, nil
+}
+
+func (p *Parser) returnStatement() (s Stmt, err error) {
+	kyw := p.previous()
+	var expr Expr
+	if p.Tokens[p.Current].Type != Semicolon {
+		expr, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !p.match(Semicolon) {
+		return nil, p.getError("Expect ';' after return value.")
+	}
+
+	return &ReturnStmt{
+		keyword: kyw,
+		value:   expr,
+		span:    p.spanFrom(kyw),
+	}, nil
+}
+
+// TryStmt -> "try" block ( "catch" "(" IDENTIFIER ")" block )? ( "finally" block )?
+// At least one of the catch and finally clauses must be present.
+func (p *Parser) tryStatement() (Stmt, error) {
+	keyword := p.previous()
+	body, err := p.clauseBlock("Expect '{' after 'try'.")
+	if err != nil {
+		return nil, err
+	}
+
+	var catchName *Token
+	var catchBody, finallyBody Stmt
+	if p.match(Catch) {
+		if !p.match(LeftParen) {
+			return nil, p.getError("Expect '(' after 'catch'.")
+		}
+		if !p.match(Identifier) {
+			return nil, p.getError("Expect error variable name.")
+		}
+		name := p.previous()
+		catchName = &name
+		if !p.match(RightParen) {
+			return nil, p.getError("Expect ')' after error variable.")
+		}
+
+		catchBody, err = p.clauseBlock("Expect '{' after catch clause.")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.match(Finally) {
+		finallyBody, err = p.clauseBlock("Expect '{' after 'finally'.")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if catchBody == nil && finallyBody == nil {
+		return nil, p.getError("Expect 'catch' or 'finally' after try block.")
+	}
+
+	return &TryStmt{
+		keyword:     keyword,
+		body:        body,
+		catchName:   catchName,
+		catchBody:   catchBody,
+		finallyBody: finallyBody,
+		span:        p.spanFrom(keyword),
+	}, nil
+}
+
+// clauseBlock parses the block of a try, catch or finally clause
+func (p *Parser) clauseBlock(missingBrace string) (Stmt, error) {
+	if !p.match(LeftBrace) {
+		return nil, p.getError(missingBrace)
+	}
+	start := p.previous()
+	stmts, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return &BlockStmt{statements: stmts, span: p.spanFrom(start)}, nil
+}
+
+// ThrowStmt -> "throw" Expr ";"
+func (p *Parser) throwStatement() (Stmt, error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(Semicolon) {
+		return nil, p.getError("Expect ';' after thrown value.")
+	}
+	return &ThrowStmt{keyword: keyword, value: value, span: p.spanFrom(keyword)}, nil
+}
+
+// LabeledStmt -> IDENTIFIER ":" (WhileStmt | ForStmt)
+func (p *Parser) labeledStatement() (Stmt, error) {
+	label := p.Tokens[p.Current]
+	p.Current += 2
+	for _, enclosing := range p.loops {
+		if enclosing == label.Lexeme {
+			p.reported = append(p.reported, p.errorAt(label, "Label '%s' is already used by an enclosing loop.", label.Lexeme))
+		}
+	}
+
+	if p.match(While) {
+		return p.whileStatement(&label)
+	}
+	if p.match(For) {
+		return p.forStatement(&label)
+	}
+	return nil, p.getError("Expect loop after label.")
+}
+
+// BreakStmt -> "break" IDENTIFIER? ";"
+// ContinueStmt -> "continue" IDENTIFIER? ";"
+func (p *Parser) loopControlStatement() (Stmt, error) {
+	keyword := p.previous()
+	var label *Token
+	if p.match(Identifier) {
+		name := p.previous()
+		label = &name
+	}
+	if !p.match(Semicolon) {
+		return nil, p.getError("Expect ';' after '%s'.", keyword.Lexeme)
+	}
+
+	// misplaced break and continue statements are reported without giving up on the statement
+	if len(p.loops) == 0 {
+		p.reported = append(p.reported, p.errorAt(keyword, "Can't use '%s' outside of a loop.", keyword.Lexeme))
+	} else if label != nil && !slices.Contains(p.loops, label.Lexeme) {
+		p.reported = append(p.reported, p.errorAt(*label, "No enclosing loop is labeled '%s'.", label.Lexeme))
+	}
+
+	if keyword.Type == Break {
+		return &BreakStmt{keyword: keyword, label: label, span: p.spanFrom(keyword)}, nil
+	}
+	return &ContinueStmt{keyword: keyword, label: label, span: p.spanFrom(keyword)}, nil
+}
+
+// loopBody parses the body of a loop, within which break and continue may target it
+func (p *Parser) loopBody(label *Token) (Stmt, error) {
+	name := ""
+	if label != nil {
+		name = label.Lexeme
+	}
+	p.loops = append(p.loops, name)
+	defer func() { p.loops = p.loops[:len(p.loops)-1] }()
+	return p.statement()
+}
+
+// ForStmt -> "for" "(" (VarDecl | ExprStmt | ";") Expr? ";" Expr? ")" statement
+func (p *Parser) forStatement(label *Token) (Stmt, error) {
+	start := p.previous()
+	if label != nil {
+		start = *label
+	}
+	if !p.match(LeftParen) {
+		return nil, p.getError("Expect '(' after 'for'.")
+	}
+
+	// each clause may be empty
+	var initialization Stmt
+	var err error
+	if p.match(Var) {
+		initialization, err = p.varDeclaration()
+	} else if !p.match(Semicolon) {
+		initialization, err = p.expressionStatement()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var condition Expr
+	if p.Tokens[p.Current].Type != Semicolon {
+		condition, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !p.match(Semicolon) {
+		return nil, p.getError("Expect ';' after for condition.")
+	}
+
+	var iteration Expr
+	if p.Tokens[p.Current].Type != RightParen {
+		iteration, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !p.match(RightParen) {
+		return nil, p.getError("Expect ')' after for header.")
+	}
+
+	body, err := p.loopBody(label)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ForStmt{
+		init:      initialization,
+		condition: condition,
+		iteration: iteration,
+		loopBody:  body,
+		label:     label,
+		span:      p.spanFrom(start),
+	}, nil
+}
+
+// WhileStmt -> "while" "(" Expr ")" statement
+func (p *Parser) whileStatement(label *Token) (Stmt, error) {
+	start := p.previous()
+	if label != nil {
+		start = *label
+	}
+	if !p.match(LeftParen) {
+		return nil, p.getError("Expect '(' after 'while'.")
+	}
+
+	cond, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.match(RightParen) {
+		return nil, p.getError("Expect ')' after while condition.")
+	}
+
+	body, err := p.loopBody(label)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WhileStmt{
+		condition: cond,
+		loopBody:  body,
+		label:     label,
+		span:      p.spanFrom(start),
+	}, nil
+}
+
+// IfStmt -> "if" "(" Expr ")" statement ("else" statement)?
+func (p *Parser) ifStatement() (Stmt, error) {
+	start := p.previous()
+	if !p.match(LeftParen) {
+		return nil, p.getError("Expect '(' after 'if'.")
+	}
+
+	cond, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.match(RightParen) {
+		return nil, p.getError("Expect ')' after if condition.")
+	}
+
+	thenStmt, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	var elseStmt Stmt
+	if p.match(Else) {
+		elseStmt, err = p.statement()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &IfStmt{
+		condition:  cond,
+		thenBranch: thenStmt,
+		elseBranch: elseStmt,
+		span:       p.spanFrom(start),
+	}, nil
+}
+
+// PrintStmt -> "print" Expr ";"
+func (p *Parser) printStatement() (Stmt, error) {
+	start := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(Semicolon) {
+		return nil, p.getError("Expect ';' after value.")
+	}
+	return &PrintStmt{expression: value, span: p.spanFrom(start)}, nil
+}
+
+// ExprStmt -> Expr ";"
+func (p *Parser) expressionStatement() (Stmt, error) {
+	start := p.Tokens[p.Current]
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(Semicolon) {
+		return nil, p.getError("Expect ';' after expression.")
+	}
+	return &ExpressionStmt{expression: value, span: p.spanFrom(start)}, nil
+}
+
+func (p *Parser) ParseExpr() (Expr, error) {
+	p.skipDocComments()
+	expr, err := p.expression()
+	if err != nil {
+		return nil, append(p.reported, err)
+	}
+	return expr, p.reported.Err()
+}
+
+// skipDocComments drops the doc comment tokens the scanner keeps for tools, which the grammar has no
+// place for
+func (p *Parser) skipDocComments() {
+	p.Tokens = slices.DeleteFunc(slices.Clone(p.Tokens), func(token Token) bool { return token.Type == DocComment })
+}
+
+// expression -> assignment
+func (p *Parser) expression() (Expr, error) {
+	return p.assignment()
+}
+
+// assignment -> ( call "." )? IDENTIFIER "=" assignment (left assoc.)
+// assignment -> call "[" expression "]" "=" assignment
+// assignment -> target ( "+=" | "-=" | "*=" | "/=" | "%=" ) assignment
+// assignment -> conditional
+func (p *Parser) assignment() (Expr, error) {
+	lvalue, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(PlusEqual, MinusEqual, StarEqual, SlashEqual, PercentEqual) {
+		operator := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		if !isAssignable(lvalue) {
+			return nil, p.errorAt(operator, "Invalid assignment target.")
+		}
+		return &CompoundExpr{target: lvalue, operator: operator, value: value, span: joinSpans(lvalue.Span(), value.Span())}, nil
+	}
+
+	if p.match(Equal) {
+		equals := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+
+		if variableExpr, ok := lvalue.(*VariableExpr); ok {
+			lvalueToken := variableExpr.variableName
+			return &AssignExpr{
+				variableName: lvalueToken,
+				assignValue:  value,
+				span:         joinSpans(lvalue.Span(), value.Span()),
+			}, nil
+		}
+
+		if getExpr, ok := lvalue.(*GetExpr); ok {
+			return &SetExpr{
+				object: getExpr.object,
+				name:   getExpr.name,
+				value:  value,
+				span:   joinSpans(lvalue.Span(), value.Span()),
+			}, nil
+		}
+
+		if indexExpr, ok := lvalue.(*IndexExpr); ok {
+			return &IndexSetExpr{
+				object:  indexExpr.object,
+				bracket: indexExpr.bracket,
+				index:   indexExpr.index,
+				value:   value,
+				span:    joinSpans(lvalue.Span(), value.Span()),
+			}, nil
+		}
+
+		return nil, p.errorAt(equals, "Invalid assignment target.")
+	}
+
+	return lvalue, nil
+}
+
+// isAssignable reports whether expr is a target of compound assignments and increments: a
+// variable, a property or a subscript
+func isAssignable(expr Expr) bool {
+	switch expr.(type) {
+	case *VariableExpr, *GetExpr, *IndexExpr:
+		return true
+	}
+	return false
+}
+
+// conditional -> logicalOr ( "?" expression ":" conditional )?
+func (p *Parser) conditional() (Expr, error) {
+	condition, err := p.logicalOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(Question) {
+		return condition, nil
+	}
+
+	thenBranch, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(Colon) {
+		return nil, p.getError("Expect ':' after then branch of conditional expression.")
+	}
+	elseBranch, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	return &ConditionalExpr{
+		condition:  condition,
+		thenBranch: thenBranch,
+		elseBranch: elseBranch,
+		span:       joinSpans(condition.Span(), elseBranch.Span()),
+	}, nil
+}
+
+// logicalOr -> logicalAnd ("or" logicalAnd)*
+func (p *Parser) logicalOr() (Expr, error) {
+	leftAnd, err := p.logicalAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(Or) {
+		orOperator := p.previous()
+		rightAnd, err := p.logicalAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		leftAnd = &LogicalExpr{
+			left:     leftAnd,
+			operator: orOperator,
+			right:    rightAnd,
+			span:     joinSpans(leftAnd.Span(), rightAnd.Span()),
+		}
+	}
+
+	return leftAnd, nil
+}
+
+// logicalAnd -> equality ("and" equality)*
+func (p *Parser) logicalAnd() (Expr, error) {
+	leftEquality, err := p.equality()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(And) {
+		andOperator := p.previous()
+		rightEquality, err := p.equality()
+		if err != nil {
+			return nil, err
+		}
+
+		leftEquality = &LogicalExpr{
+			left:     leftEquality,
+			operator: andOperator,
+			right:    rightEquality,
+			span:     joinSpans(leftEquality.Span(), rightEquality.Span()),
+		}
+	}
+
+	return leftEquality, nil
+}
+
+// equality -> comparison ( ("==" | "!=") comparison)*
+func (p *Parser) equality() (Expr, error) {
+	leftCmp, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(EqualEqual, BangEqual) {
+		op := p.previous()
+		rightCmp, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		leftCmp = &BinaryExpr{left: leftCmp, operator: op, right: rightCmp, span: joinSpans(leftCmp.Span(), rightCmp.Span())}
+	}
+	return leftCmp, nil
+}
+
+// comparison -> term ( (>|<|<=|>=) term )*
+func (p *Parser) comparison() (Expr, error) {
+	leftTerm, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(Greater, Less, GreaterEqual, LessEqual) {
+		op := p.previous()
+		rightTerm, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		leftTerm = &BinaryExpr{left: leftTerm, operator: op, right: rightTerm, span: joinSpans(leftTerm.Span(), rightTerm.Span())}
+	}
+	return leftTerm, nil
+}
+
+// term -> factor ( ("+" | "-") factor )*
+func (p *Parser) term() (Expr, error) {
+	leftFactor, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(Plus, Minus) { // this "while" loop represents the * suffix in the notation
+		op := p.previous()
+		rightFactor, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		leftFactor = &BinaryExpr{left: leftFactor, operator: op, right: rightFactor, span: joinSpans(leftFactor.Span(), rightFactor.Span())}
+	}
+	return leftFactor, nil
+}
+
+// factor -> unary ( ("*" | "/" | "%" | "~/") unary )*
+func (p *Parser) factor() (Expr, error) {
+	leftUnary, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(Star, Slash, Percent, TildeSlash) { // this "while" loop represents the * suffix in the notation
+		op := p.previous()
+		rightUnary, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		leftUnary = &BinaryExpr{left: leftUnary, operator: op, right: rightUnary, span: joinSpans(leftUnary.Span(), rightUnary.Span())}
+	}
+	return leftUnary, nil
+}
+
+// unary -> ("-" | "!") unary
+// unary -> ("++" | "--") unary
+// unary -> power
+func (p *Parser) unary() (Expr, error) {
+	if p.match(PlusPlus, MinusMinus) {
+		op := p.previous()
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if !isAssignable(target) {
+			return nil, p.errorAt(op, "Invalid assignment target.")
+		}
+		return &IncrementExpr{target: target, operator: op, prefix: true, span: joinSpans(op.Span, target.Span())}, nil
+	}
+
+	if p.match(Minus, Bang) {
+		op := p.previous()
+		nestedUnary, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{operator: op, right: nestedUnary, span: joinSpans(op.Span, nestedUnary.Span())}, nil
+	}
+
+	return p.power()
+}
+
+// power -> postfix ( "**" unary )?
+// The exponent is parsed as a unary, which makes "**" right-associative and lets it take a negated
+// exponent (2 ** -1), while a minus in front of the base applies to the whole power: -2 ** 2 is -4.
+func (p *Parser) power() (Expr, error) {
+	base, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(StarStar) {
+		return base, nil
+	}
+
+	op := p.previous()
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryExpr{left: base, operator: op, right: exponent, span: joinSpans(base.Span(), exponent.Span())}, nil
+}
+
+// postfix -> call ( "++" | "--" )?
+func (p *Parser) postfix() (Expr, error) {
+	target, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(PlusPlus, MinusMinus) {
+		return target, nil
+	}
+
+	op := p.previous()
+	if !isAssignable(target) {
+		return nil, p.errorAt(op, "Invalid assignment target.")
+	}
+	return &IncrementExpr{target: target, operator: op, prefix: false, span: joinSpans(target.Span(), op.Span)}, nil
+}
+
+// call -> primary ( "(" arguments? ")" | "." IDENTIFIER | subscript )*
+func (p *Parser) call() (Expr, error) {
+	primaryExpr, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.match(LeftParen) {
+			primaryExpr, err = p.finishCall(primaryExpr)
+			if err != nil {
+				return nil, err
+			}
+		} else if p.match(Dot) {
+			if !p.match(Identifier) {
+				return nil, p.getError("Expect property name after '.'.")
+			}
+			primaryExpr = &GetExpr{object: primaryExpr, name: p.previous(), span: joinSpans(primaryExpr.Span(), p.previous().Span)}
+		} else if p.match(LeftBracket) {
+			primaryExpr, err = p.finishIndex(primaryExpr)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			break
+		}
+	}
+
+	return primaryExpr, nil
+}
+
+// subscript -> "[" Expr "]" | "[" Expr? ":" Expr? "]"
+func (p *Parser) finishIndex(object Expr) (Expr, error) {
+	bracket := p.previous()
+	var index Expr
+	var err error
+	if p.Tokens[p.Current].Type != Colon {
+		index, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.match(Colon) {
+		var end Expr
+		if p.Tokens[p.Current].Type != RightBracket {
+			end, err = p.expression()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !p.match(RightBracket) {
+			return nil, p.getError("Expect ']' after slice.")
+		}
+		return &SliceExpr{
+			object:  object,
+			bracket: bracket,
+			start:   index,
+			end:     end,
+			span:    joinSpans(object.Span(), p.previous().Span),
+		}, nil
+	}
+
+	if !p.match(RightBracket) {
+		return nil, p.getError("Expect ']' after index.")
+	}
+	return &IndexExpr{
+		object:  object,
+		bracket: bracket,
+		index:   index,
+		span:    joinSpans(object.Span(), p.previous().Span),
+	}, nil
+}
+
+func (p *Parser) finishCall(callee Expr) (Expr, error) {
+	var args []Expr
+	if p.Tokens[p.Current].Type != RightParen {
+		firstArg, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		args = []Expr{firstArg}
+
+		for p.match(Comma) {
+			nextArg, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, nextArg)
+
+			if len(args) > 255 {
+				p.reported = append(p.reported, p.getError("Can't have more than 255 arguments."))
+			}
+		}
+	}
+
+	if !p.match(RightParen) {
+		return nil, p.getError("Expect ')' after arguments.")
+	}
+
+	return &CallExpr{
+		callee:       callee,
+		arguments:    args,
+		closingParen: p.previous(),
+		span:         joinSpans(callee.Span(), p.previous().Span),
+	}, nil
+}
+
+// primary -> NUMBER | STRING | "true" | "false" | "nil"
+// primary -> "(" expression ")"
+// primary -> IDENTIFIER (variable)
+// primary -> "this" | "super" "." IDENTIFIER
+// primary -> lambda | arrowFunction
+// primary -> interpolation
+// primary -> "[" ( expression ( "," expression )* )? "]"
+// primary -> "{" ( expression ":" expression ( "," expression ":" expression )* )? "}"
+func (p *Parser) primary() (Expr, error) {
+	if p.match(True) {
+		return &LiteralExpr{value: true, span: p.previous().Span}, nil
+	}
+	if p.match(False) {
+		return &LiteralExpr{value: false, span: p.previous().Span}, nil
+	}
+	if p.match(Nil) {
+		return &LiteralExpr{value: nil, span: p.previous().Span}, nil
+	}
+	if p.match(Number, String) {
+		return &LiteralExpr{value: p.previous().Literal, span: p.previous().Span}, nil
+	}
+
+	if p.match(Interpolation) {
+		return p.interpolation()
+	}
+
+	if p.match(Function) {
+		return p.lambda()
+	}
+
+	if p.Tokens[p.Current].Type == LeftParen && p.isArrowFunction() {
+		return p.arrowFunction()
+	}
+
+	if p.match(LeftParen) {
+		start := p.previous()
+		grouping, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if !p.match(RightParen) {
+			return nil, p.getError("Expect ')' after expression.")
+		}
+
+		return &GroupingExpr{expr: grouping, span: p.spanFrom(start)}, nil
+	}
+
+	if p.match(LeftBracket) {
+		start := p.previous()
+		var elements []Expr
+		if p.Tokens[p.Current].Type != RightBracket {
+			for {
+				element, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, element)
+				if !p.match(Comma) {
+					break
+				}
+			}
+		}
+		if !p.match(RightBracket) {
+			return nil, p.getError("Expect ']' after list elements.")
+		}
+
+		return &ListExpr{elements: elements, span: p.spanFrom(start)}, nil
+	}
+
+	// a brace at the start of a statement opens a block, see statement
+	if p.match(LeftBrace) {
+		brace := p.previous()
+		var keys, values []Expr
+		if p.Tokens[p.Current].Type != RightBrace {
+			for {
+				key, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				if !p.match(Colon) {
+					return nil, p.getError("Expect ':' after map key.")
+				}
+				value, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				keys, values = append(keys, key), append(values, value)
+				if !p.match(Comma) {
+					break
+				}
+			}
+		}
+		if !p.match(RightBrace) {
+			return nil, p.getError("Expect '}' after map entries.")
+		}
+
+		return &MapExpr{brace: brace, keys: keys, values: values, span: p.spanFrom(brace)}, nil
+	}
+
+	if p.match(This) {
+		return &ThisExpr{keyword: p.previous(), span: p.previous().Span}, nil
+	}
+
+	if p.match(Super) {
+		keyword := p.previous()
+		if !p.match(Dot) {
+			return nil, p.getError("Expect '.' after 'super'.")
+		}
+		if !p.match(Identifier) {
+			return nil, p.getError("Expect superclass method name.")
+		}
+		return &SuperExpr{keyword: keyword, method: p.previous(), span: p.spanFrom(keyword)}, nil
+	}
+
+	if p.match(Identifier) {
+		return &VariableExpr{variableName: p.previous(), span: p.previous().Span}, nil
+	}
+
+	return nil, p.getError("Expect expression.")
+}
+
+// interpolation -> ( INTERPOLATION expression "}" )+ STRING
+// The scanner splits a string with embedded expressions into the parts around them; an
+// Interpolation token has already been consumed.
+func (p *Parser) interpolation() (Expr, error) {
+	start := p.previous()
+	var parts []Expr
+	for {
+		segment := p.previous()
+		if segment.Literal != "" {
+			parts = append(parts, &LiteralExpr{value: segment.Literal, span: segment.Span})
+		}
+		if segment.Type == String {
+			break
+		}
+
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, value)
+		if !p.match(RightBrace) {
+			return nil, p.getError("Expect '}' after interpolated expression.")
+		}
+		// the scanner follows the brace with the next part of the string, unless it is unterminated
+		if !p.match(Interpolation, String) {
+			break
+		}
+	}
+	return &InterpolationExpr{parts: parts, span: p.spanFrom(start)}, nil
+}
+
+// lambda -> "fun" "(" parameters? ")" block
+func (p *Parser) lambda() (Expr, error) {
+	keyword := p.previous()
+	if !p.match(LeftParen) {
+		return nil, p.getError("Expect '(' after 'fun'.")
+	}
+
+	params, body, err := p.functionRest("function")
+	if err != nil {
+		return nil, err
+	}
+	return &LambdaExpr{keyword: keyword, parameters: params, body: body, span: p.spanFrom(keyword)}, nil
+}
+
+// isArrowFunction looks ahead from a '(' for the matching ')' followed by "=>"
+func (p *Parser) isArrowFunction() bool {
+	depth := 0
+	for i := p.Current; p.Tokens[i].Type != Eof; i++ {
+		switch p.Tokens[i].Type {
+		case LeftParen:
+			depth++
+		case RightParen:
+			depth--
+			if depth == 0 {
+				return p.Tokens[i+1].Type == Arrow
+			}
+		}
+	}
+	return false
+}
+
+// arrowFunction -> "(" parameters? ")" "=>" expression
+// The body is a single expression whose value is returned; a '{' after the arrow starts a map.
+func (p *Parser) arrowFunction() (Expr, error) {
+	p.match(LeftParen)
+	start := p.previous()
+	params, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	p.match(Arrow)
+	arrow := p.previous()
+
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	body := []Stmt{&ReturnStmt{keyword: arrow, value: value, span: value.Span()}}
+	return &LambdaExpr{keyword: arrow, parameters: params, body: body, span: p.spanFrom(start)}, nil
+}
+
+// synchronize discards tokens up to the next statement boundary: right after a ';', before a
+// statement keyword, or before the '}' closing the enclosing block. Blocks opened in the discarded
+// tokens (e.g. the body of a malformed function) are skipped as a whole.
+func (p *Parser) synchronize() {
+	depth := 0
+	for p.Tokens[p.Current].Type != Eof {
+		switch p.Tokens[p.Current].Type {
+		case Semicolon:
+			if depth == 0 {
+				p.Current++
+				return
+			}
+		case LeftBrace:
+			depth++
+		case RightBrace:
+			if depth == 0 {
+				return
+			}
+			depth--
+			if depth == 0 {
+				p.Current++
+				return
+			}
+		case Class, Function, Var, For, If, While, Print, Return, Break, Continue, Try, Throw, Import, Export:
+			if depth == 0 {
+				return
+			}
+		}
+
+		p.Current++
+	}
+}
+
+// synchronizeMethod skips tokens after a syntax error in a class body up to the start of the next
+// method, a name followed by '(', or the '}' closing the class
+func (p *Parser) synchronizeMethod() {
+	depth := 0
+	for p.Tokens[p.Current].Type != Eof {
+		switch p.Tokens[p.Current].Type {
+		case LeftBrace:
+			depth++
+		case RightBrace:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case Identifier:
+			if depth == 0 && p.Tokens[p.Current+1].Type == LeftParen {
+				return
+			}
+		}
+		p.Current++
+	}
+}
+
+// repeats reports whether err is raised at the same token as the previous syntax error, as happens
+// when parsing resumes at a '}' that ended an expression early, e.g. after a trailing comma in a map
+func (p *Parser) repeats(err error) bool {
+	if len(p.reported) == 0 {
+		return false
+	}
+	previous, ok := p.reported[len(p.reported)-1].(*ParseError)
+	current, isParseErr := err.(*ParseError)
+	return ok && isParseErr && previous.Token.Span == current.Token.Span
+}
+
+func (p *Parser) previous() Token {
+	return p.Tokens[p.Current-1]
+}
+
+func (p *Parser) match(tokenTypes ...TokenType) bool {
+	if p.Tokens[p.Current].Type == Eof {
+		return false
+	}
+
+	for _, tokenType := range tokenTypes {
+		if p.Tokens[p.Current].Type == tokenType {
+			p.Current++
+			return true
+		}
+	}
+
+	return false
+}
+
+// spanFrom covers the source from start up to the last consumed token
+func (p *Parser) spanFrom(start Token) Span {
+	return joinSpans(start.Span, p.previous().Span)
+}
+
+func (p *Parser) getError(msg string, a ...any) error {
+	// errors are reported at the token the parser got stuck on
+	token := p.Tokens[len(p.Tokens)-1]
+	if p.Current < len(p.Tokens) {
+		token = p.Tokens[p.Current]
+	}
+
+	return p.errorAt(token, msg, a...)
+}
+
+func (p *Parser) errorAt(token Token, msg string, a ...any) error {
+	return &ParseError{Token: token, Message: fmt.Sprintf(msg, a...)}
+}
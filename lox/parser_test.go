@@ -0,0 +1,105 @@
+package lox
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParserReportsEveryError(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		// the errors, in the book's short format
+		want []string
+		// the number of statements that parsed cleanly
+		stmts int
+	}{
+		{
+			"errors on separate lines",
+			"print 1 +;\nvar = 2;\nprint 3;\nvar y = (4;\n",
+			[]string{
+				"[line 1] Error at ';': Expect expression.",
+				"[line 2] Error at '=': Expect variable name.",
+				"[line 4] Error at ';': Expect ')' after expression.",
+			},
+			1,
+		},
+		{
+			"stray closing brace before a block",
+			"}\n{ var x = ; }\nprint 1;",
+			[]string{
+				"[line 1] Error at '}': Expect expression.",
+				"[line 2] Error at ';': Expect expression.",
+			},
+			2,
+		},
+		{
+			"errors in nested blocks",
+			"fun f() {\n  if (true) { print ); }\n  return 1 1;\n}\nprint f();",
+			[]string{
+				"[line 2] Error at ')': Expect expression.",
+				"[line 3] Error at '1': Expect ';' after return value.",
+			},
+			2,
+		},
+		{
+			"malformed method",
+			"class A {\n  m( { }\n  n() { print +; }\n}\nprint +;",
+			[]string{
+				"[line 2] Error at '{': Expect parameter name.",
+				"[line 3] Error at '+': Expect expression.",
+				"[line 5] Error at '+': Expect expression.",
+			},
+			1,
+		},
+		{
+			"stray tokens in a class body",
+			"class A {\n  ;\n  m() { return 1; }\n  n(a b) { print a; }\n  o() {}\n}\nprint A().o();",
+			[]string{
+				"[line 2] Error at ';': Expect method name.",
+				"[line 4] Error at 'b': Expect ')' after parameters.",
+			},
+			2,
+		},
+		{
+			"loop control",
+			"break;\nwhile (true) { continue outer; }\nfor (;;) { break; }",
+			[]string{
+				"[line 1] Error at 'break': Can't use 'break' outside of a loop.",
+				"[line 2] Error at 'outer': No enclosing loop is labeled 'outer'.",
+			},
+			3,
+		},
+		{
+			"scan and parse errors",
+			"print @;\nvar s = \"open;",
+			[]string{
+				"[line 1] Error: Unexpected character: @",
+				"[line 2] Error: Unterminated string.",
+				"[line 1] Error at ';': Expect expression.",
+				"[line 2] Error at end: Expect expression.",
+			},
+			0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmts, err := Parse(test.source)
+			var list ErrorList
+			if !errors.As(err, &list) {
+				t.Fatalf("Parse(%q) fails with %v, want an ErrorList", test.source, err)
+			}
+			got := make([]string, len(list))
+			for i, err := range list {
+				got[i] = err.Error()
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("Parse(%q) reports\n%q\nwant\n%q", test.source, got, test.want)
+			}
+			if len(stmts) != test.stmts {
+				t.Errorf("Parse(%q) returns %d statements, want %d", test.source, len(stmts), test.stmts)
+			}
+		})
+	}
+}
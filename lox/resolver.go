@@ -0,0 +1,423 @@
+package lox
+
+type functionType int
+
+const (
+	noFunction functionType = iota
+	plainFunction
+	methodFunction
+	initializerFunction
+)
+
+type classType int
+
+const (
+	noClass classType = iota
+	plainClass
+	subclass
+)
+
+// Resolver is a static pass run between parsing and interpreting (11. Resolving and Binding).
+// It records, for every local variable access, how many environments away its binding lives,
+// and reports static errors that the parser cannot catch.
+type Resolver struct {
+	// nil when the resolver only checks for static errors, e.g. before compiling for the VM
+	interpreter *AstInterpreter
+	// each scope maps a variable name to whether its initializer has finished resolving
+	scopes          []map[string]bool
+	currentFunction functionType
+	currentClass    classType
+	Errors          ErrorList
+}
+
+func NewResolver(itp *AstInterpreter) *Resolver {
+	return &Resolver{interpreter: itp}
+}
+
+func (r *Resolver) Resolve(stmts []Stmt) {
+	for _, stmt := range stmts {
+		r.resolveStmt(stmt)
+	}
+}
+
+func (r *Resolver) resolveStmt(stmt Stmt) {
+	if stmt != nil {
+		stmt.Accept(r)
+	}
+}
+
+func (r *Resolver) resolveExpr(expr Expr) {
+	if expr != nil {
+		expr.Accept(r)
+	}
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, make(map[string]bool))
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *Resolver) declare(name Token) {
+	if len(r.scopes) == 0 {
+		// globals are late bound, so they are not tracked
+		return
+	}
+
+	scope := r.scopes[len(r.scopes)-1]
+	if _, ok := scope[name.Lexeme]; ok {
+		r.error(name, "Already a variable with this name in this scope.")
+	}
+	scope[name.Lexeme] = false
+}
+
+func (r *Resolver) define(name Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	r.scopes[len(r.scopes)-1][name.Lexeme] = true
+}
+
+// resolveLocal records the number of scopes between the innermost one and the one declaring name;
+// variables not found in any scope are assumed to be global
+func (r *Resolver) resolveLocal(expr Expr, name Token) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name.Lexeme]; ok {
+			if r.interpreter != nil {
+				r.interpreter.resolve(expr, len(r.scopes)-1-i)
+			}
+			return
+		}
+	}
+}
+
+func (r *Resolver) resolveFunction(parameters []Token, body []Stmt, kind functionType) {
+	enclosingFunction := r.currentFunction
+	r.currentFunction = kind
+	defer func() { r.currentFunction = enclosingFunction }()
+
+	// parameters and body share one scope, mirroring the environment created by LoxFunction.Call
+	r.beginScope()
+	for _, param := range parameters {
+		r.declare(param)
+		r.define(param)
+	}
+	r.Resolve(body)
+	r.endScope()
+}
+
+func (r *Resolver) error(tok Token, msg string) {
+	r.Errors = append(r.Errors, &ParseError{Token: tok, Message: msg})
+}
+
+func (r *Resolver) VisitBlockStmt(s *BlockStmt) (result interface{}, err error) {
+	r.beginScope()
+	r.Resolve(s.statements)
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitClassStmt(s *ClassStmt) (result interface{}, err error) {
+	enclosingClass := r.currentClass
+	r.currentClass = plainClass
+	defer func() { r.currentClass = enclosingClass }()
+
+	r.declare(s.name)
+	r.define(s.name)
+
+	if s.superclass != nil {
+		if s.superclass.variableName.Lexeme == s.name.Lexeme {
+			r.error(s.superclass.variableName, "A class can't inherit from itself.")
+		}
+		r.currentClass = subclass
+		r.resolveExpr(s.superclass)
+
+		r.beginScope()
+		r.scopes[len(r.scopes)-1]["super"] = true
+	}
+
+	r.beginScope()
+	r.scopes[len(r.scopes)-1]["this"] = true
+
+	for _, method := range s.methods {
+		kind := methodFunction
+		if method.name.Lexeme == "init" {
+			kind = initializerFunction
+		}
+		r.resolveFunction(method.parameters, method.body, kind)
+	}
+
+	r.endScope()
+	if s.superclass != nil {
+		r.endScope()
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitVarStmt(s *VarStmt) (result interface{}, err error) {
+	r.declare(s.varName)
+	r.resolveExpr(s.initializerExpression)
+	r.define(s.varName)
+	return nil, nil
+}
+
+func (r *Resolver) VisitFunctionStmt(s *FunctionStmt) (result interface{}, err error) {
+	// define eagerly so the function can refer to itself recursively
+	r.declare(s.name)
+	r.define(s.name)
+	r.resolveFunction(s.parameters, s.body, plainFunction)
+	return nil, nil
+}
+
+func (r *Resolver) VisitExpressionStmt(s *ExpressionStmt) (result interface{}, err error) {
+	r.resolveExpr(s.expression)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIfStmt(s *IfStmt) (result interface{}, err error) {
+	r.resolveExpr(s.condition)
+	r.resolveStmt(s.thenBranch)
+	r.resolveStmt(s.elseBranch)
+	return nil, nil
+}
+
+func (r *Resolver) VisitPrintStmt(s *PrintStmt) (result interface{}, err error) {
+	r.resolveExpr(s.expression)
+	return nil, nil
+}
+
+func (r *Resolver) VisitReturnStmt(s *ReturnStmt) (result interface{}, err error) {
+	if r.currentFunction == noFunction {
+		r.error(s.keyword, "Can't return from top-level code.")
+	}
+
+	if s.value != nil {
+		if r.currentFunction == initializerFunction {
+			r.error(s.keyword, "Can't return a value from an initializer.")
+		}
+		r.resolveExpr(s.value)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitImportStmt(s *ImportStmt) (result interface{}, err error) {
+	if len(r.scopes) > 0 {
+		r.error(s.keyword, "Can only import at the top level of a file.")
+	}
+
+	names := s.names
+	if s.alias != nil {
+		names = []Token{*s.alias}
+	}
+	for _, name := range names {
+		r.declare(name)
+		r.define(name)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitExportStmt(s *ExportStmt) (result interface{}, err error) {
+	if len(r.scopes) > 0 {
+		r.error(s.keyword, "Can only export top-level declarations.")
+	}
+	r.resolveStmt(s.declaration)
+	return nil, nil
+}
+
+func (r *Resolver) VisitThrowStmt(s *ThrowStmt) (result interface{}, err error) {
+	r.resolveExpr(s.value)
+	return nil, nil
+}
+
+func (r *Resolver) VisitTryStmt(s *TryStmt) (result interface{}, err error) {
+	r.resolveStmt(s.body)
+	if s.catchBody != nil {
+		// the error variable gets a scope of its own around the catch block
+		r.beginScope()
+		r.declare(*s.catchName)
+		r.define(*s.catchName)
+		r.resolveStmt(s.catchBody)
+		r.endScope()
+	}
+	r.resolveStmt(s.finallyBody)
+	return nil, nil
+}
+
+func (r *Resolver) VisitBreakStmt(s *BreakStmt) (result interface{}, err error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitContinueStmt(s *ContinueStmt) (result interface{}, err error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitWhileStmt(s *WhileStmt) (result interface{}, err error) {
+	r.resolveExpr(s.condition)
+	r.resolveStmt(s.loopBody)
+	return nil, nil
+}
+
+func (r *Resolver) VisitForStmt(s *ForStmt) (result interface{}, err error) {
+	// the for header gets its own scope, mirroring AstInterpreter.VisitForStmt
+	r.beginScope()
+	r.resolveStmt(s.init)
+	r.resolveExpr(s.condition)
+	r.resolveExpr(s.iteration)
+	r.resolveStmt(s.loopBody)
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitVariableExpr(e *VariableExpr) (result interface{}, err error) {
+	if len(r.scopes) > 0 {
+		if defined, ok := r.scopes[len(r.scopes)-1][e.variableName.Lexeme]; ok && !defined {
+			r.error(e.variableName, "Can't read local variable in its own initializer.")
+		}
+	}
+
+	r.resolveLocal(e, e.variableName)
+	return nil, nil
+}
+
+func (r *Resolver) VisitAssignExpr(e *AssignExpr) (result interface{}, err error) {
+	r.resolveExpr(e.assignValue)
+	r.resolveLocal(e, e.variableName)
+	return nil, nil
+}
+
+func (r *Resolver) VisitBinaryExpr(e *BinaryExpr) (result interface{}, err error) {
+	r.resolveExpr(e.left)
+	r.resolveExpr(e.right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitCallExpr(e *CallExpr) (result interface{}, err error) {
+	r.resolveExpr(e.callee)
+	for _, arg := range e.arguments {
+		r.resolveExpr(arg)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitGetExpr(e *GetExpr) (result interface{}, err error) {
+	// properties are looked up dynamically, only the object expression is resolved
+	r.resolveExpr(e.object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSetExpr(e *SetExpr) (result interface{}, err error) {
+	r.resolveExpr(e.value)
+	r.resolveExpr(e.object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitLambdaExpr(e *LambdaExpr) (result interface{}, err error) {
+	r.resolveFunction(e.parameters, e.body, plainFunction)
+	return nil, nil
+}
+
+func (r *Resolver) VisitListExpr(e *ListExpr) (result interface{}, err error) {
+	for _, element := range e.elements {
+		r.resolveExpr(element)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitInterpolationExpr(e *InterpolationExpr) (result interface{}, err error) {
+	for _, part := range e.parts {
+		r.resolveExpr(part)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitCompoundExpr(e *CompoundExpr) (result interface{}, err error) {
+	r.resolveExpr(e.target)
+	r.resolveExpr(e.value)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIncrementExpr(e *IncrementExpr) (result interface{}, err error) {
+	r.resolveExpr(e.target)
+	return nil, nil
+}
+
+func (r *Resolver) VisitConditionalExpr(e *ConditionalExpr) (result interface{}, err error) {
+	r.resolveExpr(e.condition)
+	r.resolveExpr(e.thenBranch)
+	r.resolveExpr(e.elseBranch)
+	return nil, nil
+}
+
+func (r *Resolver) VisitMapExpr(e *MapExpr) (result interface{}, err error) {
+	for i, key := range e.keys {
+		r.resolveExpr(key)
+		r.resolveExpr(e.values[i])
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexExpr(e *IndexExpr) (result interface{}, err error) {
+	r.resolveExpr(e.object)
+	r.resolveExpr(e.index)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexSetExpr(e *IndexSetExpr) (result interface{}, err error) {
+	r.resolveExpr(e.object)
+	r.resolveExpr(e.index)
+	r.resolveExpr(e.value)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSliceExpr(e *SliceExpr) (result interface{}, err error) {
+	r.resolveExpr(e.object)
+	r.resolveExpr(e.start)
+	r.resolveExpr(e.end)
+	return nil, nil
+}
+
+func (r *Resolver) VisitThisExpr(e *ThisExpr) (result interface{}, err error) {
+	if r.currentClass == noClass {
+		r.error(e.keyword, "Can't use 'this' outside of a class.")
+		return nil, nil
+	}
+
+	r.resolveLocal(e, e.keyword)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSuperExpr(e *SuperExpr) (result interface{}, err error) {
+	if r.currentClass == noClass {
+		r.error(e.keyword, "Can't use 'super' outside of a class.")
+	} else if r.currentClass != subclass {
+		r.error(e.keyword, "Can't use 'super' in a class with no superclass.")
+	}
+
+	r.resolveLocal(e, e.keyword)
+	return nil, nil
+}
+
+func (r *Resolver) VisitGroupingExpr(e *GroupingExpr) (result interface{}, err error) {
+	r.resolveExpr(e.expr)
+	return nil, nil
+}
+
+func (r *Resolver) VisitLiteralExpr(e *LiteralExpr) (result interface{}, err error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitLogicalExpr(e *LogicalExpr) (result interface{}, err error) {
+	r.resolveExpr(e.left)
+	r.resolveExpr(e.right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitUnaryExpr(e *UnaryExpr) (result interface{}, err error) {
+	r.resolveExpr(e.right)
+	return nil, nil
+}
+
+var _ ExprVisitor = (*Resolver)(nil)
+var _ StmtVisitor = (*Resolver)(nil)
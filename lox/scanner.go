@@ -0,0 +1,616 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Scanner struct {
+	Source      []rune
+	Tokens      []Token
+	Start       int
+	Current     int
+	CurrentLine int
+	// lexical errors; scanning goes on after each one so all of them are reported
+	Errors ErrorList
+
+	// index of the first rune of the current line, and the line and column the current lexeme starts at
+	lineStart   int
+	startLine   int
+	startColumn int
+	// byteOffsets[i] is the byte offset of rune i in the source, with an extra entry for the end
+	byteOffsets []int
+	// for every string interpolation whose embedded expression is being scanned, innermost last, the
+	// number of braces opened inside the expression and not closed yet
+	interpolations []int
+	// comments scanned since the last token, see Token.Comments
+	comments []Comment
+}
+
+// NewScanner decodes source for scanning. A leading byte order mark is skipped and bytes that
+// aren't valid UTF-8 are reported as errors; token spans still count bytes of source.
+func NewScanner(source string) *Scanner {
+	s := &Scanner{}
+	offset := 0
+	if strings.HasPrefix(source, byteOrderMark) {
+		offset = len(byteOrderMark)
+	}
+
+	line, lineStart := 0, 0
+	for offset < len(source) {
+		r, size := utf8.DecodeRuneInString(source[offset:])
+		if r == utf8.RuneError && size == 1 {
+			// a run of invalid bytes is reported once
+			last := len(s.Errors) - 1
+			if last >= 0 && s.Errors[last].(*ScanError).Span.End == offset {
+				s.Errors[last].(*ScanError).Span.End++
+			} else {
+				s.Errors = append(s.Errors, &ScanError{
+					Line:    line + 1,
+					Column:  len(s.Source) - lineStart + 1,
+					Span:    Span{Start: offset, End: offset + 1},
+					Message: "Invalid UTF-8 encoding.",
+				})
+			}
+		}
+
+		s.Source = append(s.Source, r)
+		s.byteOffsets = append(s.byteOffsets, offset)
+		offset += size
+		if r == '\n' {
+			line++
+			lineStart = len(s.Source)
+		}
+	}
+	s.byteOffsets = append(s.byteOffsets, offset)
+	return s
+}
+
+const byteOrderMark = "\uFEFF"
+
+func (s *Scanner) ScanTokens() []Token {
+	// a Scanner built from runes rather than by NewScanner spans their UTF-8 encoding
+	if s.byteOffsets == nil {
+		s.byteOffsets = make([]int, len(s.Source)+1)
+		for i, r := range s.Source {
+			s.byteOffsets[i+1] = s.byteOffsets[i] + utf8.RuneLen(r)
+		}
+	}
+
+	// a "#!" first line makes a script executable, e.g. "#!/usr/bin/env -S lox run"
+	if s.peek(0) == '#' && s.peek(1) == '!' {
+		for !s.isAtEnd() && s.Source[s.Current] != '\n' {
+			s.Current++
+		}
+	}
+
+	for !s.isAtEnd() {
+		s.startLexeme()
+		s.scan()
+	}
+	s.startLexeme()
+	s.Tokens = append(s.Tokens, Token{
+		Lexeme:   "",
+		Literal:  nil,
+		Line:     s.CurrentLine,
+		Column:   s.startColumn,
+		Span:     s.span(),
+		Type:     Eof,
+		Comments: s.comments,
+	})
+	// encoding errors were found before scanning started
+	slices.SortStableFunc(s.Errors, func(a, b error) int {
+		return a.(*ScanError).Span.Start - b.(*ScanError).Span.Start
+	})
+
+	return s.Tokens
+}
+
+func (s *Scanner) scan() {
+	nextRune := s.advance()
+	switch nextRune {
+	case '(':
+		s.addToken(LeftParen)
+	case ')':
+		s.addToken(RightParen)
+	case '{':
+		if n := len(s.interpolations); n > 0 {
+			s.interpolations[n-1]++
+		}
+		s.addToken(LeftBrace)
+	case '}':
+		n := len(s.interpolations)
+		if n == 0 || s.interpolations[n-1] > 0 {
+			if n > 0 {
+				s.interpolations[n-1]--
+			}
+			s.addToken(RightBrace)
+			break
+		}
+		// the end of an embedded expression: the rest of the string is a lexeme of its own
+		s.interpolations = s.interpolations[:n-1]
+		s.addToken(RightBrace)
+		s.startLexeme()
+		s.string()
+	case '[':
+		s.addToken(LeftBracket)
+	case ']':
+		s.addToken(RightBracket)
+	case ',':
+		s.addToken(Comma)
+	case '.':
+		s.addToken(Dot)
+	case '-':
+		if s.match('-') {
+			s.addToken(MinusMinus)
+		} else if s.match('=') {
+			s.addToken(MinusEqual)
+		} else {
+			s.addToken(Minus)
+		}
+	case '+':
+		if s.match('+') {
+			s.addToken(PlusPlus)
+		} else if s.match('=') {
+			s.addToken(PlusEqual)
+		} else {
+			s.addToken(Plus)
+		}
+	case ';':
+		s.addToken(Semicolon)
+	case ':':
+		s.addToken(Colon)
+	case '?':
+		s.addToken(Question)
+	case '*':
+		if s.match('*') {
+			s.addToken(StarStar)
+		} else if s.match('=') {
+			s.addToken(StarEqual)
+		} else {
+			s.addToken(Star)
+		}
+	case '%':
+		if s.match('=') {
+			s.addToken(PercentEqual)
+		} else {
+			s.addToken(Percent)
+		}
+	case '~':
+		// "//" starts a comment, so integer division is spelled "~/"
+		if s.match('/') {
+			s.addToken(TildeSlash)
+		} else {
+			s.logError("Unexpected character: %c", nextRune)
+		}
+	case '=':
+		if s.match('=') {
+			s.addToken(EqualEqual)
+		} else if s.match('>') {
+			s.addToken(Arrow)
+		} else {
+			s.addToken(Equal)
+		}
+	case '!':
+		if s.match('=') {
+			s.addToken(BangEqual)
+		} else {
+			s.addToken(Bang)
+		}
+	case '<':
+		if s.match('=') {
+			s.addToken(LessEqual)
+		} else {
+			s.addToken(Less)
+		}
+	case '>':
+		if s.match('=') {
+			s.addToken(GreaterEqual)
+		} else {
+			s.addToken(Greater)
+		}
+	case '/':
+		if s.match('/') {
+			s.lineComment()
+		} else if s.match('*') {
+			s.blockComment()
+		} else if s.match('=') {
+			s.addToken(SlashEqual)
+		} else {
+			s.addToken(Slash)
+		}
+	case '"':
+		s.string()
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		s.number()
+	case ' ', '\t', '\r':
+		// noop
+	case '\n':
+		s.newLine()
+	default:
+		switch {
+		case isIdentifierStart(nextRune):
+			s.identifier()
+		case nextRune == utf8.RuneError && s.byteOffsets[s.Current]-s.byteOffsets[s.Start] == 1:
+			// an invalid byte, reported by NewScanner
+		default:
+			s.logError("Unexpected character: %c", nextRune)
+		}
+	}
+}
+
+func (s *Scanner) addTokenWithLiteral(tokenType TokenType, literal interface{}) {
+	token := Token{
+		Lexeme:   string(s.Source[s.Start:s.Current]),
+		Literal:  literal,
+		Line:     s.startLine,
+		Column:   s.startColumn,
+		Span:     s.span(),
+		Type:     tokenType,
+		Comments: s.comments,
+	}
+	s.comments = nil
+	s.Tokens = append(s.Tokens, token)
+}
+
+// addComment keeps the current lexeme as a comment for the next token
+func (s *Scanner) addComment() {
+	ownLine := true
+	for i := s.Start - 1; i >= 0 && s.Source[i] != '\n'; i-- {
+		if s.Source[i] != ' ' && s.Source[i] != '\t' && s.Source[i] != '\r' {
+			ownLine = false
+			break
+		}
+	}
+	s.comments = append(s.comments, Comment{
+		Text:    strings.TrimRight(string(s.Source[s.Start:s.Current]), "\r"),
+		Span:    s.span(),
+		OwnLine: ownLine,
+	})
+}
+
+func (s *Scanner) addToken(tokenType TokenType) {
+	s.addTokenWithLiteral(tokenType, nil)
+}
+
+// startLexeme makes the next rune the start of the current lexeme
+func (s *Scanner) startLexeme() {
+	s.Start = s.Current
+	s.startLine = s.CurrentLine
+	s.startColumn = s.Current - s.lineStart + 1
+}
+
+func (s *Scanner) advance() rune {
+	s.Current++
+	return s.Source[s.Current-1]
+}
+
+// peek returns the rune offset runes after the current one, or 0 past the end of the source
+func (s *Scanner) peek(offset int) rune {
+	if s.Current+offset >= len(s.Source) {
+		return 0
+	}
+	return s.Source[s.Current+offset]
+}
+
+func (s *Scanner) match(expected rune) bool {
+	if s.isAtEnd() || (s.Source[s.Current] != expected) {
+		return false
+	}
+
+	s.Current++
+	return true
+}
+
+// string scans a string literal, or the part of one up to an embedded expression "${...}" or after
+// it. A string with embedded expressions becomes an Interpolation token for every part followed by
+// an expression, then the expression's tokens and the '}' closing it, and a String token for the
+// part after the last expression.
+func (s *Scanner) string() {
+	var value strings.Builder
+	for !s.isAtEnd() && s.Source[s.Current] != '"' {
+		r := s.advance()
+		switch {
+		case r == '\r' && s.peek(0) == '\n':
+			// CRLF line endings read as '\n'
+		case r == '\n':
+			s.newLine()
+			value.WriteRune(r)
+		case r == '\\':
+			s.escape(&value)
+		case r == '$' && s.match('{'):
+			s.addTokenWithLiteral(Interpolation, value.String())
+			s.interpolations = append(s.interpolations, 0)
+			return
+		default:
+			value.WriteRune(r)
+		}
+	}
+
+	if s.isAtEnd() {
+		s.logError("Unterminated string.")
+		return
+	}
+	s.Current++
+	s.addTokenWithLiteral(String, value.String())
+}
+
+// escapes maps the character following a backslash to the character the escape sequence stands for
+var escapes = map[rune]rune{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'"':  '"',
+	'\\': '\\',
+	'$':  '$',
+}
+
+// escape decodes the escape sequence following a backslash into value. Invalid sequences are reported
+// and left out, and scanning goes on with the rest of the string.
+func (s *Scanner) escape(value *strings.Builder) {
+	start := s.Current - 1
+	if s.isAtEnd() {
+		return
+	}
+	if s.Source[s.Current] == '\n' {
+		s.errorAt(start, "Invalid escape sequence at end of line.")
+		return
+	}
+
+	r := s.advance()
+	if decoded, ok := escapes[r]; ok {
+		value.WriteRune(decoded)
+		return
+	}
+	if r != 'u' {
+		s.errorAt(start, "Invalid escape sequence '\\%c'.", r)
+		return
+	}
+
+	// \u{X} to \u{XXXXXX}: a Unicode code point in hexadecimal
+	if !s.match('{') {
+		s.errorAt(start, "Invalid Unicode escape sequence.")
+		return
+	}
+	digits := s.Current
+	for !s.isAtEnd() && s.Current-digits < 6 && digitValue(s.Source[s.Current]) < 16 {
+		s.Current++
+	}
+	code, err := strconv.ParseUint(string(s.Source[digits:s.Current]), 16, 32)
+	if !s.match('}') || err != nil || code > unicode.MaxRune || (code >= 0xD800 && code <= 0xDFFF) {
+		s.errorAt(start, "Invalid Unicode escape sequence.")
+		return
+	}
+	value.WriteRune(rune(code))
+}
+
+// digitValue is the value of r as a digit in bases up to 16, or 16 if r isn't such a digit
+func digitValue(r rune) int {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r - '0')
+	case r >= 'a' && r <= 'f':
+		return int(r-'a') + 10
+	case r >= 'A' && r <= 'F':
+		return int(r-'A') + 10
+	}
+	return 16
+}
+
+// lineComment skips a comment running to the end of the line. Exactly three slashes start a doc
+// comment, which becomes a token whose literal is the comment's text.
+func (s *Scanner) lineComment() {
+	for !s.isAtEnd() && s.Source[s.Current] != '\n' {
+		s.Current++
+	}
+
+	text := string(s.Source[s.Start:s.Current])
+	if strings.HasPrefix(text, "///") && !strings.HasPrefix(text, "////") {
+		s.addTokenWithLiteral(DocComment, strings.TrimSpace(text[3:]))
+	} else {
+		s.addComment()
+	}
+}
+
+// blockComment skips a comment up to the "*/" closing it; block comments nest
+func (s *Scanner) blockComment() {
+	depth := 1
+	for depth > 0 {
+		switch {
+		case s.isAtEnd():
+			s.logError("Unterminated block comment.")
+			return
+		case s.peek(0) == '/' && s.peek(1) == '*':
+			s.Current += 2
+			depth++
+		case s.peek(0) == '*' && s.peek(1) == '/':
+			s.Current += 2
+			depth--
+		default:
+			if s.advance() == '\n' {
+				s.newLine()
+			}
+		}
+	}
+	s.addComment()
+}
+
+// radixPrefixes are the letters following a leading 0 that introduce integers in other bases
+var radixPrefixes = map[rune]struct {
+	base int
+	name string
+}{
+	'x': {16, "hexadecimal"}, 'X': {16, "hexadecimal"},
+	'o': {8, "octal"}, 'O': {8, "octal"},
+	'b': {2, "binary"}, 'B': {2, "binary"},
+}
+
+// number scans a numeric literal: a decimal number with an optional fraction and exponent
+// (1.5e-3), or an integer in hexadecimal (0xFF), octal (0o17) or binary (0b1010). Digits may be
+// grouped with '_' separators, as in 1_000_000.
+func (s *Scanner) number() {
+	if prefix, ok := radixPrefixes[s.peek(0)]; ok && s.Source[s.Start] == '0' {
+		s.Current++
+		s.radixInteger(prefix.base, prefix.name)
+		return
+	}
+
+	s.Current = s.Start
+	valid := s.digits(10)
+	if s.peek(0) == '.' && digitValue(s.peek(1)) < 10 {
+		s.Current++
+		valid = s.digits(10) && valid
+	}
+	if e := s.peek(0); e == 'e' || e == 'E' {
+		sign := 0
+		if next := s.peek(1); next == '+' || next == '-' {
+			sign = 1
+		}
+		if digitValue(s.peek(1+sign)) < 10 {
+			s.Current += 1 + sign
+			valid = s.digits(10) && valid
+		}
+	}
+	if !valid {
+		s.logError("Digit separator '_' must be between digits.")
+	}
+
+	literal := strings.ReplaceAll(string(s.Source[s.Start:s.Current]), "_", "")
+	literalAsFloat, err := strconv.ParseFloat(literal, 64)
+	if err != nil && math.IsInf(literalAsFloat, 0) {
+		s.logError("Number literal is too large.")
+	}
+	s.addTokenWithLiteral(Number, literalAsFloat)
+}
+
+// radixInteger scans the digits of an integer literal following its base prefix
+func (s *Scanner) radixInteger(base int, name string) {
+	start := s.Current
+	valid := s.digits(base)
+	switch {
+	case s.Current == start:
+		s.logError("Expect %s digits after '%s'.", name, string(s.Source[s.Start:s.Current]))
+	case digitValue(s.peek(0)) < 10:
+		s.Current++
+		s.logError("Invalid digit '%c' in %s number.", s.Source[s.Current-1], name)
+	case !valid:
+		s.logError("Digit separator '_' must be between digits.")
+	}
+
+	digits := strings.ReplaceAll(string(s.Source[start:s.Current]), "_", "")
+	value := 0.0
+	if n, ok := new(big.Int).SetString(digits, base); ok {
+		value, _ = new(big.Float).SetInt(n).Float64()
+	}
+	s.addTokenWithLiteral(Number, value)
+}
+
+// digits consumes a run of digits in base, possibly grouped with '_' separators; it reports whether
+// there was a digit and every separator is between two digits
+func (s *Scanner) digits(base int) bool {
+	valid := true
+	previous := '_'
+	for !s.isAtEnd() && (digitValue(s.Source[s.Current]) < base || s.Source[s.Current] == '_') {
+		if s.Source[s.Current] == '_' && previous == '_' {
+			valid = false
+		}
+		previous = s.advance()
+	}
+	return valid && previous != '_'
+}
+
+func (s *Scanner) identifier() {
+	for !s.isAtEnd() && isIdentifierPart(s.Source[s.Current]) {
+		s.Current++
+	}
+	keywordTokenType, ok := ReservedKeywords[string(s.Source[s.Start:s.Current])]
+	if ok {
+		s.addToken(keywordTokenType)
+	} else {
+		s.addToken(Identifier)
+	}
+}
+
+// newLine is called right after consuming a line break
+func (s *Scanner) newLine() {
+	s.CurrentLine++
+	s.lineStart = s.Current
+}
+
+// span covers the current lexeme
+func (s *Scanner) span() Span {
+	return Span{Start: s.byteOffsets[s.Start], End: s.byteOffsets[s.Current]}
+}
+
+// logError reports an error about the current lexeme at the line and column it starts at, even when
+// it runs over several lines like an unterminated string
+func (s *Scanner) logError(msg string, a ...any) {
+	s.Errors = append(s.Errors, &ScanError{
+		Line:    s.startLine + 1,
+		Column:  s.startColumn,
+		Span:    s.span(),
+		Message: fmt.Sprintf(msg, a...),
+	})
+}
+
+// errorAt reports an error about the runes from start up to the current one, all on the current line
+func (s *Scanner) errorAt(start int, msg string, a ...any) {
+	s.Errors = append(s.Errors, &ScanError{
+		Line:    s.CurrentLine + 1,
+		Column:  start - s.lineStart + 1,
+		Span:    Span{Start: s.byteOffsets[start], End: s.byteOffsets[s.Current]},
+		Message: fmt.Sprintf(msg, a...),
+	})
+}
+
+func (s *Scanner) isAtEnd() bool {
+	return s.Current >= len(s.Source)
+}
+
+// isIdentifierStart reports whether r can start an identifier: '_' or a character with the Unicode
+// XID_Start property
+func isIdentifierStart(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+	}
+	return unicode.In(r, unicode.L, unicode.Nl, unicode.Other_ID_Start) &&
+		!unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space, notXIDStart)
+}
+
+// isIdentifierPart reports whether r can continue an identifier: a character with the Unicode
+// XID_Continue property
+func isIdentifierPart(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+	}
+	return unicode.In(r, unicode.L, unicode.Nl, unicode.Other_ID_Start, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue) &&
+		!unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space, notXIDContinue)
+}
+
+// The XID properties are ID_Start and ID_Continue, which the unicode package can derive from
+// categories, less the few characters whose NFKC normalization doesn't fit in an identifier.
+var (
+	notXIDStart = &unicode.RangeTable{R16: []unicode.Range16{
+		{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+		{Lo: 0x0e33, Hi: 0x0eb3, Stride: 0x80},
+		{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+		{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+		{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+		{Lo: 0xff9e, Hi: 0xff9f, Stride: 1},
+	}}
+	notXIDContinue = &unicode.RangeTable{R16: []unicode.Range16{
+		{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+		{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+		{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+		{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+	}}
+)
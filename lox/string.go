@@ -0,0 +1,45 @@
+package lox
+
+import (
+	"reflect"
+	"unicode/utf8"
+)
+
+// loxString lets strings be indexed and sliced like lists. Indices and lengths count runes, so
+// s[i] is the i-th character of s as a one-character string. Strings are immutable.
+type loxString string
+
+func (s loxString) Get(index interface{}) (interface{}, error) {
+	runes := []rune(string(s))
+	i, err := elementIndex(index, len(runes), "string", false)
+	if err != nil {
+		return nil, err
+	}
+	return string(runes[i]), nil
+}
+
+func (s loxString) Set(index, value interface{}) error {
+	return runtimeErrorf("Strings are immutable.")
+}
+
+// Slice copies the characters from start up to end into a new string, see LoxList.Slice
+func (s loxString) Slice(start, end interface{}) (string, error) {
+	runes := []rune(string(s))
+	from, to, err := sliceRange(start, end, len(runes))
+	if err != nil {
+		return "", err
+	}
+	return string(runes[from:to]), nil
+}
+
+// method returns the built-in method name bound to the string; ok is false if there is no such method
+func (s loxString) method(name string) (method LoxCallable, ok bool) {
+	var fn interface{}
+	switch name {
+	case "len":
+		fn = func() int { return utf8.RuneCountInString(string(s)) }
+	default:
+		return nil, false
+	}
+	return &nativeFunction{name: name, fn: reflect.ValueOf(fn)}, true
+}
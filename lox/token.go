@@ -0,0 +1,231 @@
+package lox
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type TokenType string
+
+const (
+	LeftParen    TokenType = "LEFT_PAREN"
+	RightParen             = "RIGHT_PAREN"
+	LeftBrace              = "LEFT_BRACE"
+	RightBrace             = "RIGHT_BRACE"
+	LeftBracket            = "LEFT_BRACKET"
+	RightBracket           = "RIGHT_BRACKET"
+	Comma                  = "COMMA"
+	Dot                    = "DOT"
+	Minus                  = "MINUS"
+	MinusMinus             = "MINUS_MINUS"
+	MinusEqual             = "MINUS_EQUAL"
+	Plus                   = "PLUS"
+	PlusPlus               = "PLUS_PLUS"
+	PlusEqual              = "PLUS_EQUAL"
+	Semicolon              = "SEMICOLON"
+	Colon                  = "COLON"
+	Question               = "QUESTION"
+	Star                   = "STAR"
+	StarEqual              = "STAR_EQUAL"
+	StarStar               = "STAR_STAR"
+	Percent                = "PERCENT"
+	PercentEqual           = "PERCENT_EQUAL"
+	TildeSlash             = "TILDE_SLASH"
+	Equal                  = "EQUAL"
+	EqualEqual             = "EQUAL_EQUAL"
+	Arrow                  = "ARROW"
+	Bang                   = "BANG"
+	BangEqual              = "BANG_EQUAL"
+	Less                   = "LESS"
+	LessEqual              = "LESS_EQUAL"
+	Greater                = "GREATER"
+	GreaterEqual           = "GREATER_EQUAL"
+	Slash                  = "SLASH"
+	SlashEqual             = "SLASH_EQUAL"
+
+	String = "STRING"
+	// part of a string literal followed by an embedded expression
+	Interpolation = "INTERPOLATION"
+	Number        = "NUMBER"
+	Identifier    = "IDENTIFIER"
+	// a "///" comment, kept for tools such as documentation generators; the parser skips it
+	DocComment = "DOC_COMMENT"
+
+	True  = "TRUE"
+	False = "FALSE"
+	And   = "AND"
+	Or    = "OR"
+
+	Nil   = "NIL"
+	This  = "THIS"
+	Super = "SUPER"
+
+	Function = "FUN"
+	Class    = "CLASS"
+
+	If       = "IF"
+	Else     = "ELSE"
+	For      = "FOR"
+	While    = "WHILE"
+	Return   = "RETURN"
+	Break    = "BREAK"
+	Continue = "CONTINUE"
+
+	Try     = "TRY"
+	Catch   = "CATCH"
+	Finally = "FINALLY"
+	Throw   = "THROW"
+
+	Import = "IMPORT"
+	Export = "EXPORT"
+
+	Print = "PRINT"
+	Var   = "VAR"
+
+	Eof = "EOF"
+)
+
+var ReservedKeywords = map[string]TokenType{
+	"true":  True,
+	"false": False,
+	"and":   And,
+	"or":    Or,
+
+	"nil":   Nil,
+	"this":  This,
+	"super": Super,
+
+	"fun":   Function,
+	"class": Class,
+
+	"if":       If,
+	"else":     Else,
+	"for":      For,
+	"while":    While,
+	"return":   Return,
+	"break":    Break,
+	"continue": Continue,
+
+	"try":     Try,
+	"catch":   Catch,
+	"finally": Finally,
+	"throw":   Throw,
+
+	"import": Import,
+	"export": Export,
+
+	"print": Print,
+	"var":   Var,
+}
+
+// compoundOperators maps each compound assignment operator to the binary operator it applies
+var compoundOperators = map[TokenType]TokenType{
+	PlusEqual:    Plus,
+	MinusEqual:   Minus,
+	StarEqual:    Star,
+	SlashEqual:   Slash,
+	PercentEqual: Percent,
+}
+
+// Span is the half-open range [Start, End) of byte offsets a token or AST node covers in the source
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// joinSpans covers everything from the start of first to the end of last
+func joinSpans(first, last Span) Span {
+	return Span{Start: first.Start, End: last.End}
+}
+
+type Token struct {
+	Type    TokenType
+	Lexeme  string
+	Literal interface{}
+	// 0-based line the token starts on
+	Line int
+	// 1-based column (in characters) of the token's first character
+	Column int
+	Span   Span
+	// comments between the previous token and this one; the EOF token has the comments ending the source
+	Comments []Comment
+}
+
+// tokenJSON is how a token is encoded in JSON. Its line counts from 1, like its column and the lines
+// of diagnostics.
+type tokenJSON struct {
+	Type     TokenType   `json:"type"`
+	Lexeme   string      `json:"lexeme"`
+	Literal  interface{} `json:"literal"`
+	Line     int         `json:"line"`
+	Column   int         `json:"column"`
+	Span     Span        `json:"span"`
+	Comments []Comment   `json:"comments,omitempty"`
+}
+
+func (tok Token) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tokenJSON{
+		Type:     tok.Type,
+		Lexeme:   tok.Lexeme,
+		Literal:  tok.Literal,
+		Line:     tok.Line + 1,
+		Column:   tok.Column,
+		Span:     tok.Span,
+		Comments: tok.Comments,
+	})
+}
+
+func (tok *Token) UnmarshalJSON(data []byte) error {
+	var fields tokenJSON
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	// a missing position would decode as 0, which diagnostics can't point at
+	if fields.Line < 1 || fields.Column < 1 {
+		return fmt.Errorf("token at line %d, column %d: the line and column must be at least 1", fields.Line, fields.Column)
+	}
+	*tok = Token{
+		Type:     fields.Type,
+		Lexeme:   fields.Lexeme,
+		Literal:  fields.Literal,
+		Line:     fields.Line - 1,
+		Column:   fields.Column,
+		Span:     fields.Span,
+		Comments: fields.Comments,
+	}
+	return nil
+}
+
+// Comment is a comment the scanner skipped over. Comments are kept on the token after them so tools
+// that print source back, like the formatter, don't lose them.
+type Comment struct {
+	// the comment with its delimiters, e.g. "// note" or "/* note */"
+	Text string `json:"text"`
+	Span Span   `json:"span"`
+	// OwnLine is false for a comment following a token on the same line
+	OwnLine bool `json:"ownLine"`
+}
+
+func (tok Token) String() string {
+	return fmt.Sprintf("%s %s %s", tok.Type, tok.Lexeme, tok.GetLiteralAsString())
+}
+
+func (tok Token) GetLiteralAsString() string {
+	lit := "null"
+	if tok.Literal != nil {
+		switch v := tok.Literal.(type) {
+		case float64:
+			// return smallest number of digits necessary
+			lit = strconv.FormatFloat(v, 'f', -1, 64)
+			if !strings.Contains(lit, ".") {
+				lit += ".0"
+			}
+		default:
+			// Fallback for other types
+			lit = fmt.Sprintf("%v", v)
+		}
+	}
+	return lit
+}
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"strings"
+	"text/template"
+)
+
+type Grammar struct {
+	AST_DEFINITIONS []AstDefinition `json:"astDefinitions"`
+}
+
+type AstDefinition struct {
+	BASE_NAME   string       `json:"baseName"`
+	PRODUCTIONS []Production `json:"productions"`
+}
+
+type Production struct {
+	HEAD string     `json:"head"`
+	BODY []BodyItem `json:"body"`
+}
+
+type BodyItem struct {
+	TYPE string `json:"type"`
+	NAME string `json:"name"`
+	// OPTIONAL marks a child node the parser may leave out; decoding JSON rejects any other missing one
+	OPTIONAL bool `json:"optional"`
+}
+
+const codeTemplate = `
+// Code generated by ast_codegen.go; DO NOT EDIT.
+
+package {{ .PACKAGE }}
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+{{ range .AST_DEFINITIONS }}
+{{ $L_AST_DEFINITION := . }}
+{{ $L_VISITOR_INTERFACE := print $L_AST_DEFINITION.BASE_NAME "Visitor" }}
+	// define the base {{ $L_AST_DEFINITION.BASE_NAME }} (5.2.2 Metaprogramming the trees)
+	type {{ $L_AST_DEFINITION.BASE_NAME }} interface {
+		// define the abstract accept() function (5.3.3 Visitors for expressions)
+		Accept(visitor {{ $L_VISITOR_INTERFACE }}) (result interface{}, err error)
+		// Span is the part of the source the node was parsed from
+		Span() Span
+	}
+
+	// define the visitor interface (5.3.3 Visitors for expressions)
+	type {{ $L_VISITOR_INTERFACE }} interface {
+		{{ range $L_AST_DEFINITION.PRODUCTIONS }}
+		{{ $L_PRODUCTION_NAME := print .HEAD $L_AST_DEFINITION.BASE_NAME }}
+			Visit{{ $L_PRODUCTION_NAME }}(v *{{ $L_PRODUCTION_NAME }}) (result interface{}, err error)
+		{{ end }}
+	}
+
+	type Stub{{ $L_VISITOR_INTERFACE }} struct{}
+	// type assertion to ensure stub implements all
+	var _ {{ $L_VISITOR_INTERFACE }} = Stub{{ $L_VISITOR_INTERFACE }} {}
+
+	{{ range $L_AST_DEFINITION.PRODUCTIONS }}
+	{{ $L_PRODUCTION_NAME := print .HEAD $L_AST_DEFINITION.BASE_NAME }}
+		func (s Stub{{ $L_VISITOR_INTERFACE }}) Visit{{ $L_PRODUCTION_NAME }}(_ *{{ $L_PRODUCTION_NAME }}) (result interface{}, err error) {
+			return nil, errors.New("visit func for {{ $L_PRODUCTION_NAME }} is not implemented")
+		}
+	{{ end }}
+
+	{{ range $L_AST_DEFINITION.PRODUCTIONS }}
+	{{ $L_PRODUCTION_NAME := print .HEAD $L_AST_DEFINITION.BASE_NAME }}
+		// define the subtype {{ .HEAD }} (5.2.2 Metaprogramming the trees)
+		type {{ $L_PRODUCTION_NAME }} struct {
+			{{ range .BODY }}
+				{{ .NAME }} {{ .TYPE }}
+			{{ end }}
+			span Span
+		}
+
+		// each subtype implements the abstract accept() and calls the right visit method (5.3.3 Visitors for expressions)
+		func (b *{{ $L_PRODUCTION_NAME }}) Accept(visitor {{ $L_VISITOR_INTERFACE }}) (result interface{}, err error) {
+			return visitor.Visit{{ $L_PRODUCTION_NAME }}(b)
+		}
+
+		func (b *{{ $L_PRODUCTION_NAME }}) Span() Span {
+			return b.span
+		}
+
+		var _ {{ $L_AST_DEFINITION.BASE_NAME }} = (* {{ $L_PRODUCTION_NAME }} ) (nil)
+	{{ end }}
+
+{{ end }}
+`
+
+// jsonTemplate generates the JSON encoding of the AST: each node marshals to an object with its type
+// name as "type", its "span" and its fields named as in the grammar, and each base type gets a
+// decoder that picks the node type by that tag. Decoding fails on a null or missing child unless the
+// grammar marks its field optional, and on a null in a list of nodes.
+const jsonTemplate = `
+{{ range .AST_DEFINITIONS }}
+{{ $L_BASE_NAME := .BASE_NAME }}
+	// decode{{ $L_BASE_NAME }} reconstructs a {{ $L_BASE_NAME }} from its JSON object, whose "type" names the node type; null decodes to nil
+	func decode{{ $L_BASE_NAME }}(data json.RawMessage) ({{ $L_BASE_NAME }}, error) {
+		if len(data) == 0 || string(data) == "null" {
+			return nil, nil
+		}
+		var tag struct {
+			Type string ` + "`json:\"type\"`" + `
+		}
+		if err := json.Unmarshal(data, &tag); err != nil {
+			return nil, err
+		}
+
+		var node {{ $L_BASE_NAME }}
+		switch tag.Type {
+		{{ range .PRODUCTIONS }}
+		case "{{ .HEAD }}{{ $L_BASE_NAME }}":
+			node = &{{ .HEAD }}{{ $L_BASE_NAME }}{}
+		{{ end }}
+		default:
+			return nil, fmt.Errorf("unknown {{ $L_BASE_NAME }} type %q", tag.Type)
+		}
+		if err := json.Unmarshal(data, node); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+
+	// decode{{ $L_BASE_NAME }}s decodes a list of nodes, none of which may be null
+	func decode{{ $L_BASE_NAME }}s(data []json.RawMessage) ([]{{ $L_BASE_NAME }}, error) {
+		if data == nil {
+			return nil, nil
+		}
+		nodes := make([]{{ $L_BASE_NAME }}, len(data))
+		for i, item := range data {
+			node, err := decode{{ $L_BASE_NAME }}(item)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			if node == nil {
+				return nil, fmt.Errorf("element %d is null", i)
+			}
+			nodes[i] = node
+		}
+		return nodes, nil
+	}
+
+	// decode{{ $L_BASE_NAME }}As decodes a field holding a particular type of {{ $L_BASE_NAME }}
+	func decode{{ $L_BASE_NAME }}As[T {{ $L_BASE_NAME }}](data json.RawMessage) (T, error) {
+		var typed T
+		node, err := decode{{ $L_BASE_NAME }}(data)
+		if err != nil || node == nil {
+			return typed, err
+		}
+		typed, ok := node.(T)
+		if !ok {
+			return typed, fmt.Errorf("expected %T, got %T", typed, node)
+		}
+		return typed, nil
+	}
+
+	func decode{{ $L_BASE_NAME }}sAs[T {{ $L_BASE_NAME }}](data []json.RawMessage) ([]T, error) {
+		nodes, err := decode{{ $L_BASE_NAME }}s(data)
+		if err != nil || nodes == nil {
+			return nil, err
+		}
+		typed := make([]T, len(nodes))
+		for i, node := range nodes {
+			var ok bool
+			if typed[i], ok = node.(T); !ok {
+				return nil, fmt.Errorf("element %d: expected %T, got %T", i, typed[i], node)
+			}
+		}
+		return typed, nil
+	}
+
+	{{ range .PRODUCTIONS }}
+	{{ $L_PRODUCTION_NAME := print .HEAD $L_BASE_NAME }}
+		func (b *{{ $L_PRODUCTION_NAME }}) MarshalJSON() ([]byte, error) {
+			return json.Marshal(struct {
+				Type string ` + "`json:\"type\"`" + `
+				Span Span ` + "`json:\"span\"`" + `
+				{{ range .BODY }}
+					{{ exported .NAME }} {{ .TYPE }} ` + "`json:\"{{ .NAME }}\"`" + `
+				{{ end }}
+			}{"{{ $L_PRODUCTION_NAME }}", b.span, {{ range .BODY }} b.{{ .NAME }}, {{ end }}})
+		}
+
+		func (b *{{ $L_PRODUCTION_NAME }}) UnmarshalJSON(data []byte) error {
+			var fields struct {
+				Span Span ` + "`json:\"span\"`" + `
+				{{ range .BODY }}
+					{{ exported .NAME }} {{ jsonType .TYPE }} ` + "`json:\"{{ .NAME }}\"`" + `
+				{{ end }}
+			}
+			if err := json.Unmarshal(data, &fields); err != nil {
+				return fmt.Errorf("{{ $L_PRODUCTION_NAME }}: %w", err)
+			}
+
+			b.span = fields.Span
+			{{ $L_DECODED := false }}
+			{{ range .BODY }}{{ if jsonDecoder .TYPE }}{{ $L_DECODED = true }}{{ end }}{{ end }}
+			{{ if $L_DECODED }}var err error{{ end }}
+			{{ range .BODY }}
+				{{ if jsonDecoder .TYPE }}
+					if b.{{ .NAME }}, err = {{ jsonDecoder .TYPE }}(fields.{{ exported .NAME }}); err != nil {
+						return fmt.Errorf("{{ $L_PRODUCTION_NAME }}.{{ .NAME }}: %w", err)
+					}
+					{{ if and (eq (fieldKind .TYPE) "node") (not .OPTIONAL) }}
+						if b.{{ .NAME }} == nil {
+							return errors.New("{{ $L_PRODUCTION_NAME }}.{{ .NAME }} is missing")
+						}
+					{{ end }}
+				{{ else }}
+					b.{{ .NAME }} = fields.{{ exported .NAME }}
+				{{ end }}
+			{{ end }}
+			return nil
+		}
+	{{ end }}
+{{ end }}
+`
+
+// treeTemplate generates what tools need to work on the AST without a visitor per analysis: exported
+// constructors and field accessors, and Walk, Transform, Clone and EqualNodes, which know the fields of
+// each node that hold child nodes
+const treeTemplate = `
+// Node is an Expr or a Stmt
+type Node interface {
+	Span() Span
+}
+
+// Walk calls fn for node and, unless it returns false, walks each child of node in the order of its
+// fields, depth-first. Missing children are skipped.
+func Walk(node Node, fn func(Node) bool) {
+	switch n := node.(type) {
+{{ range .AST_DEFINITIONS }}
+{{ $L_BASE_NAME := .BASE_NAME }}
+	{{ range .PRODUCTIONS }}
+	case *{{ .HEAD }}{{ $L_BASE_NAME }}:
+		if n == nil || !fn(n) {
+			return
+		}
+		{{ range .BODY }}
+			{{ if eq (fieldKind .TYPE) "node" }}
+				Walk(n.{{ .NAME }}, fn)
+			{{ else if eq (fieldKind .TYPE) "nodes" }}
+				for _, child := range n.{{ .NAME }} {
+					Walk(child, fn)
+				}
+			{{ end }}
+		{{ end }}
+	{{ end }}
+{{ end }}
+	}
+}
+
+// Transform rewrites the tree under node bottom-up: the children of a node are transformed and
+// replaced by the results first, then fn is called with the node and returns its replacement, or
+// the node itself to keep it. Returning nil removes a node from a list. Nodes are changed in place,
+// so Clone a tree to keep the original. A replacement must fit where the node was, an Expr for an
+// Expr, a *FunctionStmt for a method and so on, or Transform panics.
+func Transform[T Node](node T, fn func(Node) Node) T {
+	var zero T
+	if Node(node) == nil {
+		return zero
+	}
+	return transformedAs[T](transform(node, fn), node)
+}
+
+func transform(node Node, fn func(Node) Node) Node {
+	switch n := node.(type) {
+{{ range .AST_DEFINITIONS }}
+{{ $L_BASE_NAME := .BASE_NAME }}
+	{{ range .PRODUCTIONS }}
+	case *{{ .HEAD }}{{ $L_BASE_NAME }}:
+		if n == nil {
+			return n
+		}
+		{{ range .BODY }}
+			{{ if eq (fieldKind .TYPE) "node" }}
+				n.{{ .NAME }} = Transform(n.{{ .NAME }}, fn)
+			{{ else if eq (fieldKind .TYPE) "nodes" }}
+				n.{{ .NAME }} = transformAll(n.{{ .NAME }}, fn)
+			{{ end }}
+		{{ end }}
+	{{ end }}
+{{ end }}
+	}
+	return fn(node)
+}
+
+func transformAll[T Node](nodes []T, fn func(Node) Node) []T {
+	if nodes == nil {
+		return nil
+	}
+	transformed := make([]T, 0, len(nodes))
+	for _, node := range nodes {
+		if result := transform(node, fn); result != nil {
+			transformed = append(transformed, transformedAs[T](result, node))
+		}
+	}
+	return transformed
+}
+
+// transformedAs checks that the replacement of node has the type of the field holding node
+func transformedAs[T Node](result, node Node) T {
+	typed, ok := result.(T)
+	if !ok && result != nil {
+		panic(fmt.Sprintf("Transform: %T can't replace %T", result, node))
+	}
+	return typed
+}
+
+// Clone copies the tree under node, so that either copy can be changed without affecting the other
+func Clone[T Node](node T) T {
+	clone, _ := cloneNode(node).(T)
+	return clone
+}
+
+func cloneNode(node Node) Node {
+	switch n := node.(type) {
+{{ range .AST_DEFINITIONS }}
+{{ $L_BASE_NAME := .BASE_NAME }}
+	{{ range .PRODUCTIONS }}
+	case *{{ .HEAD }}{{ $L_BASE_NAME }}:
+		if n == nil {
+			return n
+		}
+		clone := *n
+		{{ range .BODY }}
+			{{ if eq (fieldKind .TYPE) "node" }}
+				clone.{{ .NAME }} = Clone(n.{{ .NAME }})
+			{{ else if eq (fieldKind .TYPE) "nodes" }}
+				clone.{{ .NAME }} = cloneAll(n.{{ .NAME }})
+			{{ else if eq (fieldKind .TYPE) "tokens" }}
+				clone.{{ .NAME }} = slices.Clone(n.{{ .NAME }})
+			{{ else if eq (fieldKind .TYPE) "token pointer" }}
+				if n.{{ .NAME }} != nil {
+					token := *n.{{ .NAME }}
+					clone.{{ .NAME }} = &token
+				}
+			{{ end }}
+		{{ end }}
+		return &clone
+	{{ end }}
+{{ end }}
+	}
+	return node
+}
+
+func cloneAll[T Node](nodes []T) []T {
+	if nodes == nil {
+		return nil
+	}
+	clones := make([]T, len(nodes))
+	for i, node := range nodes {
+		clones[i] = Clone(node)
+	}
+	return clones
+}
+
+// EqualNodes reports whether a and b are the same tree: nodes of the same types with equal fields. Tokens
+// are compared by type, lexeme and literal, while spans, lines, columns and comments are ignored,
+// so the same program formatted differently is equal.
+func EqualNodes(a, b Node) bool {
+	switch x := a.(type) {
+{{ range .AST_DEFINITIONS }}
+{{ $L_BASE_NAME := .BASE_NAME }}
+	{{ range .PRODUCTIONS }}
+	case *{{ .HEAD }}{{ $L_BASE_NAME }}:
+		y, ok := b.(*{{ .HEAD }}{{ $L_BASE_NAME }})
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return {{ range .BODY -}}
+			{{- if eq (fieldKind .TYPE) "node" -}}
+				EqualNodes(x.{{ .NAME }}, y.{{ .NAME }}) &&
+			{{- else if eq (fieldKind .TYPE) "nodes" -}}
+				equalAll(x.{{ .NAME }}, y.{{ .NAME }}) &&
+			{{- else if eq (fieldKind .TYPE) "token" -}}
+				equalTokens(x.{{ .NAME }}, y.{{ .NAME }}) &&
+			{{- else if eq (fieldKind .TYPE) "token pointer" -}}
+				(x.{{ .NAME }} == nil) == (y.{{ .NAME }} == nil) && (x.{{ .NAME }} == nil || equalTokens(*x.{{ .NAME }}, *y.{{ .NAME }})) &&
+			{{- else if eq (fieldKind .TYPE) "tokens" -}}
+				slices.EqualFunc(x.{{ .NAME }}, y.{{ .NAME }}, equalTokens) &&
+			{{- else -}}
+				x.{{ .NAME }} == y.{{ .NAME }} &&
+			{{- end }} {{ end -}} true
+	{{ end }}
+{{ end }}
+	}
+	return a == nil && b == nil
+}
+
+func equalAll[T Node](a, b []T) bool {
+	return slices.EqualFunc(a, b, func(x, y T) bool { return EqualNodes(x, y) })
+}
+
+func equalTokens(a, b Token) bool {
+	return a.Type == b.Type && a.Lexeme == b.Lexeme && a.Literal == b.Literal
+}
+
+{{ range .AST_DEFINITIONS }}
+{{ $L_BASE_NAME := .BASE_NAME }}
+	{{ range .PRODUCTIONS }}
+	{{ $L_PRODUCTION_NAME := print .HEAD $L_BASE_NAME }}
+		// New{{ $L_PRODUCTION_NAME }} creates a {{ $L_PRODUCTION_NAME }} covering span of the source; tools building nodes that weren't parsed can pass an empty Span
+		func New{{ $L_PRODUCTION_NAME }}({{ range .BODY }}{{ .NAME }} {{ .TYPE }}, {{ end }}span Span) *{{ $L_PRODUCTION_NAME }} {
+			return &{{ $L_PRODUCTION_NAME }}{ {{ range .BODY }}{{ .NAME }}: {{ .NAME }}, {{ end }}span: span}
+		}
+
+		{{ range .BODY }}
+			func (b *{{ $L_PRODUCTION_NAME }}) {{ exported .NAME }}() {{ .TYPE }} {
+				return b.{{ .NAME }}
+			}
+		{{ end }}
+	{{ end }}
+{{ end }}
+`
+
+// fieldKind tells what a field of type goType holds, for code walking the tree: a child "node" or
+// "nodes", a "token", "tokens" or a "token pointer", or "" for anything else
+func fieldKind(grammar Grammar, goType string) string {
+	switch goType {
+	case "Token":
+		return "token"
+	case "*Token":
+		return "token pointer"
+	case "[]Token":
+		return "tokens"
+	}
+	fieldType, _ := jsonCodec(grammar, goType)
+	switch fieldType {
+	case "json.RawMessage":
+		return "node"
+	case "[]json.RawMessage":
+		return "nodes"
+	}
+	return ""
+}
+
+// jsonCodec tells how a field of type goType is decoded: into a field of type fieldType, converted
+// by the decode function, or decoded as it is when decode is empty. Nodes are decoded from raw JSON
+// since the node type is only known from the "type" tag.
+func jsonCodec(grammar Grammar, goType string) (fieldType, decode string) {
+	for _, definition := range grammar.AST_DEFINITIONS {
+		base := definition.BASE_NAME
+		switch goType {
+		case base:
+			return "json.RawMessage", "decode" + base
+		case "[]" + base:
+			return "[]json.RawMessage", "decode" + base + "s"
+		}
+		for _, production := range definition.PRODUCTIONS {
+			node := "*" + production.HEAD + base
+			switch goType {
+			case node:
+				return "json.RawMessage", "decode" + base + "As[" + node + "]"
+			case "[]" + node:
+				return "[]json.RawMessage", "decode" + base + "sAs[" + node + "]"
+			}
+		}
+	}
+	return goType, ""
+}
+
+// templateData is what the templates are executed with
+type templateData struct {
+	Grammar
+	PACKAGE string
+}
+
+func main() {
+	output := flag.String("o", "ast.generated.go", "file to write the generated code to")
+	packageName := flag.String("package", "lox", "package of the generated code")
+	check := flag.Bool("check", false, "write nothing, but exit with status 1 if the output file isn't what the grammar generates")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: ast_codegen [-o file] [-package name] [-check] grammar.json")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	grammarPath := flag.Arg(0)
+	if !token.IsIdentifier(*packageName) {
+		fmt.Fprintf(os.Stderr, "ast_codegen: invalid package name %q\n", *packageName)
+		os.Exit(2)
+	}
+
+	code, err := generate(grammarPath, *packageName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ast_codegen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *check {
+		current, err := os.ReadFile(*output)
+		if err != nil || !bytes.Equal(current, code) {
+			fmt.Fprintf(os.Stderr, "ast_codegen: %s is out of date with %s, run go generate\n", *output, grammarPath)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := os.WriteFile(*output, code, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "ast_codegen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// generate reads and validates the grammar at grammarPath, and returns the formatted code for it
+func generate(grammarPath, packageName string) ([]byte, error) {
+	grammarFileBytes, err := os.ReadFile(grammarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var grammar Grammar
+	decoder := json.NewDecoder(bytes.NewReader(grammarFileBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&grammar); err != nil {
+		return nil, fmt.Errorf("%s: %w", grammarPath, err)
+	}
+	if problems := validate(grammar); len(problems) > 0 {
+		return nil, fmt.Errorf("%s: invalid grammar:\n\t%s", grammarPath, strings.Join(problems, "\n\t"))
+	}
+
+	funcs := template.FuncMap{
+		"exported": exported,
+		"jsonType": func(goType string) string {
+			fieldType, _ := jsonCodec(grammar, goType)
+			return fieldType
+		},
+		"jsonDecoder": func(goType string) string {
+			_, decode := jsonCodec(grammar, goType)
+			return decode
+		},
+		"fieldKind": func(goType string) string { return fieldKind(grammar, goType) },
+	}
+	tmpl, err := template.New("myTempl").Funcs(funcs).Parse(codeTemplate + jsonTemplate + treeTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var codegenBuf bytes.Buffer
+	if err := tmpl.Execute(&codegenBuf, templateData{Grammar: grammar, PACKAGE: packageName}); err != nil {
+		return nil, err
+	}
+	return format.Source(codegenBuf.Bytes())
+}
+
+// exported is the name of the accessor of a field, and of the field in JSON structs
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
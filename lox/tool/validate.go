@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+)
+
+// fieldTypes are the types a field may have besides nodes and lists of nodes
+var fieldTypes = map[string]bool{
+	"Token":       true,
+	"*Token":      true,
+	"[]Token":     true,
+	"bool":        true,
+	"string":      true,
+	"float64":     true,
+	"interface{}": true,
+}
+
+// nodeMethods are the methods generated for every node, which an accessor must not clash with
+var nodeMethods = map[string]bool{
+	"Accept":        true,
+	"Span":          true,
+	"MarshalJSON":   true,
+	"UnmarshalJSON": true,
+}
+
+// validate checks that the grammar describes types the templates generate valid Go code for, and
+// returns every problem found
+func validate(grammar Grammar) (problems []string) {
+	report := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	if len(grammar.AST_DEFINITIONS) == 0 {
+		report("no AST definitions")
+	}
+	bases := make(map[string]bool)
+	nodeTypes := make(map[string]bool)
+	for i, definition := range grammar.AST_DEFINITIONS {
+		base := definition.BASE_NAME
+		switch {
+		case !token.IsIdentifier(base) || !token.IsExported(base):
+			report("definition %d: base name %q is not an exported Go identifier", i+1, base)
+		case bases[base]:
+			report("%s: defined more than once", base)
+		case len(definition.PRODUCTIONS) == 0:
+			report("%s: no productions", base)
+		}
+		bases[base] = true
+
+		for _, production := range definition.PRODUCTIONS {
+			switch name := production.HEAD + base; {
+			case !token.IsIdentifier(production.HEAD) || !token.IsExported(production.HEAD):
+				report("%s: head %q is not an exported Go identifier", base, production.HEAD)
+			case nodeTypes[name]:
+				report("%s: more than one production generates %s", base, name)
+			default:
+				nodeTypes[name] = true
+			}
+		}
+	}
+	if len(problems) > 0 {
+		// field types can't be checked against broken node types
+		return problems
+	}
+
+	for _, definition := range grammar.AST_DEFINITIONS {
+		for _, production := range definition.PRODUCTIONS {
+			node := production.HEAD + definition.BASE_NAME
+			names := make(map[string]bool)
+			for _, field := range production.BODY {
+				name := field.NAME
+				switch {
+				case !token.IsIdentifier(name):
+					report("%s: field name %q is not a Go identifier", node, name)
+				case token.IsExported(name):
+					report("%s: field %s must start with a lower-case letter, as it is exported by its accessor", node, name)
+				case types.Universe.Lookup(name) != nil:
+					report("%s: field %s would shadow the predeclared Go identifier %s", node, name, name)
+				case name == "span":
+					report("%s: field span is reserved for the span of the node", node)
+				case nodeMethods[exported(name)]:
+					report("%s: the accessor of field %s clashes with the %s method", node, name, exported(name))
+				case names[name]:
+					report("%s: field %s is defined more than once", node, name)
+				}
+				names[name] = true
+
+				if _, decode := jsonCodec(grammar, field.TYPE); decode == "" && !fieldTypes[field.TYPE] {
+					report("%s: field %s has unknown type %q", node, name, field.TYPE)
+				} else if field.OPTIONAL && fieldKind(grammar, field.TYPE) != "node" {
+					report("%s: field %s is marked optional, but only a single child node can be", node, name)
+				}
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,868 @@
+package lox
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+const maxFrames = 1 << 16
+
+// vmFunction is a compiled function body; the top-level script is a vmFunction with an empty name
+type vmFunction struct {
+	name         string
+	arity        int
+	upvalueCount int
+	chunk        Chunk
+}
+
+func (f *vmFunction) String() string {
+	if f.name == "" {
+		return "<script>"
+	}
+	return fmt.Sprintf("<fn %s>", f.name)
+}
+
+// vmUpvalue refers to a variable captured by a closure: a stack slot while the variable is
+// still live ("open"), or its own copy once the declaring scope has ended ("closed")
+type vmUpvalue struct {
+	slot   int
+	closed interface{}
+	isOpen bool
+	// next open upvalue lower on the stack
+	next *vmUpvalue
+}
+
+// vmClosure, vmClass and vmBoundMethod keep the VM they were created by, so that native functions
+// can call them back as LoxCallable
+type vmClosure struct {
+	function *vmFunction
+	upvalues []*vmUpvalue
+	vm       *VM
+	// module the closure was created in, whose globals it reads
+	module *LoxModule
+}
+
+func (c *vmClosure) Arity() int {
+	return c.function.arity
+}
+
+func (c *vmClosure) Call(_ *AstInterpreter, arguments []interface{}) (interface{}, error) {
+	return c.vm.callFromHost(c, arguments)
+}
+
+func (c *vmClosure) String() string {
+	return c.function.String()
+}
+
+type vmClass struct {
+	name    string
+	methods map[string]*vmClosure
+	vm      *VM
+}
+
+func (c *vmClass) Arity() int {
+	if initializer, ok := c.methods["init"]; ok {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+func (c *vmClass) Call(_ *AstInterpreter, arguments []interface{}) (interface{}, error) {
+	return c.vm.callFromHost(c, arguments)
+}
+
+func (c *vmClass) String() string {
+	return c.name
+}
+
+type vmInstance struct {
+	class  *vmClass
+	fields map[string]interface{}
+}
+
+func (i *vmInstance) String() string {
+	return i.class.name + " instance"
+}
+
+type vmBoundMethod struct {
+	receiver interface{}
+	method   *vmClosure
+}
+
+func (b *vmBoundMethod) Arity() int {
+	return b.method.Arity()
+}
+
+func (b *vmBoundMethod) Call(_ *AstInterpreter, arguments []interface{}) (interface{}, error) {
+	return b.method.vm.callFromHost(b, arguments)
+}
+
+func (b *vmBoundMethod) String() string {
+	return b.method.String()
+}
+
+// tryHandler is installed by a try statement: an error raised while it is active resumes execution at
+// the handler, with the stack cut back to its depth when the statement began
+type tryHandler struct {
+	// index of the frame executing the try statement
+	frame      int
+	stackDepth int
+	ip         int
+}
+
+type callFrame struct {
+	closure *vmClosure
+	ip      int
+	// index of the frame's slot zero on the value stack
+	base int
+}
+
+// VM executes the bytecode produced by the Compiler on a value stack (15. A Virtual Machine)
+type VM struct {
+	frames       []callFrame
+	stack        []interface{}
+	globals      map[string]interface{}
+	openUpvalues *vmUpvalue
+	// the module of the scripts passed to Interpret, whose globals are globals
+	main *LoxModule
+	// loads the modules of import statements, nil when imports aren't available
+	modules *moduleLoader
+	// try statements being executed, innermost last
+	handlers []tryHandler
+	stdout   io.Writer
+	// execution stops with the context's error once it is cancelled
+	ctx  context.Context
+	done <-chan struct{}
+}
+
+func NewVM(stdout io.Writer) *VM {
+	globals := builtinGlobals()
+
+	return &VM{
+		stack:   make([]interface{}, 0, 256),
+		globals: globals,
+		main:    &LoxModule{globals: &Environment{Values: globals}},
+		stdout:  stdout,
+	}
+}
+
+// Interpret runs a compiled script and returns the value it returns, which is the value of a
+// trailing expression statement (see Compiler.Compile)
+func (vm *VM) Interpret(ctx context.Context, script *vmFunction) (interface{}, error) {
+	vm.ctx, vm.done = ctx, ctx.Done()
+	defer func() { vm.ctx, vm.done = nil, nil }()
+
+	closure := &vmClosure{function: script, vm: vm, module: vm.main}
+	vm.push(closure)
+	if err := vm.call(closure, 0); err != nil {
+		return nil, err
+	}
+
+	result, err := vm.execute(0)
+	if err != nil {
+		vm.resetStack()
+	}
+	return result, err
+}
+
+// callFromHost runs callee to completion on top of the frames that are already active, e.g. when a
+// native function invokes a Lox callback. On error the stack is unwound back to where it was, since
+// the Go caller may recover and carry on.
+func (vm *VM) callFromHost(callee interface{}, arguments []interface{}) (interface{}, error) {
+	base, depth := len(vm.stack), len(vm.frames)
+	vm.push(callee)
+	for _, argument := range arguments {
+		vm.push(argument)
+	}
+
+	err := vm.callValue(callee, len(arguments))
+	if err == nil && len(vm.frames) == depth {
+		// natives and classes without an initializer have already left their result on the stack
+		return vm.pop(), nil
+	}
+
+	var result interface{}
+	if err == nil {
+		result, err = vm.execute(depth)
+	}
+	if err != nil {
+		vm.closeUpvalues(base)
+		vm.stack = vm.stack[:base]
+		vm.frames = vm.frames[:depth]
+		// a cancelled program stops without running its handlers
+		for len(vm.handlers) > 0 && vm.handlers[len(vm.handlers)-1].frame >= depth {
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// runModule executes the script of an imported module in the module's globals, on top of the frames
+// of the importing one
+func (vm *VM) runModule(script *vmFunction, module *LoxModule) error {
+	_, err := vm.callFromHost(&vmClosure{function: script, vm: vm, module: module}, nil)
+	return err
+}
+
+func (vm *VM) resetStack() {
+	vm.stack = vm.stack[:0]
+	vm.frames = vm.frames[:0]
+	vm.handlers = vm.handlers[:0]
+	vm.openUpvalues = nil
+}
+
+func (vm *VM) push(value interface{}) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *VM) pop() interface{} {
+	value := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return value
+}
+
+func (vm *VM) peek(distance int) interface{} {
+	return vm.stack[len(vm.stack)-1-distance]
+}
+
+func (vm *VM) call(closure *vmClosure, argCount int) error {
+	if argCount != closure.function.arity {
+		return runtimeErrorf("Expected %d arguments but got %d.", closure.function.arity, argCount)
+	}
+
+	if len(vm.frames) == maxFrames {
+		return runtimeErrorf("Stack overflow.")
+	}
+
+	vm.frames = append(vm.frames, callFrame{
+		closure: closure,
+		base:    len(vm.stack) - argCount - 1,
+	})
+	return nil
+}
+
+func (vm *VM) callValue(callee interface{}, argCount int) error {
+	switch callee := callee.(type) {
+	case *vmClosure:
+		return vm.call(callee, argCount)
+	case *vmBoundMethod:
+		vm.stack[len(vm.stack)-argCount-1] = callee.receiver
+		return vm.call(callee.method, argCount)
+	case *vmClass:
+		vm.stack[len(vm.stack)-argCount-1] = &vmInstance{
+			class:  callee,
+			fields: make(map[string]interface{}),
+		}
+		if initializer, ok := callee.methods["init"]; ok {
+			return vm.call(initializer, argCount)
+		}
+		if argCount != 0 {
+			return runtimeErrorf("Expected 0 arguments but got %d.", argCount)
+		}
+		return nil
+	case LoxCallable:
+		// native functions don't need an interpreter to run
+		if arity := callee.Arity(); arity >= 0 && arity != argCount {
+			return runtimeErrorf("Expected %d arguments but got %d.", arity, argCount)
+		}
+
+		args := make([]interface{}, argCount)
+		copy(args, vm.stack[len(vm.stack)-argCount:])
+		result, err := callee.Call(nil, args)
+		if err != nil {
+			return err
+		}
+
+		vm.stack = vm.stack[:len(vm.stack)-argCount-1]
+		vm.push(result)
+		return nil
+	}
+
+	return runtimeErrorf("Can only call functions and classes.")
+}
+
+func (vm *VM) captureUpvalue(slot int) *vmUpvalue {
+	var previous *vmUpvalue
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.slot > slot {
+		previous = upvalue
+		upvalue = upvalue.next
+	}
+
+	if upvalue != nil && upvalue.slot == slot {
+		return upvalue
+	}
+
+	created := &vmUpvalue{slot: slot, isOpen: true, next: upvalue}
+	if previous == nil {
+		vm.openUpvalues = created
+	} else {
+		previous.next = created
+	}
+	return created
+}
+
+// closeUpvalues moves every variable captured from slot last or above off the stack
+func (vm *VM) closeUpvalues(last int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.slot >= last {
+		upvalue := vm.openUpvalues
+		upvalue.closed = vm.stack[upvalue.slot]
+		upvalue.isOpen = false
+		vm.openUpvalues = upvalue.next
+	}
+}
+
+func (vm *VM) getUpvalue(upvalue *vmUpvalue) interface{} {
+	if upvalue.isOpen {
+		return vm.stack[upvalue.slot]
+	}
+	return upvalue.closed
+}
+
+func (vm *VM) setUpvalue(upvalue *vmUpvalue, value interface{}) {
+	if upvalue.isOpen {
+		vm.stack[upvalue.slot] = value
+	} else {
+		upvalue.closed = value
+	}
+}
+
+func (vm *VM) bindMethod(class *vmClass, name string) (interface{}, error) {
+	method, ok := class.methods[name]
+	if !ok {
+		return nil, runtimeErrorf("Undefined property '%s'.", name)
+	}
+
+	return &vmBoundMethod{receiver: vm.peek(0), method: method}, nil
+}
+
+func (vm *VM) binaryNumbers() (float64, float64, error) {
+	left, okLeft := vm.peek(1).(float64)
+	right, okRight := vm.peek(0).(float64)
+	if !(okLeft && okRight) {
+		return 0, 0, runtimeErrorf("Operands must be numbers.")
+	}
+	vm.stack = vm.stack[:len(vm.stack)-2]
+	return left, right, nil
+}
+
+// interrupted returns the context's error once it is cancelled; it is checked on every backward jump and call
+func (vm *VM) interrupted() error {
+	select {
+	case <-vm.done:
+		return vm.ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// execute runs the dispatch loop until the frame count drops back to baseDepth, which is non-zero
+// for calls made from Go (see callFromHost). Runtime errors resume at the innermost handler of the
+// frames it runs.
+func (vm *VM) execute(baseDepth int) (interface{}, error) {
+	for {
+		result, err := vm.run(baseDepth)
+		if err == nil {
+			return result, nil
+		}
+		vm.locateError(err)
+		if !vm.catch(err, baseDepth) {
+			return nil, err
+		}
+	}
+}
+
+// catch unwinds to the innermost handler and pushes err for it; it reports false when err is not a
+// runtime error or no frame from baseDepth up is executing a try statement
+func (vm *VM) catch(err error, baseDepth int) bool {
+	runtimeErr, ok := err.(*RuntimeError)
+	if !ok || len(vm.handlers) == 0 || vm.handlers[len(vm.handlers)-1].frame < baseDepth {
+		return false
+	}
+
+	handler := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	vm.closeUpvalues(handler.stackDepth)
+	vm.stack = vm.stack[:handler.stackDepth]
+	vm.frames = vm.frames[:handler.frame+1]
+	vm.frames[handler.frame].ip = handler.ip
+	vm.push(runtimeErr)
+	return true
+}
+
+// caughtValue converts an error caught by the current frame into the value of the error variable.
+// The trace of a located error runs down to the script, so it is cut at the catching frame.
+func (vm *VM) caughtValue(err *RuntimeError) interface{} {
+	if len(err.Trace) == 0 {
+		return caughtValue(err, []StackFrame{{Line: err.line}})
+	}
+	return caughtValue(err, err.Trace[:len(err.Trace)-(len(vm.frames)-1)])
+}
+
+// locateError places a runtime error at the instruction each active frame is executing. Errors raised
+// in a callback are located by the nested dispatch loop, which sees the frames of its callers as well.
+func (vm *VM) locateError(err error) {
+	runtimeErr, ok := err.(*RuntimeError)
+	if !ok || runtimeErr.line != 0 || len(vm.frames) == 0 {
+		return
+	}
+
+	for i := len(vm.frames) - 1; i >= 0; i-- {
+		frame := &vm.frames[i]
+		token := frame.closure.function.chunk.tokenAt(frame.ip - 1)
+		if i == len(vm.frames)-1 {
+			runtimeErr.Token = token
+			runtimeErr.module = frame.closure.module
+		}
+		runtimeErr.line = token.Line + 1
+		if frame.closure.function.name == "" && frame.closure.module.name != "" {
+			runtimeErr.enterModule(frame.closure.module)
+		} else {
+			runtimeErr.enterFrame(frame.closure.function.name)
+		}
+	}
+	if len(vm.frames) == 1 {
+		// not inside any function
+		runtimeErr.Trace = nil
+	}
+}
+
+func (vm *VM) run(baseDepth int) (interface{}, error) {
+	frame := &vm.frames[len(vm.frames)-1]
+	code := frame.closure.function.chunk.Code
+	constants := frame.closure.function.chunk.Constants
+	globals := frame.closure.module.globals.Values
+
+	// the high bits of the next operand, set by OpWide
+	wide := 0
+	readByte := func() byte {
+		frame.ip++
+		return code[frame.ip-1]
+	}
+	// readOperand reads a one-byte operand
+	readOperand := func() int {
+		frame.ip++
+		operand := wide<<8 | int(code[frame.ip-1])
+		wide = 0
+		return operand
+	}
+	readShort := func() int {
+		frame.ip += 2
+		operand := wide<<16 | int(code[frame.ip-2])<<8 | int(code[frame.ip-1])
+		wide = 0
+		return operand
+	}
+	readJump := func() int {
+		frame.ip += jumpSize
+		return int(binary.BigEndian.Uint32(code[frame.ip-jumpSize:]))
+	}
+	readString := func() string {
+		return constants[readShort()].(string)
+	}
+	// the active frame changes on calls and returns
+	loadFrame := func() {
+		frame = &vm.frames[len(vm.frames)-1]
+		code = frame.closure.function.chunk.Code
+		constants = frame.closure.function.chunk.Constants
+		globals = frame.closure.module.globals.Values
+	}
+
+	for {
+		switch OpCode(readByte()) {
+		case OpConstant:
+			vm.push(constants[readShort()])
+		case OpNil:
+			vm.push(nil)
+		case OpTrue:
+			vm.push(true)
+		case OpFalse:
+			vm.push(false)
+		case OpPop:
+			vm.pop()
+		case OpDup:
+			vm.push(vm.peek(readOperand()))
+		case OpRotate:
+			count := readOperand()
+			top := len(vm.stack) - 1
+			value := vm.stack[top]
+			copy(vm.stack[top-count+1:], vm.stack[top-count:top])
+			vm.stack[top-count] = value
+		case OpGetLocal:
+			vm.push(vm.stack[frame.base+readOperand()])
+		case OpSetLocal:
+			vm.stack[frame.base+readOperand()] = vm.peek(0)
+		case OpGetGlobal:
+			name := readString()
+			value, ok := globals[name]
+			if !ok {
+				return nil, runtimeErrorf("Undefined variable '%s'.", name)
+			}
+			vm.push(value)
+		case OpDefineGlobal:
+			globals[readString()] = vm.pop()
+		case OpSetGlobal:
+			name := readString()
+			if _, ok := globals[name]; !ok {
+				return nil, runtimeErrorf("Undefined variable '%s'.", name)
+			}
+			globals[name] = vm.peek(0)
+		case OpGetUpvalue:
+			vm.push(vm.getUpvalue(frame.closure.upvalues[readOperand()]))
+		case OpSetUpvalue:
+			vm.setUpvalue(frame.closure.upvalues[readOperand()], vm.peek(0))
+		case OpGetProperty:
+			if module, ok := vm.peek(0).(*LoxModule); ok {
+				value, err := module.export(readString())
+				if err != nil {
+					return nil, err
+				}
+				vm.pop()
+				vm.push(value)
+				break
+			}
+			if loxErr, ok := vm.peek(0).(*LoxError); ok {
+				name := readString()
+				value, ok := loxErr.field(name)
+				if !ok {
+					return nil, runtimeErrorf("Undefined property '%s'.", name)
+				}
+				vm.pop()
+				vm.push(value)
+				break
+			}
+			if collection, ok := asCollection(vm.peek(0)); ok {
+				name := readString()
+				method, ok := collection.method(name)
+				if !ok {
+					return nil, runtimeErrorf("Undefined property '%s'.", name)
+				}
+				vm.pop()
+				vm.push(method)
+				break
+			}
+
+			instance, ok := vm.peek(0).(*vmInstance)
+			if !ok {
+				return nil, runtimeErrorf("Only instances have properties.")
+			}
+
+			name := readString()
+			if value, ok := instance.fields[name]; ok {
+				vm.pop()
+				vm.push(value)
+				break
+			}
+
+			method, err := vm.bindMethod(instance.class, name)
+			if err != nil {
+				return nil, err
+			}
+			vm.pop()
+			vm.push(method)
+		case OpCheckFields:
+			if _, ok := vm.peek(0).(*vmInstance); !ok {
+				return nil, runtimeErrorf("Only instances have fields.")
+			}
+		case OpSetProperty:
+			instance := vm.peek(1).(*vmInstance)
+			instance.fields[readString()] = vm.peek(0)
+			value := vm.pop()
+			vm.pop()
+			vm.push(value)
+		case OpGetSuper:
+			name := readString()
+			superclass := vm.pop().(*vmClass)
+			method, err := vm.bindMethod(superclass, name)
+			if err != nil {
+				return nil, err
+			}
+			vm.pop()
+			vm.push(method)
+		case OpEqual:
+			right := vm.pop()
+			left := vm.pop()
+			vm.push(left == right)
+		case OpNotEqual:
+			right := vm.pop()
+			left := vm.pop()
+			vm.push(left != right)
+		case OpGreater:
+			left, right, err := vm.binaryNumbers()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(left > right)
+		case OpGreaterEqual:
+			left, right, err := vm.binaryNumbers()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(left >= right)
+		case OpLess:
+			left, right, err := vm.binaryNumbers()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(left < right)
+		case OpLessEqual:
+			left, right, err := vm.binaryNumbers()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(left <= right)
+		case OpAdd:
+			switch left := vm.peek(1).(type) {
+			case float64:
+				if right, ok := vm.peek(0).(float64); ok {
+					vm.stack = vm.stack[:len(vm.stack)-2]
+					vm.push(left + right)
+					continue
+				}
+			case string:
+				if right, ok := vm.peek(0).(string); ok {
+					vm.stack = vm.stack[:len(vm.stack)-2]
+					vm.push(left + right)
+					continue
+				}
+			}
+			return nil, runtimeErrorf("Operands must be two numbers or two strings.")
+		case OpSubtract:
+			left, right, err := vm.binaryNumbers()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(left - right)
+		case OpMultiply:
+			left, right, err := vm.binaryNumbers()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(left * right)
+		case OpDivide:
+			left, right, err := vm.binaryNumbers()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(left / right)
+		case OpModulo:
+			left, right, err := vm.binaryNumbers()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(modulo(left, right))
+		case OpFloorDivide:
+			left, right, err := vm.binaryNumbers()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(math.Floor(left / right))
+		case OpPower:
+			left, right, err := vm.binaryNumbers()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(math.Pow(left, right))
+		case OpNot:
+			vm.push(!isTruthy(vm.pop()))
+		case OpIncrement:
+			operand, ok := vm.peek(0).(float64)
+			if !ok {
+				return nil, runtimeErrorf("Operand must be a number.")
+			}
+			vm.stack[len(vm.stack)-1] = operand + 1
+		case OpDecrement:
+			operand, ok := vm.peek(0).(float64)
+			if !ok {
+				return nil, runtimeErrorf("Operand must be a number.")
+			}
+			vm.stack[len(vm.stack)-1] = operand - 1
+		case OpNegate:
+			operand, ok := vm.peek(0).(float64)
+			if !ok {
+				return nil, runtimeErrorf("Operand must be a number.")
+			}
+			vm.stack[len(vm.stack)-1] = -operand
+		case OpPrint:
+			fmt.Fprintln(vm.stdout, loxStringify(vm.pop()))
+		case OpJump:
+			offset := readJump()
+			frame.ip += offset
+		case OpJumpIfFalse:
+			offset := readJump()
+			if !isTruthy(vm.peek(0)) {
+				frame.ip += offset
+			}
+		case OpLoop:
+			offset := readJump()
+			frame.ip -= offset
+			if err := vm.interrupted(); err != nil {
+				return nil, err
+			}
+		case OpCall:
+			argCount := readOperand()
+			if err := vm.interrupted(); err != nil {
+				return nil, err
+			}
+			if err := vm.callValue(vm.peek(argCount), argCount); err != nil {
+				return nil, err
+			}
+			loadFrame()
+		case OpClosure:
+			function := constants[readShort()].(*vmFunction)
+			closure := &vmClosure{
+				function: function,
+				upvalues: make([]*vmUpvalue, function.upvalueCount),
+				vm:       vm,
+				module:   frame.closure.module,
+			}
+			for i := range closure.upvalues {
+				isLocal := readByte() == 1
+				index := int(readByte())<<16 | readShort()
+				if isLocal {
+					closure.upvalues[i] = vm.captureUpvalue(frame.base + index)
+				} else {
+					closure.upvalues[i] = frame.closure.upvalues[index]
+				}
+			}
+			vm.push(closure)
+		case OpCloseUpvalue:
+			vm.closeUpvalues(len(vm.stack) - 1)
+			vm.pop()
+		case OpReturn:
+			result := vm.pop()
+			vm.closeUpvalues(frame.base)
+			vm.stack = vm.stack[:frame.base]
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			if len(vm.frames) == baseDepth {
+				return result, nil
+			}
+
+			vm.push(result)
+			loadFrame()
+		case OpClass:
+			vm.push(&vmClass{name: readString(), methods: make(map[string]*vmClosure), vm: vm})
+		case OpInherit:
+			superclass, ok := vm.peek(1).(*vmClass)
+			if !ok {
+				return nil, runtimeErrorf("Superclass must be a class.")
+			}
+			subclass := vm.peek(0).(*vmClass)
+			// copy-down inheritance: classes are closed once declared, so this equals a lookup chain
+			for name, method := range superclass.methods {
+				subclass.methods[name] = method
+			}
+			vm.pop()
+		case OpMethod:
+			method := vm.peek(0).(*vmClosure)
+			class := vm.peek(1).(*vmClass)
+			class.methods[readString()] = method
+			vm.pop()
+		case OpBuildList:
+			count := readShort()
+			elements := slices.Clone(vm.stack[len(vm.stack)-count:])
+			vm.stack = vm.stack[:len(vm.stack)-count]
+			vm.push(&LoxList{elements: elements})
+		case OpBuildString:
+			count := readShort()
+			var built strings.Builder
+			for _, part := range vm.stack[len(vm.stack)-count:] {
+				built.WriteString(loxStringify(part))
+			}
+			vm.stack = vm.stack[:len(vm.stack)-count]
+			vm.push(built.String())
+		case OpBuildMap:
+			count := readShort()
+			m := newLoxMap()
+			entries := vm.stack[len(vm.stack)-2*count:]
+			for i := 0; i < len(entries); i += 2 {
+				if err := m.Set(entries[i], entries[i+1]); err != nil {
+					return nil, err
+				}
+			}
+			vm.stack = vm.stack[:len(vm.stack)-2*count]
+			vm.push(m)
+		case OpGetIndex:
+			collection, ok := asCollection(vm.peek(1))
+			if !ok {
+				return nil, runtimeErrorf("Only lists, maps and strings can be indexed.")
+			}
+			element, err := collection.Get(vm.peek(0))
+			if err != nil {
+				return nil, err
+			}
+			vm.stack = vm.stack[:len(vm.stack)-2]
+			vm.push(element)
+		case OpSetIndex:
+			collection, ok := asCollection(vm.peek(2))
+			if !ok {
+				return nil, runtimeErrorf("Only lists, maps and strings can be indexed.")
+			}
+			value := vm.peek(0)
+			if err := collection.Set(vm.peek(1), value); err != nil {
+				return nil, err
+			}
+			vm.stack = vm.stack[:len(vm.stack)-3]
+			vm.push(value)
+		case OpSlice:
+			slice, err := sliceValue(vm.peek(2), vm.peek(1), vm.peek(0))
+			if err != nil {
+				return nil, err
+			}
+			vm.stack = vm.stack[:len(vm.stack)-3]
+			vm.push(slice)
+		case OpImport:
+			path := readString()
+			if vm.modules == nil {
+				return nil, runtimeErrorf("Can't import modules without an Interpreter.")
+			}
+			module, err := vm.modules.load(path)
+			if err != nil {
+				return nil, err
+			}
+			// running the module may have grown the frame stack
+			loadFrame()
+			vm.push(module)
+		case OpGetExport:
+			value, err := vm.peek(0).(*LoxModule).export(readString())
+			if err != nil {
+				return nil, err
+			}
+			vm.push(value)
+		case OpTry:
+			offset := readJump()
+			vm.handlers = append(vm.handlers, tryHandler{
+				frame:      len(vm.frames) - 1,
+				stackDepth: len(vm.stack),
+				ip:         frame.ip + offset,
+			})
+		case OpEndTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case OpThrow:
+			return nil, thrownError(vm.pop())
+		case OpCatch:
+			vm.stack[len(vm.stack)-1] = vm.caughtValue(vm.peek(0).(*RuntimeError))
+		case OpRethrow:
+			return nil, vm.pop().(*RuntimeError)
+		case OpWide:
+			wide = readShort()
+		default:
+			panic(fmt.Sprintf("unknown opcode %d", code[frame.ip-1]))
+		}
+	}
+}
+
+// DisassembleAll lists the script and every function nested in it
+func DisassembleAll(w io.Writer, function *vmFunction) {
+	function.chunk.Disassemble(w, function.String())
+	for _, constant := range function.chunk.Constants {
+		if nested, ok := constant.(*vmFunction); ok {
+			DisassembleAll(w, nested)
+		}
+	}
+}